@@ -0,0 +1,56 @@
+package tftp
+
+import (
+	"bytes"
+	"net"
+	"text/template"
+)
+
+// RejectionTemplateData is the data made available to a Rejection's
+// message template.
+type RejectionTemplateData struct {
+	Addr     string
+	Filename string
+}
+
+// Rejection is returned by a Validator to deny a request. Message may
+// contain Go template actions referencing .Addr and .Filename (e.g. to
+// embed a support URL alongside the offending filename), letting
+// operators customize the exact ERROR text clients see without patching
+// code.
+type Rejection struct {
+	Code    ErrorCode
+	Message string
+}
+
+// render expands the Rejection's message template against the
+// requesting client's address and filename. A template error falls back
+// to the raw, unexpanded message so a misconfigured template never
+// blocks the rejection itself from reaching the client.
+func (r *Rejection) render(addr net.Addr, filename string) string {
+	tmpl, err := template.New("rejection").Parse(r.Message)
+	if err != nil {
+		return r.Message
+	}
+	var buf bytes.Buffer
+	data := RejectionTemplateData{Addr: addr.String(), Filename: filename}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return r.Message
+	}
+	return buf.String()
+}
+
+// Validator inspects an incoming request's Session before it reaches the
+// Handler and may deny it by returning a non-nil Rejection. Validators
+// run in the order they were registered; the first rejection wins.
+type Validator func(s *Session) *Rejection
+
+// UploadValidator inspects a WRQ's fully assembled content — already
+// decompressed, if OptCompress was negotiated — after the transfer
+// completes but before it reaches Handler.WriteFile, and may deny it by
+// returning a non-nil Rejection: checking upload size, magic bytes,
+// config syntax, or running it through an external scanner, none of
+// which Validator can do since the content doesn't exist yet at request
+// time. ContentValidators run in the order they were registered; the
+// first rejection wins.
+type UploadValidator func(s *Session, data []byte) *Rejection
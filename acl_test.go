@@ -0,0 +1,30 @@
+package tftp
+
+import (
+	"net"
+	"testing"
+)
+
+func TestRejectionRender(t *testing.T) {
+	rej := &Rejection{
+		Code:    ErrCodeAccessViolation,
+		Message: "{{.Filename}} denied for {{.Addr}}: see https://support.example/tftp",
+	}
+	addr := &net.UDPAddr{IP: net.ParseIP("10.0.0.5"), Port: 6000}
+
+	got := rej.render(addr, "secret.img")
+	want := "secret.img denied for 10.0.0.5:6000: see https://support.example/tftp"
+	if got != want {
+		t.Errorf("render() = %q, want %q", got, want)
+	}
+}
+
+func TestRejectionRenderInvalidTemplateFallsBack(t *testing.T) {
+	rej := &Rejection{Code: ErrCodeAccessViolation, Message: "bad {{.Filename"}
+	addr := &net.UDPAddr{IP: net.ParseIP("10.0.0.5"), Port: 6000}
+
+	got := rej.render(addr, "secret.img")
+	if got != rej.Message {
+		t.Errorf("render() = %q, want raw message %q", got, rej.Message)
+	}
+}
@@ -0,0 +1,60 @@
+// Package logrus adapts a *logrus.Logger (or logrus.FieldLogger) to
+// tftp.Logger, for teams standardized on logrus rather than this
+// package's own minimal Logger interface. It lives in its own module so
+// pulling it in doesn't saddle every tftp-go user with a logrus
+// dependency.
+package logrus
+
+import (
+	"github.com/sirupsen/logrus"
+
+	tftp "github.com/cjvirtucio87/tftp-go"
+)
+
+// Logger adapts a logrus.FieldLogger to tftp.Logger, tftp.DebugLogger,
+// tftp.WarnLogger, and tftp.FieldLogger. Printf messages are logged at
+// logrus' Info level, Warnf at Warn, and Debugf — the optional, much
+// chattier per-packet trace, see tftp.DebugLogger — at Debug.
+type Logger struct {
+	l logrus.FieldLogger
+}
+
+// New returns a Logger that writes through l. Both *logrus.Logger and
+// *logrus.Entry satisfy logrus.FieldLogger.
+func New(l logrus.FieldLogger) *Logger {
+	return &Logger{l: l}
+}
+
+// Printf logs an info-level message.
+func (a *Logger) Printf(format string, args ...any) {
+	a.l.Infof(format, args...)
+}
+
+// Debugf logs a debug-level message, implementing the optional
+// tftp.DebugLogger capability.
+func (a *Logger) Debugf(format string, args ...any) {
+	a.l.Debugf(format, args...)
+}
+
+// Warnf logs a warn-level message, implementing the optional
+// tftp.WarnLogger capability.
+func (a *Logger) Warnf(format string, args ...any) {
+	a.l.Warnf(format, args...)
+}
+
+// WithFields returns a Logger whose messages carry keyvals as
+// structured fields, implementing the optional tftp.FieldLogger
+// capability. keyvals is an alternating key, value, key, value... list;
+// an odd-length or non-string-keyed list is reported with the key
+// "field" so a caller mistake surfaces instead of silently dropping.
+func (a *Logger) WithFields(keyvals ...any) tftp.Logger {
+	fields := logrus.Fields{}
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		key, ok := keyvals[i].(string)
+		if !ok {
+			key = "field"
+		}
+		fields[key] = keyvals[i+1]
+	}
+	return &Logger{l: a.l.WithFields(fields)}
+}
@@ -0,0 +1,41 @@
+package logrus
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	tftp "github.com/cjvirtucio87/tftp-go"
+)
+
+func TestLoggerImplementsCapabilities(t *testing.T) {
+	var buf bytes.Buffer
+	base := logrus.New()
+	base.Out = &buf
+	base.SetLevel(logrus.DebugLevel)
+	l := New(base)
+
+	var _ tftp.Logger = l
+	var _ tftp.DebugLogger = l
+	var _ tftp.WarnLogger = l
+	var _ tftp.FieldLogger = l
+
+	l.Printf("RRQ %s from %s", "boot.img", "10.0.0.5")
+	l.Debugf("sent block=%d", 1)
+	l.Warnf("retry budget exhausted")
+	l.WithFields("client", "10.0.0.5").Printf("rejected %s", "boot.img")
+
+	out := buf.String()
+	for _, want := range []string{
+		"level=info", "RRQ boot.img from 10.0.0.5",
+		"level=debug", "sent block=1",
+		"level=warning", "retry budget exhausted",
+		`client=10.0.0.5`, "rejected boot.img",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output %q missing %q", out, want)
+		}
+	}
+}
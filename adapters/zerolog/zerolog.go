@@ -0,0 +1,60 @@
+// Package zerolog adapts a zerolog.Logger to tftp.Logger, for teams
+// standardized on zerolog rather than this package's own minimal Logger
+// interface. It lives in its own module so pulling it in doesn't saddle
+// every tftp-go user with a zerolog dependency.
+package zerolog
+
+import (
+	"fmt"
+
+	"github.com/rs/zerolog"
+
+	tftp "github.com/cjvirtucio87/tftp-go"
+)
+
+// Logger adapts a zerolog.Logger to tftp.Logger, tftp.DebugLogger,
+// tftp.WarnLogger, and tftp.FieldLogger. Printf messages are logged at
+// zerolog's Info level, Warnf at Warn, and Debugf — the optional, much
+// chattier per-packet trace, see tftp.DebugLogger — at Debug.
+type Logger struct {
+	l zerolog.Logger
+}
+
+// New returns a Logger that writes through l.
+func New(l zerolog.Logger) *Logger {
+	return &Logger{l: l}
+}
+
+// Printf logs an info-level message.
+func (a *Logger) Printf(format string, args ...any) {
+	a.l.Info().Msg(fmt.Sprintf(format, args...))
+}
+
+// Debugf logs a debug-level message, implementing the optional
+// tftp.DebugLogger capability.
+func (a *Logger) Debugf(format string, args ...any) {
+	a.l.Debug().Msg(fmt.Sprintf(format, args...))
+}
+
+// Warnf logs a warn-level message, implementing the optional
+// tftp.WarnLogger capability.
+func (a *Logger) Warnf(format string, args ...any) {
+	a.l.Warn().Msg(fmt.Sprintf(format, args...))
+}
+
+// WithFields returns a Logger whose messages carry keyvals as
+// structured fields, implementing the optional tftp.FieldLogger
+// capability. keyvals is an alternating key, value, key, value... list;
+// an odd-length or non-string-keyed list is reported with the key
+// "field" so a caller mistake surfaces instead of silently dropping.
+func (a *Logger) WithFields(keyvals ...any) tftp.Logger {
+	ctx := a.l.With()
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		key, ok := keyvals[i].(string)
+		if !ok {
+			key = "field"
+		}
+		ctx = ctx.Interface(key, keyvals[i+1])
+	}
+	return &Logger{l: ctx.Logger()}
+}
@@ -0,0 +1,91 @@
+package tftp
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SessionInfo is the JSON-serializable view of an in-flight transfer
+// returned by Server.Sessions and GET /admin/sessions.
+type SessionInfo struct {
+	ID        uint64    `json:"id"`
+	Addr      string    `json:"addr"`
+	Filename  string    `json:"filename"`
+	Op        string    `json:"op"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// RegisterAdminHandlers wires an operator-facing JSON admin API onto
+// mux, for inspecting and cancelling transfers when a wedged client is
+// holding a goroutine and an ephemeral port open:
+//
+//   - GET /admin/sessions lists every in-flight transfer.
+//   - POST /admin/sessions/{id}/cancel aborts one by ID.
+//   - POST /admin/drain and POST /admin/undrain toggle whether the
+//     server accepts new requests; transfers already in flight are
+//     unaffected by either.
+//
+// As with RegisterHealthHandlers, the server never starts its own HTTP
+// listener; embedders plug this into whatever mux already serves their
+// process's other operator endpoints, and are expected to put it behind
+// authentication themselves, since nothing here restricts who may
+// cancel a transfer or drain the server.
+func (s *Server) RegisterAdminHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/admin/sessions", s.serveAdminSessions)
+	mux.HandleFunc("/admin/sessions/", s.serveAdminSessionCancel)
+	mux.HandleFunc("/admin/drain", s.serveAdminDrain)
+	mux.HandleFunc("/admin/undrain", s.serveAdminUndrain)
+}
+
+func (s *Server) serveAdminSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "tftp: method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.Sessions())
+}
+
+func (s *Server) serveAdminSessionCancel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "tftp: method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/admin/sessions/")
+	idStr, ok := strings.CutSuffix(path, "/cancel")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "tftp: invalid session id", http.StatusBadRequest)
+		return
+	}
+	if !s.CancelSession(id) {
+		http.Error(w, "tftp: no such session", http.StatusNotFound)
+		return
+	}
+	w.Write([]byte("ok"))
+}
+
+func (s *Server) serveAdminDrain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "tftp: method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.Drain()
+	w.Write([]byte("ok"))
+}
+
+func (s *Server) serveAdminUndrain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "tftp: method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.Undrain()
+	w.Write([]byte("ok"))
+}
@@ -0,0 +1,128 @@
+package tftp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAdminSessionsListsAndCancelsTransfer(t *testing.T) {
+	h := &blockingHandler{unblock: make(chan struct{})}
+	s := NewServer("", h)
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s.conn = conn
+	go s.Serve(conn)
+	defer conn.Close()
+
+	mux := http.NewServeMux()
+	s.RegisterAdminHandlers(mux)
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = 300 * time.Millisecond
+	c.Retries = 0
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := c.GetBytes("file.bin", "octet")
+		errCh <- err
+	}()
+
+	var sessions []SessionInfo
+	deadline := time.Now().Add(2 * time.Second)
+	for len(sessions) == 0 && time.Now().Before(deadline) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/sessions", nil))
+		json.Unmarshal(rec.Body.Bytes(), &sessions)
+		if len(sessions) == 0 {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("len(sessions) = %d, want 1", len(sessions))
+	}
+	if sessions[0].Filename != "file.bin" {
+		t.Errorf("Filename = %q, want file.bin", sessions[0].Filename)
+	}
+
+	rec := httptest.NewRecorder()
+	path := fmt.Sprintf("/admin/sessions/%d/cancel", sessions[0].ID)
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("cancel status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	close(h.unblock)
+
+	select {
+	case err := <-errCh:
+		if err == nil {
+			t.Error("expected GetBytes to fail once its session was cancelled")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the cancelled transfer to abort")
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/sessions/99999/cancel", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("cancel of unknown session = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestAdminDrainRejectsNewRequests(t *testing.T) {
+	h := &memHandler{files: map[string][]byte{"file.bin": []byte("hi")}}
+	s := NewServer("", h)
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s.conn = conn
+	go s.Serve(conn)
+	defer conn.Close()
+
+	mux := http.NewServeMux()
+	s.RegisterAdminHandlers(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/drain", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("drain status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	if _, err := c.GetBytes("file.bin", "octet"); err == nil {
+		t.Fatal("expected a request during drain to fail")
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/undrain", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("undrain status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	if _, err := c.GetBytes("file.bin", "octet"); err != nil {
+		t.Fatalf("GetBytes after undrain: %v", err)
+	}
+}
+
+// blockingHandler's ReadFile blocks until unblock is closed, so a test
+// can be sure a transfer is still in flight (parked before sendData
+// ever touches the network) when it inspects the admin session
+// registry.
+type blockingHandler struct {
+	unblock chan struct{}
+}
+
+func (h *blockingHandler) ReadFile(name string) ([]byte, error) {
+	<-h.unblock
+	return bytes.Repeat([]byte("x"), 4), nil
+}
+
+func (h *blockingHandler) WriteFile(name string, data []byte) error { return nil }
@@ -0,0 +1,112 @@
+package tftp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer guarded by a mutex, for tests that write
+// from a server's background goroutine (AuditLogger.record, here) while
+// polling the same buffer from the test goroutine.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Len()
+}
+
+func (b *syncBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]byte(nil), b.buf.Bytes()...)
+}
+
+func TestAuditLoggerRecordsSuccessAndFailure(t *testing.T) {
+	var buf bytes.Buffer
+	a := NewAuditLogger(&buf)
+
+	sess := Session{Addr: &net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 6900}, Filename: "firmware.bin", Op: OpRRQ}
+	a.record(sess, Stats{Bytes: 2048, Duration: 250 * time.Millisecond}, nil)
+	a.record(sess, Stats{Bytes: 512}, NewError(ErrCodeDiskFull, "disk full"))
+
+	scanner := bufio.NewScanner(&buf)
+	var records []AuditRecord
+	for scanner.Scan() {
+		var rec AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("Unmarshal: %v", err)
+		}
+		records = append(records, rec)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+
+	ok := records[0]
+	if ok.Client != "10.0.0.1:6900" || ok.Filename != "firmware.bin" || ok.Direction != "read" {
+		t.Errorf("ok record = %+v, want client/filename/direction set from sess", ok)
+	}
+	if ok.Bytes != 2048 || ok.DurationMS != 250 || ok.Result != "ok" || ok.ErrorCode != nil {
+		t.Errorf("ok record = %+v, want a clean success", ok)
+	}
+
+	failed := records[1]
+	if failed.Result != "error" || failed.ErrorCode == nil || *failed.ErrorCode != ErrCodeDiskFull {
+		t.Errorf("failed record = %+v, want result=error with ErrCodeDiskFull", failed)
+	}
+}
+
+func TestAuditLoggerNilIsNoOp(t *testing.T) {
+	var a *AuditLogger
+	a.record(Session{}, Stats{}, nil)
+}
+
+func TestServeWRQWritesAuditRecord(t *testing.T) {
+	buf := &syncBuffer{}
+	h := &memHandler{files: map[string][]byte{}}
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s := NewServer("", h)
+	s.AuditLog = NewAuditLogger(buf)
+	s.conn = conn
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	if _, err := c.Put(context.Background(), "upload.bin", "octet", bytes.NewReader([]byte("payload"))); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for buf.Len() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	var rec AuditRecord
+	if err := json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &rec); err != nil {
+		t.Fatalf("Unmarshal: %v (buf=%q)", err, buf.Bytes())
+	}
+	if rec.Filename != "upload.bin" || rec.Direction != "write" || rec.Result != "ok" {
+		t.Errorf("record = %+v, want a clean write of upload.bin", rec)
+	}
+}
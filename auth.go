@@ -0,0 +1,60 @@
+package tftp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// OptAuthTimestamp and OptAuthSignature are paired private,
+// vendor-prefixed options (RFC 2347 doesn't standardize one) used by
+// Client.AuthKey/Server.AuthKey to sign a request: OptAuthTimestamp
+// carries the Unix time (seconds) the request was signed at, and
+// OptAuthSignature carries the hex-encoded HMAC-SHA256 computed by
+// signRequest over the filename and timestamp. A server with AuthKey
+// unset ignores both, per the usual unknown-option rule.
+const (
+	OptAuthTimestamp = "tftp-go-xauth-ts"
+	OptAuthSignature = "tftp-go-xauth-sig"
+)
+
+// DefaultAuthSkew is how far a signed request's timestamp may drift
+// from a verifier's own clock before Server.AuthSkew, left at its zero
+// value, rejects it.
+const DefaultAuthSkew = 30 * time.Second
+
+// signRequest computes the hex-encoded HMAC-SHA256 over filename and
+// timestamp keyed by key — the construction both Client.AuthKey (to
+// sign a request) and Server.AuthKey (to verify one) use.
+func signRequest(key []byte, filename string, timestamp int64) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(filename))
+	mac.Write([]byte(":"))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifyRequestAuth reports whether opts carries a signature over
+// filename that's valid for key and whose timestamp is within skew of
+// now.
+func verifyRequestAuth(key []byte, filename string, opts Options, skew time.Duration, now time.Time) bool {
+	tsStr, ok := opts[OptAuthTimestamp]
+	if !ok {
+		return false
+	}
+	sig, ok := opts[OptAuthSignature]
+	if !ok {
+		return false
+	}
+	ts, err := strconv.ParseInt(tsStr, 10, 64)
+	if err != nil {
+		return false
+	}
+	if d := now.Sub(time.Unix(ts, 0)); d > skew || d < -skew {
+		return false
+	}
+	want := signRequest(key, filename, ts)
+	return hmac.Equal([]byte(want), []byte(sig))
+}
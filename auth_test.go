@@ -0,0 +1,89 @@
+package tftp
+
+import (
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerifyRequestAuthAcceptsAFreshValidSignature(t *testing.T) {
+	key := []byte("shared-secret")
+	now := time.Now()
+	ts := now.Unix()
+	opts := Options{
+		OptAuthTimestamp: strconv.FormatInt(ts, 10),
+		OptAuthSignature: signRequest(key, "boot.img", ts),
+	}
+	if !verifyRequestAuth(key, "boot.img", opts, DefaultAuthSkew, now) {
+		t.Error("verifyRequestAuth: want true for a freshly signed request")
+	}
+}
+
+func TestVerifyRequestAuthRejectsWrongKeyFilenameOrMissingOptions(t *testing.T) {
+	key := []byte("shared-secret")
+	now := time.Now()
+	ts := now.Unix()
+	valid := Options{
+		OptAuthTimestamp: strconv.FormatInt(ts, 10),
+		OptAuthSignature: signRequest(key, "boot.img", ts),
+	}
+
+	if verifyRequestAuth([]byte("wrong-secret"), "boot.img", valid, DefaultAuthSkew, now) {
+		t.Error("verifyRequestAuth: want false for the wrong key")
+	}
+	if verifyRequestAuth(key, "other.img", valid, DefaultAuthSkew, now) {
+		t.Error("verifyRequestAuth: want false for a mismatched filename")
+	}
+	if verifyRequestAuth(key, "boot.img", Options{OptAuthSignature: valid[OptAuthSignature]}, DefaultAuthSkew, now) {
+		t.Error("verifyRequestAuth: want false when the timestamp option is missing")
+	}
+	if verifyRequestAuth(key, "boot.img", Options{OptAuthTimestamp: valid[OptAuthTimestamp]}, DefaultAuthSkew, now) {
+		t.Error("verifyRequestAuth: want false when the signature option is missing")
+	}
+}
+
+func TestVerifyRequestAuthRejectsStaleTimestamp(t *testing.T) {
+	key := []byte("shared-secret")
+	now := time.Now()
+	ts := now.Add(-time.Minute).Unix()
+	opts := Options{
+		OptAuthTimestamp: strconv.FormatInt(ts, 10),
+		OptAuthSignature: signRequest(key, "boot.img", ts),
+	}
+	if verifyRequestAuth(key, "boot.img", opts, DefaultAuthSkew, now) {
+		t.Error("verifyRequestAuth: want false for a timestamp older than the allowed skew")
+	}
+}
+
+func TestServeRRQRequiresAndAcceptsAValidSignature(t *testing.T) {
+	want := []byte("sensitive firmware image")
+	h := &memHandler{files: map[string][]byte{"secret.img": want}}
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s := NewServer("", h)
+	s.AuthKey = []byte("provisioning-vlan-secret")
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	unsigned := NewClient(conn.LocalAddr().String())
+	unsigned.Timeout = time.Second
+	unsigned.Retries = 1
+	if _, err := unsigned.GetBytes("secret.img", "octet"); err == nil {
+		t.Fatal("GetBytes without AuthKey: want an error")
+	}
+
+	signed := NewClient(conn.LocalAddr().String())
+	signed.Timeout = time.Second
+	signed.AuthKey = s.AuthKey
+	got, err := signed.GetBytes("secret.img", "octet")
+	if err != nil {
+		t.Fatalf("GetBytes with AuthKey: %v", err)
+	}
+	if string(got) != string(want) {
+		t.Fatalf("GetBytes = %q, want %q", got, want)
+	}
+}
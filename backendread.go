@@ -0,0 +1,101 @@
+package tftp
+
+import (
+	"errors"
+	"sync"
+)
+
+// errTooManyReaders is returned by backendReadLimiter.Do when a
+// filename already has MaxReadersPerFile readers waiting on its
+// backend read.
+var errTooManyReaders = errors.New("tftp: too many concurrent readers for this file")
+
+// backendCall tracks one in-flight backend read, shared by every reader
+// collapsed onto it.
+type backendCall struct {
+	wg   sync.WaitGroup
+	data []byte
+	err  error
+}
+
+// backendReadLimiter collapses concurrent reads of the same filename
+// with the singleflight pattern and caps how many readers may be
+// waiting on a single filename's backend read at once, so a slow or
+// remote backend (Handler.ReadFile, Upstream) isn't hammered with
+// hundreds of identical requests the moment a popular image goes out to
+// a fleet simultaneously.
+type backendReadLimiter struct {
+	// collapse, if true, shares one backend read across every reader
+	// currently waiting on the same filename instead of letting each
+	// trigger its own.
+	collapse bool
+	// maxReadersPerFile, if greater than zero, is the most readers
+	// allowed to be waiting on a single filename's backend read at
+	// once, regardless of collapse.
+	maxReadersPerFile int
+
+	mu    sync.Mutex
+	calls map[string]*backendCall
+	// waiting counts readers currently inside Do per filename, tracked
+	// separately from calls so maxReadersPerFile is enforced against
+	// every waiter even though they share a single in-flight call.
+	waiting map[string]int
+}
+
+func newBackendReadLimiter(collapse bool, maxReadersPerFile int) *backendReadLimiter {
+	return &backendReadLimiter{
+		collapse:          collapse,
+		maxReadersPerFile: maxReadersPerFile,
+		calls:             make(map[string]*backendCall),
+		waiting:           make(map[string]int),
+	}
+}
+
+// Do calls fn for filename, or shares the result of a call already in
+// flight for the same filename when collapse is set. It returns
+// errTooManyReaders without calling fn if doing so would put more than
+// maxReadersPerFile readers on filename at once.
+func (l *backendReadLimiter) Do(filename string, fn func() ([]byte, error)) ([]byte, error) {
+	l.mu.Lock()
+	if l.maxReadersPerFile > 0 && l.waiting[filename] >= l.maxReadersPerFile {
+		l.mu.Unlock()
+		return nil, errTooManyReaders
+	}
+	l.waiting[filename]++
+
+	if l.collapse {
+		if call, ok := l.calls[filename]; ok {
+			l.mu.Unlock()
+			call.wg.Wait()
+			l.doneWaiting(filename)
+			return call.data, call.err
+		}
+		call := &backendCall{}
+		call.wg.Add(1)
+		l.calls[filename] = call
+		l.mu.Unlock()
+
+		call.data, call.err = fn()
+		call.wg.Done()
+
+		l.mu.Lock()
+		delete(l.calls, filename)
+		l.mu.Unlock()
+		l.doneWaiting(filename)
+		return call.data, call.err
+	}
+	l.mu.Unlock()
+
+	data, err := fn()
+	l.doneWaiting(filename)
+	return data, err
+}
+
+func (l *backendReadLimiter) doneWaiting(filename string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.waiting[filename]--
+	if l.waiting[filename] <= 0 {
+		delete(l.waiting, filename)
+	}
+}
@@ -0,0 +1,158 @@
+package tftp
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBackendReadLimiterCollapsesConcurrentReads(t *testing.T) {
+	l := newBackendReadLimiter(true, 0)
+	var calls atomic.Int32
+	release := make(chan struct{})
+
+	fn := func() ([]byte, error) {
+		calls.Add(1)
+		<-release
+		return []byte("data"), nil
+	}
+
+	results := make(chan []byte, 5)
+	for i := 0; i < 5; i++ {
+		go func() {
+			data, err := l.Do("file.bin", fn)
+			if err != nil {
+				t.Errorf("Do: %v", err)
+			}
+			results <- data
+		}()
+	}
+
+	// Give every goroutine a chance to reach Do and collapse onto the
+	// single in-flight call before it's allowed to finish.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	for i := 0; i < 5; i++ {
+		<-results
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("fn called %d times, want exactly 1", n)
+	}
+}
+
+func TestBackendReadLimiterWithoutCollapseCallsEveryTime(t *testing.T) {
+	l := newBackendReadLimiter(false, 0)
+	var calls atomic.Int32
+	fn := func() ([]byte, error) {
+		calls.Add(1)
+		return []byte("data"), nil
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := l.Do("file.bin", fn); err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("fn called %d times, want 3", n)
+	}
+}
+
+func TestBackendReadLimiterRejectsPastMaxReaders(t *testing.T) {
+	l := newBackendReadLimiter(false, 2)
+	release := make(chan struct{})
+	blocked := func() ([]byte, error) {
+		<-release
+		return []byte("data"), nil
+	}
+
+	done := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := l.Do("file.bin", blocked)
+			done <- err
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	if _, err := l.Do("file.bin", blocked); !errors.Is(err, errTooManyReaders) {
+		t.Fatalf("Do: err = %v, want errTooManyReaders", err)
+	}
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			t.Errorf("Do: %v", err)
+		}
+	}
+}
+
+func TestServerCollapsesIdenticalReadsUnderConcurrentRRQs(t *testing.T) {
+	want := bytes.Repeat([]byte("x"), 4096)
+	var calls atomic.Int32
+	h := &countingHandler{files: map[string][]byte{"file.bin": want}, calls: &calls}
+
+	s := NewServer("", h)
+	s.CollapseIdenticalReads = true
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s.conn = conn
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	const n = 10
+	results := make(chan []byte, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			c := NewClient(conn.LocalAddr().String())
+			c.Timeout = 2 * time.Second
+			data, err := c.GetBytes("file.bin", "octet")
+			if err != nil {
+				t.Errorf("GetBytes: %v", err)
+				results <- nil
+				return
+			}
+			results <- data
+		}()
+	}
+	for i := 0; i < n; i++ {
+		got := <-results
+		if !bytes.Equal(got, want) {
+			t.Errorf("GetBytes returned %d bytes, want %d matching", len(got), len(want))
+		}
+	}
+
+	if calls.Load() >= int32(n) {
+		t.Errorf("Handler.ReadFile called %d times for %d concurrent identical RRQs, want it collapsed below that", calls.Load(), n)
+	}
+}
+
+// countingHandler is a Handler that sleeps briefly on every ReadFile
+// (so concurrent requests actually overlap) and counts how many times
+// ReadFile ran, for asserting that CollapseIdenticalReads shared the
+// backend call across simultaneous RRQs instead of repeating it.
+type countingHandler struct {
+	files map[string][]byte
+	calls *atomic.Int32
+}
+
+func (h *countingHandler) ReadFile(name string) ([]byte, error) {
+	h.calls.Add(1)
+	time.Sleep(20 * time.Millisecond)
+	data, ok := h.files[name]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return data, nil
+}
+
+func (h *countingHandler) WriteFile(name string, data []byte) error {
+	h.files[name] = data
+	return nil
+}
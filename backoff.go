@@ -0,0 +1,8 @@
+package tftp
+
+import "time"
+
+// maxBackoff caps how long a single read attempt will wait, however
+// many retries have already elapsed. It's ExponentialRetryPolicy's
+// default Max, used whenever a caller doesn't set one.
+const maxBackoff = 4 * time.Second
@@ -0,0 +1,51 @@
+package tftp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExponentialRetryPolicyDoublesUpToMax(t *testing.T) {
+	policy := ExponentialRetryPolicy{Base: 100 * time.Millisecond, Max: 500 * time.Millisecond}
+
+	for attempt, want := range map[int]time.Duration{
+		0: 100 * time.Millisecond,
+		1: 200 * time.Millisecond,
+		2: 400 * time.Millisecond,
+		5: 500 * time.Millisecond, // capped
+	} {
+		d, ok := policy.NextDelay(attempt)
+		if !ok {
+			t.Errorf("attempt %d: ok = false, want true", attempt)
+		}
+		if d != want {
+			t.Errorf("attempt %d: NextDelay = %v, want %v", attempt, d, want)
+		}
+	}
+}
+
+func TestJitteredRetryPolicyAddsUpToQuarter(t *testing.T) {
+	base := ExponentialRetryPolicy{Base: 100 * time.Millisecond, Max: 500 * time.Millisecond}
+	policy := JitteredRetryPolicy{Policy: base}
+
+	for attempt := 0; attempt < 5; attempt++ {
+		want, _ := base.NextDelay(attempt)
+		d, ok := policy.NextDelay(attempt)
+		if !ok {
+			t.Errorf("attempt %d: ok = false, want true", attempt)
+		}
+		if d < want || d > want+want/4 {
+			t.Errorf("attempt %d: NextDelay = %v, want in [%v, %v]", attempt, d, want, want+want/4)
+		}
+	}
+}
+
+func TestConstantRetryPolicyNeverChanges(t *testing.T) {
+	policy := ConstantRetryPolicy{Delay: 250 * time.Millisecond}
+	for attempt := 0; attempt < 5; attempt++ {
+		d, ok := policy.NextDelay(attempt)
+		if !ok || d != 250*time.Millisecond {
+			t.Errorf("attempt %d: NextDelay = (%v, %v), want (250ms, true)", attempt, d, ok)
+		}
+	}
+}
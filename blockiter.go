@@ -0,0 +1,79 @@
+package tftp
+
+// blockSource supplies the payload for block i (0-indexed) of an RRQ
+// transfer on demand, plus the total block count (including the
+// trailing short/empty block that signals EOF per RFC 1350). Block(i)
+// must be deterministic: calling it more than once for the same i
+// returns the same bytes, since sendData re-sends a block whenever its
+// ACK times out or a duplicate of the previous ACK arrives. BlockIterator
+// implements blockSource over a buffer already fully loaded into
+// memory; ReaderAtBlockIterator implements it over an io.ReaderAt,
+// reading each block on demand so the whole file never has to fit in
+// RAM.
+type blockSource interface {
+	Len() int
+	Block(i int) ([]byte, error)
+}
+
+// BlockIterator computes the payload for any block of a buffered DATA
+// transfer deterministically from the full contents and negotiated
+// block size, rather than handing out pre-sliced results from a cursor
+// that advances as it's read. Calling Block with the same index always
+// returns the same bytes, which is what makes retransmitting a block
+// (the same index, re-sent after a timeout or a duplicate ACK) safe:
+// there's no per-call counter to drift out of sync with what was
+// actually put on the wire the first time.
+type BlockIterator struct {
+	data      []byte
+	blockSize int
+	n         int
+}
+
+// NewBlockIterator returns a BlockIterator over data split into
+// blockSize-sized chunks, with one extra (possibly empty) final block
+// appended when len(data) is an exact multiple of blockSize, so EOF is
+// always signaled by a short or empty block per RFC 1350.
+func NewBlockIterator(data []byte, blockSize int) *BlockIterator {
+	n := (len(data) + blockSize - 1) / blockSize
+	if len(data)%blockSize == 0 {
+		n++
+	}
+	return &BlockIterator{data: data, blockSize: blockSize, n: n}
+}
+
+// Len returns the total number of blocks, including the trailing EOF
+// block.
+func (bi *BlockIterator) Len() int {
+	return bi.n
+}
+
+// Block returns the payload for the i'th block (0-indexed). It panics if
+// i is out of range, the same contract slice indexing has.
+func (bi *BlockIterator) Block(i int) ([]byte, error) {
+	start := i * bi.blockSize
+	if start > len(bi.data) || i < 0 {
+		panic("tftp: BlockIterator index out of range")
+	}
+	end := start + bi.blockSize
+	if end > len(bi.data) {
+		end = len(bi.data)
+	}
+	return bi.data[start:end], nil
+}
+
+// offsetBlockSource presents the blocks of src starting at base as if
+// they were block 0. serveRRQ uses this for OptOffset so resuming a
+// download skips straight to the aligned block without having to
+// re-slice (or, for a streaming source, re-read) everything before it.
+type offsetBlockSource struct {
+	src  blockSource
+	base int
+}
+
+func (o offsetBlockSource) Len() int {
+	return o.src.Len() - o.base
+}
+
+func (o offsetBlockSource) Block(i int) ([]byte, error) {
+	return o.src.Block(i + o.base)
+}
@@ -0,0 +1,68 @@
+package tftp
+
+import "testing"
+
+func block(t *testing.T, bi blockSource, i int) []byte {
+	t.Helper()
+	b, err := bi.Block(i)
+	if err != nil {
+		t.Fatalf("Block(%d): %v", i, err)
+	}
+	return b
+}
+
+func TestBlockIteratorSplitsEvenly(t *testing.T) {
+	data := []byte("abcdefgh")
+	bi := NewBlockIterator(data, 4)
+
+	// Exact multiple of blockSize gets a trailing empty block to signal EOF.
+	if got, want := bi.Len(), 3; got != want {
+		t.Fatalf("Len() = %d, want %d", got, want)
+	}
+	if got, want := string(block(t, bi, 0)), "abcd"; got != want {
+		t.Errorf("Block(0) = %q, want %q", got, want)
+	}
+	if got, want := string(block(t, bi, 1)), "efgh"; got != want {
+		t.Errorf("Block(1) = %q, want %q", got, want)
+	}
+	if got, want := len(block(t, bi, 2)), 0; got != want {
+		t.Errorf("Block(2) len = %d, want %d", got, want)
+	}
+}
+
+func TestBlockIteratorShortFinalBlock(t *testing.T) {
+	data := []byte("abcde")
+	bi := NewBlockIterator(data, 4)
+
+	if got, want := bi.Len(), 2; got != want {
+		t.Fatalf("Len() = %d, want %d", got, want)
+	}
+	if got, want := string(block(t, bi, 0)), "abcd"; got != want {
+		t.Errorf("Block(0) = %q, want %q", got, want)
+	}
+	if got, want := string(block(t, bi, 1)), "e"; got != want {
+		t.Errorf("Block(1) = %q, want %q", got, want)
+	}
+}
+
+func TestBlockIteratorEmptyData(t *testing.T) {
+	bi := NewBlockIterator(nil, 4)
+
+	if got, want := bi.Len(), 1; got != want {
+		t.Fatalf("Len() = %d, want %d", got, want)
+	}
+	if got, want := len(block(t, bi, 0)), 0; got != want {
+		t.Errorf("Block(0) len = %d, want %d", got, want)
+	}
+}
+
+func TestBlockIteratorBlockIsDeterministic(t *testing.T) {
+	data := []byte("abcdefgh")
+	bi := NewBlockIterator(data, 4)
+
+	first := block(t, bi, 0)
+	second := block(t, bi, 0)
+	if string(first) != string(second) {
+		t.Fatalf("Block(0) returned %q then %q, want identical results for a retransmit", first, second)
+	}
+}
@@ -0,0 +1,148 @@
+package tftp
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+)
+
+// fakePeer is a minimal UDP endpoint for driving receiveDataFrom with a
+// hand-crafted sequence of DATA packets.
+func newFakePeer(t *testing.T) (*net.UDPConn, *net.UDPConn) {
+	t.Helper()
+	client, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	server, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	t.Cleanup(func() { client.Close(); server.Close() })
+	return client, server
+}
+
+func TestReceiveDataFromDiscardsAndReacksDuplicate(t *testing.T) {
+	client, server := newFakePeer(t)
+	clientAddr := client.LocalAddr().(*net.UDPAddr)
+
+	nego := NegotiatedOptions{BlockSize: DefaultBlockSize, WindowSize: DefaultWindowSize}
+	// A full-size first block so the transfer isn't considered complete
+	// after just one block (a short payload signals EOF).
+	firstPayload := bytes.Repeat([]byte("a"), DefaultBlockSize)
+	first := &DATA{Block: 1, Payload: firstPayload}
+
+	var buf bytes.Buffer
+	done := make(chan error, 1)
+	go func() {
+		done <- receiveDataFrom(client, server.LocalAddr().(*net.UDPAddr), nego, time.Second, 3, first, &buf, nil, -1, nil, nil, nil, nil)
+	}()
+
+	// Re-send the already-ACKed block 1 before sending block 2; the
+	// client should re-ACK it without writing it to buf again.
+	ackBuf := make([]byte, 4)
+	mustReadACK(t, server, clientAddr, ackBuf) // ACK for block 1
+	dup, _ := first.MarshalBinary()
+	server.WriteToUDP(dup, clientAddr)
+	mustReadACK(t, server, clientAddr, ackBuf) // duplicate re-ACKed
+
+	last := &DATA{Block: 2, Payload: []byte("second")}
+	lastBytes, _ := last.MarshalBinary()
+	server.WriteToUDP(lastBytes, clientAddr)
+	mustReadACK(t, server, clientAddr, ackBuf)
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("receiveDataFrom: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for receiveDataFrom")
+	}
+
+	want := string(firstPayload) + "second"
+	if got := buf.String(); got != want {
+		t.Fatalf("buf has %d bytes, want %d (duplicate must not be written twice)", len(got), len(want))
+	}
+}
+
+func TestReceiveDataFromErrorsOnBlockGap(t *testing.T) {
+	client, server := newFakePeer(t)
+	clientAddr := client.LocalAddr().(*net.UDPAddr)
+
+	nego := NegotiatedOptions{BlockSize: DefaultBlockSize, WindowSize: DefaultWindowSize}
+	first := &DATA{Block: 1, Payload: bytes.Repeat([]byte("a"), DefaultBlockSize)}
+
+	var buf bytes.Buffer
+	done := make(chan error, 1)
+	go func() {
+		done <- receiveDataFrom(client, server.LocalAddr().(*net.UDPAddr), nego, time.Second, 3, first, &buf, nil, -1, nil, nil, nil, nil)
+	}()
+
+	ackBuf := make([]byte, 4)
+	mustReadACK(t, server, clientAddr, ackBuf) // ACK for block 1
+
+	// Skip straight to block 4 instead of sending block 2.
+	gap := &DATA{Block: 4, Payload: []byte("gap")}
+	gapBytes, _ := gap.MarshalBinary()
+	server.WriteToUDP(gapBytes, clientAddr)
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("expected an error for a gap in block numbers")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for receiveDataFrom to report the gap")
+	}
+}
+
+func TestReceiveDataFromDalliesAfterFinalACK(t *testing.T) {
+	client, server := newFakePeer(t)
+	clientAddr := client.LocalAddr().(*net.UDPAddr)
+
+	nego := NegotiatedOptions{BlockSize: DefaultBlockSize, WindowSize: DefaultWindowSize}
+	last := &DATA{Block: 1, Payload: []byte("only block")}
+
+	var buf bytes.Buffer
+	done := make(chan error, 1)
+	go func() {
+		done <- receiveDataFrom(client, server.LocalAddr().(*net.UDPAddr), nego, time.Second, 3, last, &buf, nil, -1, nil, nil, nil, nil)
+	}()
+
+	ackBuf := make([]byte, 4)
+	mustReadACK(t, server, clientAddr, ackBuf) // ACK for the final (and only) block
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("receiveDataFrom: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for receiveDataFrom")
+	}
+
+	// Simulate the server never having seen that ACK: retransmit the
+	// final block and expect the lingering dally to re-ACK it even
+	// though receiveDataFrom has already returned.
+	lastBytes, _ := last.MarshalBinary()
+	server.WriteToUDP(lastBytes, clientAddr)
+	mustReadACK(t, server, clientAddr, ackBuf)
+}
+
+func mustReadACK(t *testing.T, conn *net.UDPConn, from *net.UDPAddr, buf []byte) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, addr, err := conn.ReadFromUDP(buf)
+	if err != nil {
+		t.Fatalf("ReadFromUDP: %v", err)
+	}
+	if !sameHost(addr, from) {
+		t.Fatalf("ACK from unexpected address %v, want %v", addr, from)
+	}
+	var ack ACK
+	if err := ack.Decode(buf[:n]); err != nil {
+		t.Fatalf("Decode ACK: %v", err)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build windows || plan9
+
+package tftp
+
+import (
+	"errors"
+	"net"
+)
+
+// enableBroadcast is unavailable on this platform. Client.Broadcast
+// fails fast rather than silently sending what the caller believes is a
+// broadcast RRQ as a plain unicast one.
+func enableBroadcast(conn *net.UDPConn) error {
+	return errors.New("tftp: broadcast discovery is not supported on this platform")
+}
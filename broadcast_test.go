@@ -0,0 +1,40 @@
+package tftp
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestEnableBroadcastSetsSockopt(t *testing.T) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer conn.Close()
+
+	if err := enableBroadcast(conn); err != nil {
+		t.Fatalf("enableBroadcast: %v", err)
+	}
+}
+
+// TestClientBroadcastStillReachesAUnicastServer confirms that turning on
+// Client.Broadcast (and thus SO_BROADCAST) doesn't interfere with an
+// ordinary unicast transfer, since a sandboxed test environment has no
+// real LAN segment to verify true broadcast delivery against.
+func TestClientBroadcastStillReachesAUnicastServer(t *testing.T) {
+	want := bytes.Repeat([]byte("d"), DefaultBlockSize+10)
+	addr, _ := startTestServer(t, map[string][]byte{"file.bin": want})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+	c.Broadcast = true
+	got, err := c.GetBytes("file.bin", "octet")
+	if err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("GetBytes returned %d bytes, want %d", len(got), len(want))
+	}
+}
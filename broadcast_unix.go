@@ -0,0 +1,27 @@
+//go:build !windows && !plan9
+
+package tftp
+
+import (
+	"net"
+	"syscall"
+)
+
+// enableBroadcast sets SO_BROADCAST on conn's underlying socket. The
+// kernel refuses a sendto(2) to a broadcast address (RFC 919) without
+// it, so Client.Broadcast's RRQ to 255.255.255.255 or a subnet
+// directed-broadcast address would otherwise fail with EACCES instead
+// of reaching every listener on the segment.
+func enableBroadcast(conn *net.UDPConn) error {
+	rc, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var sockErr error
+	if ctrlErr := rc.Control(func(fd uintptr) {
+		sockErr = syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_BROADCAST, 1)
+	}); ctrlErr != nil {
+		return ctrlErr
+	}
+	return sockErr
+}
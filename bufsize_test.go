@@ -0,0 +1,161 @@
+package tftp
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestReceiveDataRejectsOversizedDatagram sends a DATA packet larger
+// than the negotiated block size permits and expects receiveData to
+// treat it as garbage (retrying, not parsing a truncated prefix of it)
+// until retries are exhausted.
+func TestReceiveDataRejectsOversizedDatagram(t *testing.T) {
+	receiver, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer receiver.Close()
+
+	sender, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer sender.Close()
+
+	nego := NegotiatedOptions{BlockSize: 8, WindowSize: 1}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := receiveData(receiver, sender.LocalAddr().(*net.UDPAddr), nego, 50*time.Millisecond, 1, nil, nil, nil, nil, nil)
+		done <- err
+	}()
+
+	oversized := bytes.Repeat([]byte("x"), maxPacketSize(nego.BlockSize)+32)
+	if _, err := sender.WriteToUDP(oversized, receiver.LocalAddr().(*net.UDPAddr)); err != nil {
+		t.Fatalf("WriteToUDP: %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if err != errTransferAborted {
+			t.Errorf("err = %v, want errTransferAborted (oversized datagram never treated as valid DATA)", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for receiveData to give up")
+	}
+}
+
+func TestServerNegotiateRejectsOversizedReply(t *testing.T) {
+	server, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer server.Close()
+
+	client, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer client.Close()
+
+	s := &Server{Timeout: 200 * time.Millisecond}
+	clientAddr := client.LocalAddr().(*net.UDPAddr)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.negotiate(server, clientAddr, Options{OptBlockSize: "8"}, 8)
+	}()
+
+	oackBuf := make([]byte, 128)
+	client.SetReadDeadline(time.Now().Add(time.Second))
+	if _, _, err := client.ReadFromUDP(oackBuf); err != nil {
+		t.Fatalf("expected OACK: %v", err)
+	}
+
+	oversized := bytes.Repeat([]byte("y"), maxPacketSize(8)+32)
+	if _, err := client.WriteToUDP(oversized, server.LocalAddr().(*net.UDPAddr)); err != nil {
+		t.Fatalf("WriteToUDP: %v", err)
+	}
+
+	select {
+	case err := <-errCh:
+		if err != errDatagramTooLarge {
+			t.Errorf("err = %v, want errDatagramTooLarge", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for negotiate to reject the oversized reply")
+	}
+}
+
+// TestServerNegotiateRejectsUnexpectedTID sends the ACK of block 0 from
+// a different port than the one negotiate expects, and expects it to be
+// answered with ErrCodeUnknownID and ignored rather than aborting the
+// negotiation still in progress with the real client.
+func TestServerNegotiateRejectsUnexpectedTID(t *testing.T) {
+	server, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer server.Close()
+
+	client, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer client.Close()
+
+	intruder, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer intruder.Close()
+
+	s := &Server{Timeout: time.Second}
+	clientAddr := client.LocalAddr().(*net.UDPAddr)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.negotiate(server, clientAddr, Options{OptBlockSize: "8"}, 8)
+	}()
+
+	oackBuf := make([]byte, 128)
+	client.SetReadDeadline(time.Now().Add(time.Second))
+	if _, _, err := client.ReadFromUDP(oackBuf); err != nil {
+		t.Fatalf("expected OACK: %v", err)
+	}
+
+	ack0, _ := (&ACK{Block: 0}).MarshalBinary()
+	if _, err := intruder.WriteToUDP(ack0, server.LocalAddr().(*net.UDPAddr)); err != nil {
+		t.Fatalf("WriteToUDP: %v", err)
+	}
+
+	errBuf := make([]byte, 128)
+	intruder.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := intruder.ReadFromUDP(errBuf)
+	if err != nil {
+		t.Fatalf("expected an ERROR reply to the intruding packet: %v", err)
+	}
+	var errPkt ERROR
+	if err := errPkt.Decode(errBuf[:n]); err != nil {
+		t.Fatalf("Decode ERROR: %v", err)
+	}
+	if errPkt.Code != ErrCodeUnknownID {
+		t.Errorf("Code = %d, want ErrCodeUnknownID", errPkt.Code)
+	}
+
+	// The real client's ACK should still complete the negotiation.
+	if _, err := client.WriteToUDP(ack0, server.LocalAddr().(*net.UDPAddr)); err != nil {
+		t.Fatalf("WriteToUDP: %v", err)
+	}
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Errorf("negotiate: %v, want nil (intruding packet must not abort the session)", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for negotiate to complete")
+	}
+}
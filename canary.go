@@ -0,0 +1,75 @@
+package tftp
+
+import "net"
+
+// CanaryVariant maps one group of clients to an alternate filename
+// served in place of a CanaryRule's Filename, so a candidate image can
+// be rolled out to a rack or device list ahead of the rest of the
+// fleet. Exactly one of Networks or Addrs should be set per variant.
+type CanaryVariant struct {
+	// Name identifies this variant for the audit log and
+	// OnTransferComplete, e.g. "rack-3-canary". Required: a variant
+	// with no Name still wins the match, but leaves the served
+	// Session's Variant field empty, defeating the point of a canary.
+	Name string
+
+	// Networks restricts this variant to clients whose address falls
+	// within any of these CIDR blocks.
+	Networks []*net.IPNet
+
+	// Addrs restricts this variant to clients with one of these exact
+	// IP addresses, for a canary group that doesn't share a subnet.
+	Addrs []net.IP
+
+	// Filename is served instead of the owning CanaryRule's Filename
+	// for a matching client.
+	Filename string
+}
+
+// matches reports whether ip falls within v's Networks or Addrs.
+func (v CanaryVariant) matches(ip net.IP) bool {
+	for _, n := range v.Networks {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	for _, a := range v.Addrs {
+		if a.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// CanaryRule selects an alternate filename to serve in place of
+// Filename for specific client groups. Variants are tried in order and
+// the first match wins; a client matching no Variant is served
+// Filename unchanged.
+type CanaryRule struct {
+	Filename string
+	Variants []CanaryVariant
+}
+
+// resolveCanary applies the first CanaryRule whose Filename matches
+// filename, returning the filename to actually serve and the matched
+// Variant's Name (empty if no CanaryRule or Variant applies). Only
+// *net.UDPAddr client addresses can be matched against Networks/Addrs;
+// any other net.Addr implementation leaves filename unchanged.
+func (s *Server) resolveCanary(filename string, addr net.Addr) (resolved, variant string) {
+	udpAddr, ok := addr.(*net.UDPAddr)
+	if !ok {
+		return filename, ""
+	}
+	for _, rule := range s.CanaryRules {
+		if rule.Filename != filename {
+			continue
+		}
+		for _, v := range rule.Variants {
+			if v.matches(udpAddr.IP) {
+				return v.Filename, v.Name
+			}
+		}
+		return filename, ""
+	}
+	return filename, ""
+}
@@ -0,0 +1,106 @@
+package tftp
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func mustCIDR(t *testing.T, s string) *net.IPNet {
+	t.Helper()
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		t.Fatalf("ParseCIDR(%q): %v", s, err)
+	}
+	return n
+}
+
+func TestResolveCanaryMatchesNetworkAndAddr(t *testing.T) {
+	s := &Server{CanaryRules: []CanaryRule{
+		{
+			Filename: "boot.img",
+			Variants: []CanaryVariant{
+				{Name: "rack3", Networks: []*net.IPNet{mustCIDR(t, "10.0.3.0/24")}, Filename: "boot-canary.img"},
+				{Name: "bench-unit", Addrs: []net.IP{net.ParseIP("192.168.1.50")}, Filename: "boot-dev.img"},
+			},
+		},
+	}}
+
+	cases := []struct {
+		addr        string
+		wantFile    string
+		wantVariant string
+	}{
+		{"10.0.3.5:69", "boot-canary.img", "rack3"},
+		{"192.168.1.50:69", "boot-dev.img", "bench-unit"},
+		{"10.0.4.5:69", "boot.img", ""},
+	}
+	for _, c := range cases {
+		addr, err := net.ResolveUDPAddr("udp", c.addr)
+		if err != nil {
+			t.Fatalf("ResolveUDPAddr(%q): %v", c.addr, err)
+		}
+		gotFile, gotVariant := s.resolveCanary("boot.img", addr)
+		if gotFile != c.wantFile || gotVariant != c.wantVariant {
+			t.Errorf("resolveCanary(boot.img, %s) = (%q, %q), want (%q, %q)", c.addr, gotFile, gotVariant, c.wantFile, c.wantVariant)
+		}
+	}
+}
+
+func TestResolveCanaryUnrelatedFilenameUnaffected(t *testing.T) {
+	s := &Server{CanaryRules: []CanaryRule{
+		{Filename: "boot.img", Variants: []CanaryVariant{
+			{Name: "rack3", Networks: []*net.IPNet{mustCIDR(t, "10.0.3.0/24")}, Filename: "boot-canary.img"},
+		}},
+	}}
+	addr, _ := net.ResolveUDPAddr("udp", "10.0.3.5:69")
+	gotFile, gotVariant := s.resolveCanary("other.img", addr)
+	if gotFile != "other.img" || gotVariant != "" {
+		t.Errorf("resolveCanary(other.img, ...) = (%q, %q), want (%q, %q)", gotFile, gotVariant, "other.img", "")
+	}
+}
+
+func TestServeRRQAppliesCanaryVariant(t *testing.T) {
+	stock := []byte("stock bootloader")
+	canary := []byte("canary bootloader")
+	h := &memHandler{files: map[string][]byte{
+		"boot.img":        stock,
+		"boot-canary.img": canary,
+	}}
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s := NewServer("", h)
+	s.CanaryRules = []CanaryRule{
+		{Filename: "boot.img", Variants: []CanaryVariant{
+			{Name: "localhost-canary", Addrs: []net.IP{net.IPv4(127, 0, 0, 1)}, Filename: "boot-canary.img"},
+		}},
+	}
+	sessions := make(chan Session, 1)
+	s.OnTransferComplete = func(sess Session, stats Stats, err error) {
+		sessions <- sess
+	}
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	got, err := c.GetBytes("boot.img", "octet")
+	if err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+	if string(got) != string(canary) {
+		t.Fatalf("GetBytes = %q, want the canary variant %q", got, canary)
+	}
+
+	select {
+	case gotSess := <-sessions:
+		if gotSess.Variant != "localhost-canary" {
+			t.Errorf("Session.Variant = %q, want %q", gotSess.Variant, "localhost-canary")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for OnTransferComplete")
+	}
+}
@@ -0,0 +1,56 @@
+package tftp
+
+import "strings"
+
+// CaseInsensitiveFilenames, when true, lets an RRQ whose filename
+// doesn't match anything exactly fall back to a case-insensitive
+// lookup against the Handler's listing, for BMCs and old BIOS PXE
+// stacks that uppercase filenames (e.g. requesting "BOOTX64.EFI" for a
+// file actually named "bootx64.efi"). It only applies when the
+// configured Handler implements Lister; Handlers that don't are left
+// to report ErrCodeNotFound for anything but an exact match, same as
+// with this option off. It has no effect on WRQ or PayloadProvider,
+// which resolves filenames itself.
+func (s *Server) resolveFilenameCase(filename string) string {
+	if !s.CaseInsensitiveFilenames || s.PayloadProvider != nil {
+		return filename
+	}
+	lister, ok := s.Handler.(Lister)
+	if !ok {
+		return filename
+	}
+	names, err := lister.ListFiles()
+	if err != nil {
+		return filename
+	}
+	for _, name := range names {
+		if name == filename {
+			return filename
+		}
+	}
+	if match, ok := resolveFilenameCase(filename, names); ok {
+		return match
+	}
+	return filename
+}
+
+// resolveFilenameCase finds the filename in candidates that matches
+// name case-insensitively. Ties — more than one candidate differing
+// from name only by case — are broken deterministically by picking the
+// lexicographically smallest candidate, so the same request always
+// resolves to the same file regardless of listing order.
+func resolveFilenameCase(name string, candidates []string) (string, bool) {
+	lower := strings.ToLower(name)
+	best := ""
+	found := false
+	for _, candidate := range candidates {
+		if strings.ToLower(candidate) != lower {
+			continue
+		}
+		if !found || candidate < best {
+			best = candidate
+			found = true
+		}
+	}
+	return best, found
+}
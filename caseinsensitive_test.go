@@ -0,0 +1,89 @@
+package tftp
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// caseListingHandler is a memHandler that also implements Lister, so
+// case-insensitive resolution has something to scan.
+type caseListingHandler struct {
+	*memHandler
+}
+
+func (h *caseListingHandler) ListFiles() ([]string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	names := make([]string, 0, len(h.files))
+	for name := range h.files {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func TestResolveFilenameCasePicksExactMatchFirst(t *testing.T) {
+	got, ok := resolveFilenameCase("BOOTX64.EFI", []string{"bootx64.efi", "BOOTX64.EFI"})
+	if !ok || got != "BOOTX64.EFI" {
+		t.Fatalf("resolveFilenameCase = (%q, %v), want (%q, true)", got, ok, "BOOTX64.EFI")
+	}
+}
+
+func TestResolveFilenameCaseDeterministicTieBreak(t *testing.T) {
+	// Neither candidate is an exact match; the lexicographically
+	// smaller one wins regardless of listing order.
+	got, ok := resolveFilenameCase("BOOTX64.EFI", []string{"Bootx64.efi", "bootx64.efi"})
+	if !ok || got != "Bootx64.efi" {
+		t.Fatalf("resolveFilenameCase = (%q, %v), want (%q, true)", got, ok, "Bootx64.efi")
+	}
+}
+
+func TestResolveFilenameCaseNoMatch(t *testing.T) {
+	if _, ok := resolveFilenameCase("missing.bin", []string{"other.bin"}); ok {
+		t.Fatal("resolveFilenameCase reported a match where none exists")
+	}
+}
+
+func TestServeRRQCaseInsensitiveFallback(t *testing.T) {
+	want := []byte("efi loader")
+	h := &caseListingHandler{memHandler: &memHandler{files: map[string][]byte{"bootx64.efi": want}}}
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s := NewServer("", h)
+	s.CaseInsensitiveFilenames = true
+	s.conn = conn
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	got, err := c.GetBytes("BOOTX64.EFI", "octet")
+	if err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+	if string(got) != string(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestServeRRQCaseInsensitiveOffByDefault(t *testing.T) {
+	h := &caseListingHandler{memHandler: &memHandler{files: map[string][]byte{"bootx64.efi": []byte("x")}}}
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s := NewServer("", h)
+	s.conn = conn
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	if _, err := c.GetBytes("BOOTX64.EFI", "octet"); err == nil {
+		t.Fatal("expected GetBytes to fail with case-insensitive resolution off")
+	}
+}
@@ -0,0 +1,55 @@
+package tftp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// ChecksumValidator returns a ContentValidator that verifies downloaded
+// data against a known sha256 digest, hex-encoded (case-insensitive).
+// TFTP runs over UDP and its DATA/ACK retransmission dance only
+// guarantees every block arrived, not that it arrived uncorrupted;
+// appending this to Client.Validators catches the rest.
+func ChecksumValidator(expectedHex string) ContentValidator {
+	return func(data []byte) error {
+		sum := sha256.Sum256(data)
+		got := hex.EncodeToString(sum[:])
+		if !strings.EqualFold(got, expectedHex) {
+			return fmt.Errorf("tftp: sha256 mismatch: got %s, want %s", got, expectedHex)
+		}
+		return nil
+	}
+}
+
+// GetVerified downloads filename the same as GetBytes, then checks its
+// sha256 digest. If expectedSHA256 is empty, the digest is instead
+// fetched from "<filename>.sha256" on the same server, the convention
+// many TFTP-served image trees already use for out-of-band integrity
+// checks; that sidecar may hold either a bare hex digest or the
+// "<hex>  <filename>" format sha256sum produces, since only its first
+// whitespace-delimited field is used.
+func (c *Client) GetVerified(ctx context.Context, filename, mode, expectedSHA256 string) ([]byte, error) {
+	if expectedSHA256 == "" {
+		sidecar, err := c.getBytes(ctx, filename+".sha256", mode)
+		if err != nil {
+			return nil, fmt.Errorf("tftp: fetch checksum sidecar: %w", err)
+		}
+		fields := strings.Fields(string(sidecar))
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("tftp: checksum sidecar %s.sha256 is empty", filename)
+		}
+		expectedSHA256 = fields[0]
+	}
+
+	data, err := c.getBytes(ctx, filename, mode)
+	if err != nil {
+		return nil, err
+	}
+	if err := ChecksumValidator(expectedSHA256)(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
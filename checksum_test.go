@@ -0,0 +1,85 @@
+package tftp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestClientGetVerifiedWithExplicitDigest(t *testing.T) {
+	want := bytes.Repeat([]byte("i"), DefaultBlockSize+7)
+	sum := sha256.Sum256(want)
+	addr, _ := startTestServer(t, map[string][]byte{"image.bin": want})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+	got, err := c.GetVerified(context.Background(), "image.bin", "octet", hex.EncodeToString(sum[:]))
+	if err != nil {
+		t.Fatalf("GetVerified: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("GetVerified returned %d bytes, want %d", len(got), len(want))
+	}
+}
+
+func TestClientGetVerifiedWithSidecar(t *testing.T) {
+	want := []byte("kernel image contents")
+	sum := sha256.Sum256(want)
+	addr, _ := startTestServer(t, map[string][]byte{
+		"image.bin":        want,
+		"image.bin.sha256": []byte(hex.EncodeToString(sum[:]) + "  image.bin\n"),
+	})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+	got, err := c.GetVerified(context.Background(), "image.bin", "octet", "")
+	if err != nil {
+		t.Fatalf("GetVerified: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("GetVerified returned %q, want %q", got, want)
+	}
+}
+
+func TestClientGetVerifiedWithFileHandlerGeneratedSidecar(t *testing.T) {
+	dir := t.TempDir()
+	want := []byte("kernel image contents served from disk")
+	if err := os.WriteFile(filepath.Join(dir, "image.bin"), want, 0o644); err != nil {
+		t.Fatalf("WriteFile (setup): %v", err)
+	}
+
+	s := NewServer("", &FileHandler{Dir: dir})
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s.conn = conn
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	got, err := c.GetVerified(context.Background(), "image.bin", "octet", "")
+	if err != nil {
+		t.Fatalf("GetVerified: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("GetVerified returned %q, want %q", got, want)
+	}
+}
+
+func TestClientGetVerifiedRejectsMismatch(t *testing.T) {
+	addr, _ := startTestServer(t, map[string][]byte{"image.bin": []byte("tampered contents")})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+	if _, err := c.GetVerified(context.Background(), "image.bin", "octet", hex.EncodeToString(make([]byte, sha256.Size))); err == nil {
+		t.Fatal("expected a checksum mismatch error")
+	}
+}
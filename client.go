@@ -0,0 +1,954 @@
+package tftp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Validator inspects the fully downloaded content of a GetBytes call
+// before it is returned to the caller. A non-nil error fails the
+// transfer, for example when a captive portal or misconfigured server
+// has returned an HTML error page in place of the requested file.
+type ContentValidator func(data []byte) error
+
+// Client is a TFTP client bound to a remote server, or, via Addrs, an
+// ordered list of redundant ones.
+type Client struct {
+	Addr    string
+	Timeout time.Duration
+	Retries int
+
+	// Addrs, if non-empty, overrides Addr with an ordered list of
+	// host:port server addresses for failover: every RRQ/WRQ is sent to
+	// Addrs[0] first, and only moves on to Addrs[1] (then Addrs[2], and
+	// so on) if that address never answers at all within c.Retries
+	// attempts. An explicit ERROR reply from a server is not a failover
+	// trigger — it means the server is there and rejected the request,
+	// so retrying the same request against a different server wouldn't
+	// help. Useful in environments with multiple provisioning servers
+	// behind no shared virtual IP.
+	Addrs []string
+
+	// AdaptiveTimeout seeds each transfer's retransmission timeout from
+	// the RTT measured between the request and the server's first
+	// response, instead of using Timeout for the whole session. This
+	// helps both ends of the spectrum: it reacts fast on a LAN and
+	// tolerates a slow WAN link without needing per-deployment tuning.
+	AdaptiveTimeout bool
+
+	// BlockSize, if non-zero, is requested via the blksize option (RFC
+	// 2348) on every Get and Put. The server may negotiate it down (or
+	// ignore it entirely on an RFC 1350-only implementation), so the
+	// block size actually used for a transfer can be smaller than this.
+	BlockSize int
+
+	// WindowSize, if non-zero, is requested via the windowsize option
+	// (RFC 7440) on every Get and Put, subject to the same negotiation
+	// caveats as BlockSize.
+	WindowSize int
+
+	// PathMTU, if non-zero, takes precedence over BlockSize: it's run
+	// through SafeBlockSize (given the server's address, to pick the
+	// right IP header overhead) to compute the blksize requested on
+	// every Get and Put, so a link with a small or unusual MTU doesn't
+	// silently lose fragmented DATA packets. Typically set from
+	// ProbeInterfaceMTU's result, or from an operator-known link MTU.
+	// Subject to the same negotiation caveats as BlockSize.
+	PathMTU int
+
+	// Validators run, in order, against the fully assembled content of
+	// every successful GetBytes call. The first error returned aborts
+	// the call.
+	Validators []ContentValidator
+
+	// ProgressFunc, if set, is invoked after each DATA block is written
+	// during Get with the cumulative bytes received so far. total is -1
+	// unless the server reports the file's size via a negotiated tsize
+	// option (RFC 2349).
+	ProgressFunc func(bytesReceived, total int64)
+
+	// LocalAddr, if set, is the local host:port every transfer binds to
+	// instead of an OS-assigned ephemeral port on the unspecified
+	// address. Leave it empty on ordinary hosts: the kernel already
+	// picks the right outbound interface from the route to Addr, and an
+	// ephemeral port avoids the conflicts a fixed one causes when
+	// several users (or transfers) run a Client at once. Set it only
+	// when a firewall requires TFTP traffic to originate from a known
+	// address.
+	LocalAddr string
+
+	// Logger receives diagnostic output such as retry exhaustion and
+	// validator rejections. Defaults to a no-op Logger via NewClient, so
+	// a Client constructed that way stays silent until the caller opts
+	// in.
+	Logger Logger
+
+	// Capture, if set, receives every datagram this Client sends or
+	// receives in pcap format, for diagnosing wire-level issues with
+	// picky firmware TFTP servers. Left nil by default; NewClient does
+	// not set one, since writing a capture is not free and should stay
+	// opt-in.
+	Capture *PacketCapture
+
+	// Middleware, if set, wraps every transfer's UDP socket in order
+	// before Get/Put use it, so a cross-cutting concern like extra
+	// metrics or throttling can be layered on without touching the
+	// transfer logic itself. See PacketMiddleware's doc comment for the
+	// tradeoff it implies.
+	Middleware []PacketMiddleware
+
+	// Compress, if true, requests OptCompress ("gzip") on every Get and
+	// Put, so the DATA stream carries gzip-compressed content instead of
+	// the file's raw bytes — worthwhile for text-heavy boot configs over
+	// a slow serial-backed link, pointless (or counterproductive) for
+	// content that's already compressed. The server may not honor it
+	// (see openBlockSource), so Get callers should not assume
+	// NegotiatedOptions.Compressed matches what was requested.
+	Compress bool
+
+	// RetryPolicy governs the wait between retransmission attempts while
+	// awaiting the server's initial response to an RRQ/WRQ. Leave it nil
+	// to get the default exponential-with-jitter behavior (see
+	// defaultRetryPolicy); set it to ConstantRetryPolicy, a tuned
+	// ExponentialRetryPolicy, or a custom implementation to match a
+	// link's actual loss/latency characteristics, e.g. a satellite or
+	// cellular connection where the default's 4-second cap backs off too
+	// fast.
+	RetryPolicy RetryPolicy
+
+	// AuthKey, if set, signs every RRQ/WRQ with the private
+	// OptAuthTimestamp/OptAuthSignature options, using the current time
+	// as the signed timestamp. Set Server.AuthKey to the same value on
+	// the server side to have it verify the signature. See signRequest.
+	AuthKey []byte
+
+	// EncryptionKey, if set, requests OptEncrypt on every Get and Put,
+	// so the DATA stream carries AES-256-GCM-encrypted content instead
+	// of the file's raw (or, with Compress also set, compressed) bytes.
+	// Set Server.EncryptionKey to the same value to have it honor the
+	// request. The server may not honor it (see openBlockSource), so
+	// Get callers should not assume NegotiatedOptions.Encrypted matches
+	// what was requested. See OptEncrypt.
+	EncryptionKey []byte
+
+	// Broadcast enables SO_BROADCAST on the socket Get uses, so Addr (or
+	// Addrs) may be a broadcast or subnet directed-broadcast address
+	// (e.g. "255.255.255.255:69") instead of a specific server's
+	// host:port. readInitialResponse already adopts whichever address a
+	// DATA/OACK arrives from as the session's peer without checking it
+	// against the address the RRQ was sent to, so the rest of Get's
+	// failover-oriented machinery needs no further changes to also serve
+	// as "ask the whole segment, use whoever answers first" discovery —
+	// the pattern several embedded bootloaders rely on in flat lab
+	// networks that have no provisioning server address configured yet.
+	// Left false by default, since a misrouted broadcast is far more
+	// disruptive than a misrouted unicast request.
+	Broadcast bool
+}
+
+// logf writes to c.Logger if one is set, and is a no-op otherwise so a
+// Client built as a zero-value struct literal rather than via NewClient
+// doesn't panic on a nil Logger.
+func (c *Client) logf(format string, args ...any) {
+	if c.Logger != nil {
+		c.Logger.Printf(format, args...)
+	}
+}
+
+// warnf is logf's counterpart for conditions worth distinguishing from
+// routine traffic: a content validation failure, a retry budget
+// exhausted. It uses c.Logger's WarnLogger capability when present, or
+// falls back to Printf like logf.
+func (c *Client) warnf(format string, args ...any) {
+	warnf(c.Logger, format, args...)
+}
+
+// localBindAddr resolves LocalAddr, or returns the unspecified address
+// with port 0 when it's unset, letting the OS pick both the interface
+// and the port.
+func (c *Client) localBindAddr() (*net.UDPAddr, error) {
+	if c.LocalAddr == "" {
+		return &net.UDPAddr{}, nil
+	}
+	return net.ResolveUDPAddr("udp", c.LocalAddr)
+}
+
+// serverAddrs returns the ordered list of server addresses a request
+// should be tried against: Addrs when set, or the single Addr otherwise.
+func (c *Client) serverAddrs() []string {
+	if len(c.Addrs) > 0 {
+		return c.Addrs
+	}
+	return []string{c.Addr}
+}
+
+// firstServerAddr resolves the first of serverAddrs for SafeBlockSize's
+// address-family check, returning nil (SafeBlockSize's conservative
+// fallback) rather than an error if resolution fails; requestOptions
+// runs before any request is actually sent, so a resolve failure here
+// shouldn't block the attempt — the real resolve happens later and
+// surfaces its own error if the address is truly bad.
+func (c *Client) firstServerAddr() net.Addr {
+	addrs := c.serverAddrs()
+	if len(addrs) == 0 {
+		return nil
+	}
+	addr, err := net.ResolveUDPAddr("udp", addrs[0])
+	if err != nil {
+		return nil
+	}
+	return addr
+}
+
+// requestOptions builds the Options map to attach to an RRQ or WRQ for
+// filename, requesting tsize unconditionally (per RFC 2349, the server
+// either reports the real size in its OACK or omits the option
+// entirely) plus whichever of
+// PathMTU/BlockSize/WindowSize/Compress/EncryptionKey/AuthKey the
+// caller has opted into. It returns nil rather than an empty map when
+// nothing is requested, so a Client left at its zero value for these
+// fields produces a plain RFC 1350 request with no Options extension at
+// all.
+func (c *Client) requestOptions(filename string, tsize int64) Options {
+	opts := Options{OptTransferSize: strconv.FormatInt(tsize, 10)}
+	if c.PathMTU > 0 {
+		opts[OptBlockSize] = strconv.Itoa(SafeBlockSize(c.PathMTU, c.firstServerAddr()))
+	} else if c.BlockSize > 0 {
+		opts[OptBlockSize] = strconv.Itoa(c.BlockSize)
+	}
+	if c.WindowSize > 0 {
+		opts[OptWindowSize] = strconv.Itoa(c.WindowSize)
+	}
+	if c.Compress {
+		opts[OptCompress] = optCompressGzip
+	}
+	if len(c.EncryptionKey) > 0 {
+		opts[OptEncrypt] = optEncryptAES256GCM
+	}
+	if len(c.AuthKey) > 0 {
+		ts := time.Now().Unix()
+		opts[OptAuthTimestamp] = strconv.FormatInt(ts, 10)
+		opts[OptAuthSignature] = signRequest(c.AuthKey, filename, ts)
+	}
+	return opts
+}
+
+// NewClient constructs a Client targeting addr (host:port).
+func NewClient(addr string) *Client {
+	return &Client{
+		Addr:            addr,
+		Timeout:         DefaultTimeout,
+		Retries:         DefaultRetries,
+		AdaptiveTimeout: true,
+		Logger:          noopLogger{},
+	}
+}
+
+// Get opens filename on the server in the given mode ("octet" or
+// "netascii") and returns a reader that streams DATA blocks as they
+// arrive, ACKing each one as it's consumed. Closing the returned
+// ReadCloser before reading to EOF aborts the transfer. ctx governs the
+// lifetime of the underlying socket: canceling it unblocks a stalled
+// Read with ctx.Err().
+func (c *Client) Get(ctx context.Context, filename, mode string) (StatsReader, error) {
+	rc, _, err := c.get(ctx, filename, mode, c.requestOptions(filename, 0))
+	return rc, err
+}
+
+// StatsReader is the io.ReadCloser returned by Get and GetResume. Stats
+// is available once the read side of the transfer has finished, whether
+// that's because the caller read to EOF or because the transfer failed
+// partway through; before then it returns the zero Stats.
+type StatsReader interface {
+	io.ReadCloser
+	Stats() Stats
+}
+
+// GetResume behaves like Get but asks the server, via the private
+// OptOffset extension, to start the DATA stream at offset bytes into
+// the file instead of from block 1 — useful for continuing a
+// multi-hundred-MB download that was interrupted partway through
+// instead of pulling the whole file again over a flaky link. actualOffset
+// reports where the returned stream really starts: offset rounded down
+// to the nearest negotiated block boundary if the server honored the
+// request, or 0 if it didn't (any server other than this package's, or
+// an offset at or past the end of the file), in which case the stream
+// is a full download from the beginning and the caller should treat it
+// that way rather than appending to a partial local copy.
+func (c *Client) GetResume(ctx context.Context, filename, mode string, offset int64) (rc StatsReader, actualOffset int64, err error) {
+	opts := c.requestOptions(filename, 0)
+	if offset > 0 {
+		opts[OptOffset] = strconv.FormatInt(offset, 10)
+	}
+	rc, nego, err := c.get(ctx, filename, mode, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	return rc, nego.Offset, nil
+}
+
+// rrqHandshake sends req to each of c.serverAddrs() in turn, returning
+// the first server's response. Addresses past the first exist purely
+// for failover (see Client.Addrs): one that never answers within
+// c.Retries attempts — readInitialResponse exhausting its retries and
+// returning errTransferAborted — or one that actively refuses the
+// connection (errConnRefused, an ICMP port-unreachable) is skipped in
+// favor of the next, while any other error (including an explicit
+// ERROR reply, or the last address failing) is returned immediately.
+func (c *Client) rrqHandshake(ctx context.Context, conn packetConn, req *RRQ) (*DATA, net.Addr, NegotiatedOptions, error) {
+	addrs := c.serverAddrs()
+	var lastErr error
+	for i, addr := range addrs {
+		remote, err := net.ResolveUDPAddr("udp", addr)
+		if err != nil {
+			return nil, nil, NegotiatedOptions{}, err
+		}
+		if err := writePacket(conn, req, remote, c.Capture, debugLoggerOf(c.Logger)); err != nil {
+			return nil, nil, NegotiatedOptions{}, err
+		}
+		data, from, nego, err := c.readInitialResponse(ctx, conn, remote, req)
+		if err == nil {
+			return data, from, nego, nil
+		}
+		if !isFailoverError(err) || i == len(addrs)-1 {
+			return nil, nil, NegotiatedOptions{}, err
+		}
+		c.warnf("tftp: %s: no response from %s, failing over to %s", req.Filename, addr, addrs[i+1])
+		lastErr = err
+	}
+	return nil, nil, NegotiatedOptions{}, lastErr
+}
+
+// rrqConnect binds a local socket, applies Middleware, and performs the
+// RRQ handshake for filename, returning the open connection alongside
+// whatever the handshake produced. It's the shared first step of every
+// download entrypoint (Get, GetResume, GetFile); the caller owns pc and
+// must close it once it's done with the transfer. On error, the socket
+// (if one was opened) is already closed.
+func (c *Client) rrqConnect(ctx context.Context, filename, mode string, opts Options) (pc net.PacketConn, data *DATA, from net.Addr, nego NegotiatedOptions, start time.Time, err error) {
+	local, err := c.localBindAddr()
+	if err != nil {
+		return nil, nil, nil, NegotiatedOptions{}, time.Time{}, err
+	}
+	conn, err := net.ListenUDP("udp", local)
+	if err != nil {
+		return nil, nil, nil, NegotiatedOptions{}, time.Time{}, err
+	}
+	enableICMPErrors(conn)
+	if c.Broadcast {
+		if err := enableBroadcast(conn); err != nil {
+			conn.Close()
+			return nil, nil, nil, NegotiatedOptions{}, time.Time{}, err
+		}
+	}
+	pc = applyMiddleware(conn, c.Middleware)
+
+	start = time.Now()
+	req := &RRQ{Filename: filename, Mode: mode, Options: opts}
+	data, from, nego, err = c.rrqHandshake(ctx, pc, req)
+	if err != nil {
+		conn.Close()
+		return nil, nil, nil, NegotiatedOptions{}, time.Time{}, err
+	}
+	return pc, data, from, nego, start, nil
+}
+
+func (c *Client) get(ctx context.Context, filename, mode string, opts Options) (*getReader, NegotiatedOptions, error) {
+	pc, data, from, nego, start, err := c.rrqConnect(ctx, filename, mode, opts)
+	if err != nil {
+		return nil, NegotiatedOptions{}, err
+	}
+	return c.getStream(ctx, pc, data, from, nego, start, mode)
+}
+
+// getStream drives the streaming, io.Pipe-based receive path over an
+// already-connected pc, as set up by rrqConnect. It's split out of get
+// so GetFile can fall back to this same path without paying for a
+// second RRQ handshake when its own out-of-order io.WriterAt path turns
+// out not to apply.
+func (c *Client) getStream(ctx context.Context, pc net.PacketConn, data *DATA, from net.Addr, nego NegotiatedOptions, start time.Time, mode string) (*getReader, NegotiatedOptions, error) {
+	timeout := c.Timeout
+	if c.AdaptiveTimeout {
+		timeout = seedTimeoutFromRTT(time.Since(start))
+	}
+
+	pr, pw := io.Pipe()
+
+	total := int64(-1)
+	if nego.TransferSize > 0 {
+		total = nego.TransferSize
+	}
+
+	var dst io.Writer = pw
+	var decoder *netasciiDecoder
+	if strings.EqualFold(mode, "netascii") {
+		decoder = newNetasciiDecoder(pw)
+		dst = decoder
+	}
+	var gzDec *gzipDecodingWriter
+	if nego.Compressed {
+		gzDec = newGzipDecodingWriter(dst)
+		dst = gzDec
+	}
+	var encDec *encryptingDecodingWriter
+	if nego.Encrypted {
+		encDec = newEncryptingDecodingWriter(dst, c.EncryptionKey)
+		dst = encDec
+	}
+
+	gr := &getReader{PipeReader: pr, conn: pc}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var xferStats transferStats
+		err := receiveDataFrom(pc, from, nego, timeout, c.Retries, data, dst, c.ProgressFunc, total, &xferStats, c.Capture, debugLoggerOf(c.Logger), c.RetryPolicy)
+		if err == nil && encDec != nil {
+			err = encDec.Flush()
+		}
+		if err == nil && gzDec != nil {
+			err = gzDec.Flush()
+		}
+		if err == nil && decoder != nil {
+			err = decoder.Flush()
+		}
+		if err != nil {
+			if cerr := ctx.Err(); cerr != nil {
+				err = cerr
+			}
+		}
+		gr.stats.Store(&Stats{
+			Bytes:           xferStats.bytes,
+			Duration:        time.Since(start),
+			Blocks:          xferStats.blocks,
+			Retransmissions: xferStats.retransmissions,
+			DuplicateACKs:   xferStats.duplicateACKs,
+			Timeouts:        xferStats.timeouts,
+			BlockSize:       nego.BlockSize,
+		})
+		pw.CloseWithError(err)
+	}()
+	go func() {
+		select {
+		case <-ctx.Done():
+			pc.Close()
+		case <-done:
+		}
+	}()
+
+	return gr, nego, nil
+}
+
+// getReader closes the session socket alongside the pipe so aborting a
+// streaming Get early (without reading to EOF) releases the ephemeral
+// UDP port immediately instead of waiting for the receive goroutine to
+// time out. conn is whatever Client.Middleware wrapped the raw UDP
+// socket into (the socket itself, unchanged, if Middleware is unset).
+type getReader struct {
+	*io.PipeReader
+	conn  net.PacketConn
+	stats atomic.Pointer[Stats]
+}
+
+func (r *getReader) Close() error {
+	r.conn.Close()
+	return r.PipeReader.Close()
+}
+
+// Stats returns the transfer's Stats. It returns the zero Stats until
+// the read side of the transfer has finished, successfully or not.
+func (r *getReader) Stats() Stats {
+	if s := r.stats.Load(); s != nil {
+		return *s
+	}
+	return Stats{}
+}
+
+// GetBytes downloads filename in full and runs it through Validators.
+// It is a convenience wrapper around Get for callers that don't need
+// streaming.
+func (c *Client) GetBytes(filename, mode string) ([]byte, error) {
+	return c.getBytes(context.Background(), filename, mode)
+}
+
+func (c *Client) getBytes(ctx context.Context, filename, mode string) ([]byte, error) {
+	rc, err := c.Get(ctx, filename, mode)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range c.Validators {
+		if err := v(data); err != nil {
+			c.warnf("tftp: %s failed content validation: %v", filename, err)
+			return nil, fmt.Errorf("tftp: content validation failed: %w", err)
+		}
+	}
+	return data, nil
+}
+
+// readInitialResponse waits for the server's first substantive reply to
+// an RRQ, which is either an OACK (the server accepted one or more of
+// the requested options) or the first DATA block (a plain RFC 1350
+// server, or one that ignored every requested option). On an OACK it
+// ACKs block 0 to complete the negotiation handshake and leaves the
+// returned DATA nil, so the caller waits for block 1 the same way
+// receiveDataFrom always does when it isn't handed a first block. Each
+// timed-out attempt retransmits req (the RRQ may simply have been lost)
+// and waits however long c.RetryPolicy says to next, so repeated losses
+// don't all retry in lockstep. It returns ctx.Err() as soon as a read
+// fails after ctx is canceled, rather than working through the
+// remaining retries first.
+func (c *Client) readInitialResponse(ctx context.Context, conn packetConn, remote *net.UDPAddr, req *RRQ) (*DATA, net.Addr, NegotiatedOptions, error) {
+	fallback := NegotiatedOptions{BlockSize: DefaultBlockSize, WindowSize: DefaultWindowSize}
+	buf := make([]byte, 65507)
+	local, _ := conn.LocalAddr().(*net.UDPAddr)
+	attempt := 0
+	for {
+		if attempt > c.Retries {
+			if err := ctx.Err(); err != nil {
+				return nil, nil, NegotiatedOptions{}, err
+			}
+			c.warnf("tftp: %s: giving up after %d retries awaiting initial response", req.Filename, c.Retries)
+			return nil, nil, NegotiatedOptions{}, errTransferAborted
+		}
+		delay, ok := retryDelay(c.RetryPolicy, c.Timeout, attempt)
+		if !ok {
+			c.warnf("tftp: %s: retry policy gave up awaiting initial response", req.Filename)
+			return nil, nil, NegotiatedOptions{}, errTransferAborted
+		}
+		conn.SetReadDeadline(time.Now().Add(delay))
+		n, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			if cerr := ctx.Err(); cerr != nil {
+				return nil, nil, NegotiatedOptions{}, cerr
+			}
+			if uc, ok := conn.(*net.UDPConn); ok && connRefused(uc) {
+				c.warnf("tftp: %s: %s refused the connection (ICMP port unreachable)", req.Filename, remote)
+				return nil, nil, NegotiatedOptions{}, errConnRefused
+			}
+			attempt++
+			if werr := writePacket(conn, req, remote, c.Capture, debugLoggerOf(c.Logger)); werr != nil {
+				return nil, nil, NegotiatedOptions{}, werr
+			}
+			continue
+		}
+		c.Capture.received(local, from, buf[:n])
+		debugPacket(debugLoggerOf(c.Logger), "received", from, buf[:n])
+		p, err := ParsePacket(buf[:n])
+		if err != nil {
+			attempt++
+			continue
+		}
+		switch pkt := p.(type) {
+		case *DATA:
+			return pkt, from, fallback, nil
+		case *OACK:
+			nego, _ := parseOptions(pkt.Options)
+			if werr := writePacket(conn, &ACK{Block: 0}, from, c.Capture, debugLoggerOf(c.Logger)); werr != nil {
+				return nil, nil, NegotiatedOptions{}, werr
+			}
+			return nil, from, nego, nil
+		case *ERROR:
+			return nil, nil, NegotiatedOptions{}, pkt
+		default:
+			attempt++
+		}
+	}
+}
+
+// receiveDataFrom mirrors the server's receiveData but starts from a
+// DATA packet already read off the wire (or nil, if the caller is still
+// waiting on block 1 after an OACK handshake), ACKs from the client's
+// perspective (the remote TID is now fixed to from), and streams each
+// block's payload to w instead of buffering the whole transfer. If
+// progress is non-nil, it's called after each block is written with the
+// cumulative byte count and total, the latter passed through verbatim
+// from the caller (-1 unless tsize was negotiated). policy governs the
+// wait between attempts while stalled on the next DATA block; nil gets
+// the default exponential-with-jitter behavior (see retryDelay).
+func receiveDataFrom(conn packetConn, from net.Addr, nego NegotiatedOptions, timeout time.Duration, retries int, first *DATA, w io.Writer, progress func(received, total int64), total int64, stats *transferStats, capture *PacketCapture, debug DebugLogger, policy RetryPolicy) error {
+	// The first block number is ordinarily 1, but a resumed download
+	// (nego.Offset > 0, see OptOffset) starts wherever the server's
+	// alignment landed, so derive it instead of assuming 1.
+	expected := uint16(1)
+	if nego.Offset > 0 && nego.BlockSize > 0 {
+		expected = uint16(((nego.Offset / int64(nego.BlockSize)) + 1) & 0xffff)
+	}
+	if first != nil {
+		expected = first.Block
+	}
+	// buf is sized one byte larger than the biggest legitimate DATA
+	// packet, so a datagram that fills it completely is distinguishable
+	// from a full-size final block; see errDatagramTooLarge.
+	buf := make([]byte, maxPacketSize(nego.BlockSize)+1)
+	local, _ := conn.LocalAddr().(*net.UDPAddr)
+	pending := first
+	var received int64
+
+	for {
+		if pending == nil {
+			attempt := 0
+			var data *DATA
+			for data == nil {
+				if attempt > retries {
+					return errTransferAborted
+				}
+				delay, ok := retryDelay(policy, timeout, attempt)
+				if !ok {
+					return errTransferAborted
+				}
+				conn.SetReadDeadline(time.Now().Add(delay))
+				n, addr, err := conn.ReadFrom(buf)
+				if err != nil {
+					attempt++
+					if expected > 1 {
+						// Lost DATA is usually a lost ACK; resend the
+						// last one we sent to prompt a retransmit.
+						writePacket(conn, &ACK{Block: expected - 1}, from, capture, debug)
+						if stats != nil {
+							stats.retransmissions++
+						}
+					}
+					continue
+				}
+				if n == len(buf) {
+					attempt++
+					continue
+				}
+				capture.received(local, addr, buf[:n])
+				debugPacket(debug, "received", addr, buf[:n])
+				if !sameHost(addr, from) {
+					// Packet from a different endpoint than the one
+					// that answered our RRQ; it isn't part of this
+					// transfer's session, so reject it and keep
+					// waiting rather than risk mixing up two crossed
+					// transfers on a busy network.
+					writePacket(conn, NewError(ErrCodeUnknownID, "unexpected TID"), addr, capture, debug)
+					continue
+				}
+				p, err := ParsePacket(buf[:n])
+				if err != nil {
+					attempt++
+					continue
+				}
+				switch pkt := p.(type) {
+				case *DATA:
+					data = pkt
+				case *ERROR:
+					return pkt
+				default:
+					attempt++
+				}
+			}
+			pending = data
+		}
+
+		if pending.Block != expected {
+			// Compare in circular sequence space (block numbers wrap
+			// at 65535) rather than as plain uint16s, so a duplicate of
+			// the last block we ACKed is always diff == -1 regardless
+			// of where it falls relative to a wraparound.
+			switch diff := int16(pending.Block - expected); {
+			case diff == -1:
+				// Exact duplicate of the last block we ACKed, most
+				// likely because our ACK was lost; re-ACK it so the
+				// server retransmits rather than stalling.
+				writePacket(conn, &ACK{Block: pending.Block}, from, capture, debug)
+				if stats != nil {
+					stats.retransmissions++
+				}
+			case diff > 0:
+				return fmt.Errorf("tftp: unexpected gap in block sequence: got block %d, want %d", pending.Block, expected)
+			}
+			// diff < -1: a stale duplicate from further back; ignore.
+			pending = nil
+			continue
+		}
+
+		if _, err := w.Write(pending.Payload); err != nil {
+			return err
+		}
+		received += int64(len(pending.Payload))
+		if progress != nil {
+			progress(received, total)
+		}
+		if err := writePacket(conn, &ACK{Block: pending.Block}, from, capture, debug); err != nil {
+			return err
+		}
+		if stats != nil {
+			stats.blocks++
+			stats.bytes += int64(len(pending.Payload))
+		}
+		done := len(pending.Payload) < nego.BlockSize
+		pending = nil
+		if done {
+			// Hand the socket off to a background dally instead of
+			// blocking the caller here; the transfer is already
+			// complete from its point of view.
+			go dallyForRetransmit(conn, from, expected, buf, local, capture, debug)
+			if total >= 0 {
+				want := total
+				if nego.Offset > 0 {
+					// A resumed download's tsize still reports the
+					// whole file, but received only counts bytes from
+					// the resumed offset onward.
+					want -= nego.Offset
+				}
+				if received != want {
+					return fmt.Errorf("tftp: received %d bytes, want %d per the negotiated transfer size: transfer may have been truncated", received, want)
+				}
+			}
+			return nil
+		}
+		expected++
+	}
+}
+
+// receiveDataFromAt mirrors receiveDataFrom, but writes each block at
+// its own computed byte offset via w.WriteAt instead of streaming
+// sequentially to an io.Writer. Once a sender has more than one packet
+// in flight per window (RFC 7440), ordinary UDP reordering can deliver
+// a later block before an earlier one; receiveDataFrom treats that as a
+// fatal gap, but receiveDataFromAt instead writes the early arrival at
+// its rightful offset and remembers it in arrived (a map standing in
+// for a bitmap over the current window) until expected catches up to
+// it, then ACKs and advances through every block that's already on
+// disk, exactly as if they'd arrived in order. A gap wider than the
+// negotiated window size is still an error, since it can only mean a
+// block was actually lost rather than merely reordered.
+func receiveDataFromAt(conn packetConn, from net.Addr, nego NegotiatedOptions, timeout time.Duration, retries int, first *DATA, w io.WriterAt, progress func(received, total int64), total int64, stats *transferStats, capture *PacketCapture, debug DebugLogger, policy RetryPolicy) error {
+	expected := uint16(1)
+	if nego.Offset > 0 && nego.BlockSize > 0 {
+		expected = uint16(((nego.Offset / int64(nego.BlockSize)) + 1) & 0xffff)
+	}
+	if first != nil {
+		expected = first.Block
+	}
+	windowSize := nego.WindowSize
+	if windowSize < 1 {
+		windowSize = 1
+	}
+	buf := make([]byte, maxPacketSize(nego.BlockSize)+1)
+	local, _ := conn.LocalAddr().(*net.UDPAddr)
+	pending := first
+
+	writeOffset := nego.Offset
+	var received int64
+	arrived := make(map[uint16]int)
+
+	// deliver ACKs block (already written to w at its correct offset by
+	// the caller) and advances past it, reporting whether it was the
+	// final short block that ends the transfer.
+	deliver := func(block uint16, n int) (bool, error) {
+		received += int64(n)
+		if progress != nil {
+			progress(received, total)
+		}
+		if err := writePacket(conn, &ACK{Block: block}, from, capture, debug); err != nil {
+			return false, err
+		}
+		if stats != nil {
+			stats.blocks++
+			stats.bytes += int64(n)
+		}
+		writeOffset += int64(n)
+		expected++
+		return n < nego.BlockSize, nil
+	}
+
+	finish := func() error {
+		go dallyForRetransmit(conn, from, expected, buf, local, capture, debug)
+		if total >= 0 {
+			want := total
+			if nego.Offset > 0 {
+				want -= nego.Offset
+			}
+			if received != want {
+				return fmt.Errorf("tftp: received %d bytes, want %d per the negotiated transfer size: transfer may have been truncated", received, want)
+			}
+		}
+		return nil
+	}
+
+	for {
+		if pending == nil {
+			attempt := 0
+			var data *DATA
+			for data == nil {
+				if attempt > retries {
+					return errTransferAborted
+				}
+				delay, ok := retryDelay(policy, timeout, attempt)
+				if !ok {
+					return errTransferAborted
+				}
+				conn.SetReadDeadline(time.Now().Add(delay))
+				n, addr, err := conn.ReadFrom(buf)
+				if err != nil {
+					attempt++
+					if expected > 1 {
+						writePacket(conn, &ACK{Block: expected - 1}, from, capture, debug)
+						if stats != nil {
+							stats.retransmissions++
+						}
+					}
+					continue
+				}
+				if n == len(buf) {
+					attempt++
+					continue
+				}
+				capture.received(local, addr, buf[:n])
+				debugPacket(debug, "received", addr, buf[:n])
+				if !sameHost(addr, from) {
+					writePacket(conn, NewError(ErrCodeUnknownID, "unexpected TID"), addr, capture, debug)
+					continue
+				}
+				p, err := ParsePacket(buf[:n])
+				if err != nil {
+					attempt++
+					continue
+				}
+				switch pkt := p.(type) {
+				case *DATA:
+					data = pkt
+				case *ERROR:
+					return pkt
+				default:
+					attempt++
+				}
+			}
+			pending = data
+		}
+
+		diff := int16(pending.Block - expected)
+		switch {
+		case diff == -1:
+			// Exact duplicate of the last block we ACKed; re-ACK it so
+			// the server retransmits rather than stalling.
+			writePacket(conn, &ACK{Block: pending.Block}, from, capture, debug)
+			if stats != nil {
+				stats.retransmissions++
+			}
+			pending = nil
+			continue
+		case diff < -1:
+			// Stale duplicate from further back; ignore.
+			pending = nil
+			continue
+		case diff > 0 && int(diff) < windowSize:
+			// Ahead of expected but still inside the current window:
+			// write it at its rightful offset now and remember it's
+			// there, instead of failing the transfer over what's just
+			// ordinary UDP reordering.
+			if _, ok := arrived[pending.Block]; !ok {
+				offset := writeOffset + int64(diff)*int64(nego.BlockSize)
+				if err := writeBlockAt(w, pending.Payload, offset); err != nil {
+					return err
+				}
+				arrived[pending.Block] = len(pending.Payload)
+			}
+			pending = nil
+			continue
+		case diff != 0:
+			return fmt.Errorf("tftp: unexpected gap in block sequence: got block %d, want %d", pending.Block, expected)
+		}
+
+		if err := writeBlockAt(w, pending.Payload, writeOffset); err != nil {
+			return err
+		}
+		done, err := deliver(pending.Block, len(pending.Payload))
+		pending = nil
+		if err != nil {
+			return err
+		}
+		if done {
+			return finish()
+		}
+
+		// expected may already be sitting in arrived from an earlier
+		// reordered arrival; flush every block that's now contiguous.
+		for {
+			n, ok := arrived[expected]
+			if !ok {
+				break
+			}
+			delete(arrived, expected)
+			block := expected
+			done, err := deliver(block, n)
+			if err != nil {
+				return err
+			}
+			if done {
+				return finish()
+			}
+		}
+	}
+}
+
+// writeBlockAt writes data to w at offset, treating a short write as an
+// error the same way io.Writer.Write's contract would.
+func writeBlockAt(w io.WriterAt, data []byte, offset int64) error {
+	if len(data) == 0 {
+		return nil
+	}
+	n, err := w.WriteAt(data, offset)
+	if n < len(data) && err == nil {
+		err = io.ErrShortWrite
+	}
+	if n < len(data) {
+		return err
+	}
+	return nil
+}
+
+// dallyTimeout is how long receiveDataFrom lingers on the socket after
+// sending the final ACK, per RFC 1350's suggestion that the host
+// acknowledging the last DATA packet "dally" around in case that ACK
+// was lost and the remote retransmits the final block.
+const dallyTimeout = 1 * time.Second
+
+// dallyForRetransmit waits up to dallyTimeout for the remote to
+// retransmit the final DATA block (finalBlock) and, if it does,
+// re-sends the ACK so the remote doesn't log a spurious timeout on its
+// last block over a lossy link. It runs after receiveDataFrom has
+// already returned, so it gives up silently on any other packet, a read
+// error (including the caller closing conn early via getReader.Close),
+// or once dallyTimeout elapses.
+func dallyForRetransmit(conn packetConn, from net.Addr, finalBlock uint16, buf []byte, local net.Addr, capture *PacketCapture, debug DebugLogger) {
+	deadline := time.Now().Add(dallyTimeout)
+	for {
+		conn.SetReadDeadline(deadline)
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if n == len(buf) || !sameHost(addr, from) {
+			continue
+		}
+		capture.received(local, addr, buf[:n])
+		debugPacket(debug, "received", addr, buf[:n])
+		p, err := ParsePacket(buf[:n])
+		if err != nil {
+			continue
+		}
+		if data, ok := p.(*DATA); ok && data.Block == finalBlock {
+			writePacket(conn, &ACK{Block: finalBlock}, from, capture, debug)
+		}
+		if time.Now().After(deadline) {
+			return
+		}
+	}
+}
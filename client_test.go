@@ -0,0 +1,200 @@
+package tftp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memHandler is a Handler backed by an in-memory map, shared by tests
+// across this package. The server dispatches every request on its own
+// goroutine, so access to files is mutex-guarded.
+type memHandler struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+func (h *memHandler) ReadFile(name string) ([]byte, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	data, ok := h.files[name]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return data, nil
+}
+
+func (h *memHandler) WriteFile(name string, data []byte) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.files[name] = data
+	return nil
+}
+
+func (h *memHandler) get(name string) ([]byte, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	data, ok := h.files[name]
+	return data, ok
+}
+
+func startTestServer(t *testing.T, files map[string][]byte) (string, *memHandler) {
+	t.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	h := &memHandler{files: files}
+	s := NewServer("", h)
+	s.conn = conn
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+	return conn.LocalAddr().String(), h
+}
+
+func TestClientGetBytes(t *testing.T) {
+	want := bytes.Repeat([]byte("a"), DefaultBlockSize*2+10)
+	addr, _ := startTestServer(t, map[string][]byte{"file.bin": want})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+	got, err := c.GetBytes("file.bin", "octet")
+	if err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("GetBytes returned %d bytes, want %d", len(got), len(want))
+	}
+}
+
+func TestClientGetStreams(t *testing.T) {
+	want := bytes.Repeat([]byte("b"), DefaultBlockSize*2+10)
+	addr, _ := startTestServer(t, map[string][]byte{"file.bin": want})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+	rc, err := c.Get(context.Background(), "file.bin", "octet")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	defer rc.Close()
+
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("Get streamed %d bytes, want %d", len(got), len(want))
+	}
+}
+
+func TestClientGetContentValidatorRejects(t *testing.T) {
+	addr, _ := startTestServer(t, map[string][]byte{"file.bin": []byte("<html>captive portal</html>")})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+	c.Validators = append(c.Validators, func(data []byte) error {
+		if bytes.HasPrefix(data, []byte("<html")) {
+			return errors.New("looks like an HTML error page, not a binary")
+		}
+		return nil
+	})
+
+	if _, err := c.GetBytes("file.bin", "octet"); err == nil {
+		t.Fatal("expected GetBytes to fail validation")
+	}
+}
+
+func TestClientGetProgressFunc(t *testing.T) {
+	want := bytes.Repeat([]byte("c"), DefaultBlockSize*3+5)
+	addr, _ := startTestServer(t, map[string][]byte{"file.bin": want})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+
+	var calls []int64
+	var mu sync.Mutex
+	c.ProgressFunc = func(received, total int64) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, received)
+		if total != int64(len(want)) {
+			t.Errorf("total = %d, want %d (tsize is always requested)", total, len(want))
+		}
+	}
+
+	got, err := c.GetBytes("file.bin", "octet")
+	if err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("GetBytes returned %d bytes, want %d", len(got), len(want))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(calls) == 0 {
+		t.Fatal("ProgressFunc was never called")
+	}
+	if calls[len(calls)-1] != int64(len(want)) {
+		t.Errorf("final progress = %d, want %d", calls[len(calls)-1], len(want))
+	}
+}
+
+func TestClientGetContextCancellation(t *testing.T) {
+	// A listener that never answers, so Get has nothing to do but wait
+	// on the context.
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer conn.Close()
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = 50 * time.Millisecond
+	c.Retries = 100
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err = c.Get(ctx, "file.bin", "octet")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Get err = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Get took %v to notice cancellation, want well under the %d-retry timeout budget", elapsed, c.Retries)
+	}
+}
+
+func TestClientGetNetasciiConvertsLineEndings(t *testing.T) {
+	wire := []byte("config line one\r\nconfig line two\r\n")
+	addr, _ := startTestServer(t, map[string][]byte{"config.txt": wire})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+	got, err := c.GetBytes("config.txt", "netascii")
+	if err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+
+	want := "config line one\nconfig line two\n"
+	if string(got) != want {
+		t.Fatalf("GetBytes = %q, want %q", got, want)
+	}
+}
+
+func TestClientGetNotFound(t *testing.T) {
+	addr, _ := startTestServer(t, map[string][]byte{})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+	if _, err := c.GetBytes("missing.bin", "octet"); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+}
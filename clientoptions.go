@@ -0,0 +1,120 @@
+package tftp
+
+import (
+	"fmt"
+	"time"
+)
+
+// ClientOption configures a Client built via NewClientWithOptions.
+// Unlike setting a Client's exported fields directly after NewClient
+// returns, each option is applied (and validated) up front, so an
+// invalid combination is reported as an error at construction instead
+// of surfacing later as a transfer that fails in a confusing way.
+type ClientOption func(*Client) error
+
+// NewClientWithOptions builds on NewClient, applying opts in order and
+// failing on the first one that reports an invalid configuration. It
+// exists for callers that want that up-front validation; a Client
+// built via plain NewClient followed by setting fields directly works
+// exactly as before and is unaffected by anything in this file.
+func NewClientWithOptions(addr string, opts ...ClientOption) (*Client, error) {
+	c := NewClient(addr)
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// WithClientTimeout sets how long the client waits for a reply before
+// retransmitting. d must be positive.
+func WithClientTimeout(d time.Duration) ClientOption {
+	return func(c *Client) error {
+		if d <= 0 {
+			return fmt.Errorf("tftp: timeout must be positive, got %v", d)
+		}
+		c.Timeout = d
+		return nil
+	}
+}
+
+// WithClientRetries sets how many times the client retransmits before
+// abandoning a transfer. n must not be negative.
+func WithClientRetries(n int) ClientOption {
+	return func(c *Client) error {
+		if n < 0 {
+			return fmt.Errorf("tftp: retries must not be negative, got %d", n)
+		}
+		c.Retries = n
+		return nil
+	}
+}
+
+// WithClientAdaptiveTimeout sets Client.AdaptiveTimeout.
+func WithClientAdaptiveTimeout(enabled bool) ClientOption {
+	return func(c *Client) error {
+		c.AdaptiveTimeout = enabled
+		return nil
+	}
+}
+
+// WithClientLogger sets the Logger the client reports diagnostic output
+// to. logger must not be nil; use NewClient's default no-op Logger to
+// silence output instead of passing nil here.
+func WithClientLogger(logger Logger) ClientOption {
+	return func(c *Client) error {
+		if logger == nil {
+			return fmt.Errorf("tftp: logger must not be nil")
+		}
+		c.Logger = logger
+		return nil
+	}
+}
+
+// WithLocalAddr sets the local host:port every transfer binds to
+// instead of an OS-assigned ephemeral port. See Client.LocalAddr.
+func WithLocalAddr(addr string) ClientOption {
+	return func(c *Client) error {
+		c.LocalAddr = addr
+		return nil
+	}
+}
+
+// WithBlockSize requests size via the blksize option (RFC 2348) on
+// every Get and Put. size must not be negative; SafeBlockSize's bounds
+// are enforced by negotiation itself, not here.
+func WithBlockSize(size int) ClientOption {
+	return func(c *Client) error {
+		if size < 0 {
+			return fmt.Errorf("tftp: block size must not be negative, got %d", size)
+		}
+		c.BlockSize = size
+		return nil
+	}
+}
+
+// WithWindowSize requests size via the windowsize option (RFC 7440) on
+// every Get and Put. size must not be negative.
+func WithWindowSize(size int) ClientOption {
+	return func(c *Client) error {
+		if size < 0 {
+			return fmt.Errorf("tftp: window size must not be negative, got %d", size)
+		}
+		c.WindowSize = size
+		return nil
+	}
+}
+
+// WithClientRetryPolicy sets the RetryPolicy governing the wait between
+// retransmission attempts. policy must not be nil; leave the option off
+// to keep the default exponential-with-jitter behavior instead.
+func WithClientRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *Client) error {
+		if policy == nil {
+			return fmt.Errorf("tftp: retry policy must not be nil")
+		}
+		c.RetryPolicy = policy
+		return nil
+	}
+}
@@ -0,0 +1,32 @@
+package tftp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewClientWithOptionsAppliesOptions(t *testing.T) {
+	c, err := NewClientWithOptions("127.0.0.1:69",
+		WithClientTimeout(5*time.Second),
+		WithClientRetries(2),
+		WithBlockSize(1024),
+	)
+	if err != nil {
+		t.Fatalf("NewClientWithOptions: %v", err)
+	}
+	if c.Timeout != 5*time.Second || c.Retries != 2 || c.BlockSize != 1024 {
+		t.Fatalf("options not applied: %+v", c)
+	}
+}
+
+func TestNewClientWithOptionsRejectsInvalidTimeout(t *testing.T) {
+	if _, err := NewClientWithOptions("127.0.0.1:69", WithClientTimeout(0)); err == nil {
+		t.Fatal("expected an error for a non-positive timeout")
+	}
+}
+
+func TestNewClientWithOptionsRejectsNilRetryPolicy(t *testing.T) {
+	if _, err := NewClientWithOptions("127.0.0.1:69", WithClientRetryPolicy(nil)); err == nil {
+		t.Fatal("expected an error for a nil retry policy")
+	}
+}
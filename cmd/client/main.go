@@ -0,0 +1,684 @@
+// Command client transfers files to or from a TFTP server, the way the
+// standard tftp(1) "get"/"put" commands do: downloads write to a local
+// path (atomically, via Client.GetFile) or to stdout for piping into
+// another tool, with an optional progress indicator while running;
+// -put uploads a local file, or stdin, via WRQ. Passing -filename more
+// than once, or -manifest, switches to a batch download of several
+// files at once with bounded concurrency and a summary report. -pcap
+// records every datagram of the session to a file for inspection in
+// Wireshark; -debug logs the same packets, as hex dumps, to stderr.
+// -json switches single-file mode to emit one JSON result object on
+// stdout instead of writing a download to it, so a provisioning script
+// can parse the outcome instead of scraping stderr text. -firmware-dest
+// switches to firmware-update mode: download -file, verify its sha256,
+// install it atomically, and optionally run -post-install-cmd. -bench
+// switches to benchmark mode: download -file repeatedly, sweeping
+// -bench-block-sizes/-bench-window-sizes, discarding the content and
+// reporting throughput/retransmit stats per combination, to
+// characterize a link before a mass rollout. -interactive drops into a
+// classic tftp(1)-style shell (connect, get, put, mode, blksize,
+// timeout, status, quit) for operators used to driving that utility by
+// hand.
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"hash"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	tftp "github.com/cjvirtucio87/tftp-go"
+)
+
+// repeatedFlag collects every occurrence of a flag passed more than
+// once on the command line, e.g. -filename a.bin -filename b.bin.
+type repeatedFlag []string
+
+func (r *repeatedFlag) String() string { return strings.Join(*r, ",") }
+func (r *repeatedFlag) Set(v string) error {
+	*r = append(*r, v)
+	return nil
+}
+
+func main() {
+	addr := flag.String("addr", "", "TFTP server address (host:port)")
+	file := flag.String("file", "", "name of the remote file (single-file mode)")
+	mode := flag.String("mode", "octet", "transfer mode")
+	output := flag.String("output", "", "single-file download: local path to write the file to (default: stdout)")
+	put := flag.Bool("put", false, "upload instead of download")
+	input := flag.String("input", "", "upload: local path to read (default: stdin)")
+	quiet := flag.Bool("quiet", false, "suppress progress output and the final summary")
+	timeout := flag.Duration("timeout", tftp.DefaultTimeout, "per-block timeout")
+	var filenames repeatedFlag
+	flag.Var(&filenames, "filename", "remote file to download (repeatable); combine with -manifest for a batch job")
+	manifestPath := flag.String("manifest", "", "path to a JSON manifest of {\"remote\":...,\"local\":...} entries to batch-download")
+	outDir := flag.String("outdir", ".", "batch download: directory entries are written into")
+	workers := flag.Int("workers", 4, "batch download: number of concurrent transfers")
+	list := flag.Bool("list", false, "print the server's directory listing (non-standard extension) and exit")
+	pcapPath := flag.String("pcap", "", "write every sent/received datagram to this file in pcap format, for inspection in Wireshark")
+	debug := flag.Bool("debug", false, "log every sent/received packet, with a hex dump, to stderr; overrides -log-level/-log-format")
+	logLevel := flag.String("log-level", "warn", "minimum severity to log: debug, info, warn, or error")
+	logFormat := flag.String("log-format", "console", "log output format: console or json")
+	jsonOut := flag.Bool("json", false, "single-file mode: print one JSON result object (filename, bytes, duration_ms, checksum, error) to stdout instead of the usual output; requires -output for downloads, since stdout is reserved for the result")
+	firmwareDest := flag.String("firmware-dest", "", "switch to firmware-update mode: download -file, verify it, and atomically install it to this path via Client.UpdateFirmware")
+	sha256Sum := flag.String("sha256", "", "firmware-update mode: expected sha256 digest (hex) of -file; omit to fetch it from <-file>.sha256 on the server")
+	postInstallCmd := flag.String("post-install-cmd", "", "firmware-update mode: executable to run, with the installed path as its only argument, once the new firmware is in place")
+	bench := flag.Bool("bench", false, "switch to benchmark mode: repeatedly download -file (discarding its content), sweeping -bench-block-sizes/-bench-window-sizes, and report throughput/retransmit stats per combination")
+	benchBlockSizes := flag.String("bench-block-sizes", "512,1024,4096,16384", "comma-separated blksize values to sweep in -bench mode")
+	benchWindowSizes := flag.String("bench-window-sizes", "1,4,16", "comma-separated windowsize values to sweep in -bench mode")
+	benchIterations := flag.Int("bench-iterations", 3, "number of downloads per block/window size combination in -bench mode")
+	interactive := flag.Bool("interactive", false, "drop into a classic tftp(1)-style interactive shell (connect, get, put, mode, blksize, timeout, status, quit) instead of running a single transfer")
+	flag.Parse()
+
+	if *addr == "" && !*interactive {
+		fmt.Fprintln(os.Stderr, "client: -addr is required")
+		os.Exit(2)
+	}
+	if *jsonOut && (*list || len(filenames) > 0 || *manifestPath != "") {
+		fmt.Fprintln(os.Stderr, "client: -json only supports single-file mode, not -list/-filename/-manifest")
+		os.Exit(2)
+	}
+	if *jsonOut && !*put && !*bench && *output == "" {
+		fmt.Fprintln(os.Stderr, "client: -json download requires -output (stdout is reserved for the JSON result)")
+		os.Exit(2)
+	}
+
+	logger, err := clientLogger(*debug, *logLevel, *logFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "client: %v\n", err)
+		os.Exit(2)
+	}
+
+	var capture *tftp.PacketCapture
+	if *pcapPath != "" {
+		f, err := os.Create(*pcapPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "client: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		capture, err = tftp.NewPacketCapture(f)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "client: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *interactive {
+		runREPL(os.Stdin, os.Stdout, *addr, *mode, *timeout, capture, logger)
+		if capture.Err() != nil {
+			fmt.Fprintf(os.Stderr, "client: pcap capture: %v\n", capture.Err())
+		}
+		return
+	}
+
+	if *bench {
+		if *put || *list || len(filenames) > 0 || *manifestPath != "" || *firmwareDest != "" {
+			fmt.Fprintln(os.Stderr, "client: -bench does not combine with -put/-list/-filename/-manifest/-firmware-dest")
+			os.Exit(2)
+		}
+		if *file == "" {
+			fmt.Fprintln(os.Stderr, "client: -bench requires -file")
+			os.Exit(2)
+		}
+		blockSizes, err := parseIntList(*benchBlockSizes)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "client: -bench-block-sizes: %v\n", err)
+			os.Exit(2)
+		}
+		windowSizes, err := parseIntList(*benchWindowSizes)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "client: -bench-window-sizes: %v\n", err)
+			os.Exit(2)
+		}
+		results := runBenchmark(*addr, *file, *mode, *timeout, *benchIterations, blockSizes, windowSizes, capture, logger)
+		if *jsonOut {
+			for _, r := range results {
+				if encErr := json.NewEncoder(os.Stdout).Encode(r); encErr != nil {
+					fmt.Fprintf(os.Stderr, "client: %v\n", encErr)
+				}
+			}
+		} else {
+			printBenchResults(results)
+		}
+		if capture.Err() != nil {
+			fmt.Fprintf(os.Stderr, "client: pcap capture: %v\n", capture.Err())
+		}
+		for _, r := range results {
+			if r.Errors > 0 {
+				os.Exit(1)
+			}
+		}
+		return
+	}
+
+	if *firmwareDest != "" {
+		if *put || *list || len(filenames) > 0 || *manifestPath != "" {
+			fmt.Fprintln(os.Stderr, "client: -firmware-dest does not combine with -put/-list/-filename/-manifest")
+			os.Exit(2)
+		}
+		if *file == "" {
+			fmt.Fprintln(os.Stderr, "client: -firmware-dest requires -file")
+			os.Exit(2)
+		}
+		if err := updateFirmware(*addr, *timeout, capture, logger, *file, *mode, *sha256Sum, *firmwareDest, *postInstallCmd); err != nil {
+			fmt.Fprintf(os.Stderr, "client: %v\n", err)
+			os.Exit(1)
+		}
+		if !*quiet {
+			fmt.Fprintf(os.Stderr, "client: installed %s to %s\n", *file, *firmwareDest)
+		}
+		if capture.Err() != nil {
+			fmt.Fprintf(os.Stderr, "client: pcap capture: %v\n", capture.Err())
+		}
+		return
+	}
+
+	if *list {
+		if err := printListing(*addr, *timeout, capture, logger); err != nil {
+			fmt.Fprintf(os.Stderr, "client: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(filenames) > 0 || *manifestPath != "" {
+		if *put {
+			fmt.Fprintln(os.Stderr, "client: -put does not support -filename/-manifest batch mode")
+			os.Exit(2)
+		}
+		if err := runBatch(*addr, *mode, *timeout, *outDir, *workers, *quiet, filenames, *manifestPath, capture, logger); err != nil {
+			fmt.Fprintf(os.Stderr, "client: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *file == "" {
+		fmt.Fprintln(os.Stderr, "client: -file is required (or use -filename/-manifest for a batch download)")
+		os.Exit(2)
+	}
+
+	c := tftp.NewClient(*addr)
+	c.Timeout = *timeout
+	c.Capture = capture
+	c.Logger = logger
+
+	var stats tftp.Stats
+	var checksum string
+	if *put {
+		stats, checksum, err = putFrom(c, *file, *mode, *input, *jsonOut)
+	} else {
+		showProgress := !*jsonOut && !*quiet && isTerminal(os.Stderr)
+		if showProgress {
+			c.ProgressFunc = func(received, total int64) {
+				printProgress(*file, received, total)
+			}
+		}
+		if *output != "" {
+			stats, checksum, err = getToFile(c, *file, *mode, *output, *jsonOut)
+		} else {
+			stats, err = getToStdout(c, *file, *mode)
+		}
+		if showProgress {
+			fmt.Fprintln(os.Stderr)
+		}
+	}
+
+	if *jsonOut {
+		printJSONResult(*file, stats, checksum, err)
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "client: %v\n", err)
+	} else if !*quiet {
+		fmt.Fprintf(os.Stderr, "client: %d bytes in %s (%d blocks, %d retransmissions)\n",
+			stats.Bytes, stats.Duration.Round(time.Millisecond), stats.Blocks, stats.Retransmissions)
+	}
+	if err != nil {
+		os.Exit(1)
+	}
+	if capture.Err() != nil {
+		fmt.Fprintf(os.Stderr, "client: pcap capture: %v\n", capture.Err())
+	}
+}
+
+// jsonResult is the single object -json prints to stdout: enough for a
+// provisioning script to tell what happened without scraping stderr
+// text. Checksum is the sha256 of the transferred content, hex-encoded,
+// and is empty if the transfer failed before any content moved.
+type jsonResult struct {
+	Filename   string `json:"filename"`
+	Bytes      int64  `json:"bytes"`
+	DurationMS int64  `json:"duration_ms"`
+	Checksum   string `json:"checksum,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// printJSONResult writes res as a single JSON line to stdout.
+func printJSONResult(filename string, stats tftp.Stats, checksum string, err error) {
+	res := jsonResult{
+		Filename:   filename,
+		Bytes:      stats.Bytes,
+		DurationMS: stats.Duration.Milliseconds(),
+		Checksum:   checksum,
+	}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	if encErr := json.NewEncoder(os.Stdout).Encode(res); encErr != nil {
+		fmt.Fprintf(os.Stderr, "client: %v\n", encErr)
+	}
+}
+
+// manifestEntry is one file in a -manifest batch download. Local
+// defaults to Remote's base name, relative to -outdir, when omitted.
+type manifestEntry struct {
+	Remote string `json:"remote"`
+	Local  string `json:"local,omitempty"`
+}
+
+// loadManifest reads a JSON array of manifestEntry from path.
+func loadManifest(path string) ([]manifestEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open manifest: %w", err)
+	}
+	defer f.Close()
+
+	var entries []manifestEntry
+	if err := json.NewDecoder(f).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+	return entries, nil
+}
+
+// runBatch downloads every entry named by filenames and manifestPath
+// with at most workers transfers in flight at once, via Client.GetAll,
+// then writes each result to outDir and prints a line per file so a
+// PXE asset sync doesn't need a shell loop around a series of
+// single-file invocations. It reports every failure rather than
+// stopping at the first one, and returns a non-nil error only once all
+// of them are known.
+func runBatch(addr, mode string, timeout time.Duration, outDir string, workers int, quiet bool, filenames []string, manifestPath string, capture *tftp.PacketCapture, logger tftp.Logger) error {
+	var entries []manifestEntry
+	for _, f := range filenames {
+		entries = append(entries, manifestEntry{Remote: f})
+	}
+	if manifestPath != "" {
+		manifestEntries, err := loadManifest(manifestPath)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, manifestEntries...)
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("-manifest listed no entries")
+	}
+
+	c := tftp.NewClient(addr)
+	c.Timeout = timeout
+	c.Capture = capture
+	c.Logger = logger
+
+	reqs := make([]tftp.FileRequest, len(entries))
+	for i, e := range entries {
+		reqs[i] = tftp.FileRequest{Filename: e.Remote, Mode: mode}
+	}
+	results := c.GetAll(context.Background(), reqs, workers)
+
+	failures := 0
+	for i, res := range results {
+		local := entries[i].Local
+		if local == "" {
+			local = filepath.Base(entries[i].Remote)
+		}
+		dest := filepath.Join(outDir, filepath.FromSlash(local))
+
+		if res.Err == nil {
+			res.Err = writeFileAtomic(dest, res.Data)
+		}
+		if res.Err != nil {
+			failures++
+			fmt.Fprintf(os.Stderr, "client: %s: %v\n", entries[i].Remote, res.Err)
+			continue
+		}
+		if !quiet {
+			fmt.Fprintf(os.Stderr, "client: %s -> %s (%d bytes)\n", entries[i].Remote, dest, len(res.Data))
+		}
+	}
+
+	if !quiet {
+		fmt.Fprintf(os.Stderr, "client: %d/%d files succeeded\n", len(entries)-failures, len(entries))
+	}
+	if failures > 0 {
+		return fmt.Errorf("%d of %d files failed", failures, len(entries))
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to dest via a temporary file in the same
+// directory followed by a rename, so a batch download interrupted
+// partway through never leaves a truncated file at dest.
+func writeFileAtomic(dest string, data []byte) (err error) {
+	dir := filepath.Dir(dest)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(dest)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+	if _, err = tmp.Write(data); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, dest)
+}
+
+// parseIntList parses a comma-separated list of positive integers, e.g.
+// "512,1024,4096", as used by -bench-block-sizes/-bench-window-sizes.
+func parseIntList(s string) ([]int, error) {
+	var out []int
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid value %q", part)
+		}
+		out = append(out, n)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("at least one value is required")
+	}
+	return out, nil
+}
+
+// benchResult aggregates -bench-iterations downloads of the same
+// block/window size combination, so a flaky single run doesn't stand in
+// for the link's actual characteristics.
+type benchResult struct {
+	BlockSize       int     `json:"block_size"`
+	WindowSize      int     `json:"window_size"`
+	Iterations      int     `json:"iterations"`
+	Errors          int     `json:"errors"`
+	Bytes           int64   `json:"bytes"`
+	DurationMS      int64   `json:"duration_ms"`
+	ThroughputBps   float64 `json:"throughput_bytes_per_sec"`
+	Retransmissions int     `json:"retransmissions"`
+	Timeouts        int     `json:"timeouts"`
+	DuplicateACKs   int     `json:"duplicate_acks"`
+}
+
+// runBenchmark downloads file -bench-iterations times for every
+// combination of blockSizes x windowSizes, discarding the content as it
+// arrives, and returns one aggregated benchResult per combination in
+// sweep order. A combination where every iteration fails still gets a
+// result (Errors == iterations, every other field zero), so the report
+// shows which settings the server rejected outright rather than
+// silently omitting them.
+func runBenchmark(addr, file, mode string, timeout time.Duration, iterations int, blockSizes, windowSizes []int, capture *tftp.PacketCapture, logger tftp.Logger) []benchResult {
+	results := make([]benchResult, 0, len(blockSizes)*len(windowSizes))
+	for _, bs := range blockSizes {
+		for _, ws := range windowSizes {
+			r := benchResult{BlockSize: bs, WindowSize: ws, Iterations: iterations}
+			for i := 0; i < iterations; i++ {
+				c := tftp.NewClient(addr)
+				c.Timeout = timeout
+				c.Capture = capture
+				c.Logger = logger
+				c.BlockSize = bs
+				c.WindowSize = ws
+
+				rc, err := c.Get(context.Background(), file, mode)
+				if err != nil {
+					r.Errors++
+					continue
+				}
+				_, copyErr := io.Copy(io.Discard, rc)
+				rc.Close()
+				if copyErr != nil {
+					r.Errors++
+					continue
+				}
+				stats := rc.Stats()
+				r.Bytes += stats.Bytes
+				r.DurationMS += stats.Duration.Milliseconds()
+				r.Retransmissions += stats.Retransmissions
+				r.Timeouts += stats.Timeouts
+				r.DuplicateACKs += stats.DuplicateACKs
+			}
+			if r.DurationMS > 0 {
+				r.ThroughputBps = float64(r.Bytes) / (float64(r.DurationMS) / 1000)
+			}
+			results = append(results, r)
+		}
+	}
+	return results
+}
+
+// printBenchResults prints one line per benchResult to stdout, in the
+// sweep order runBenchmark produced them.
+func printBenchResults(results []benchResult) {
+	fmt.Printf("%-10s %-11s %10s %14s %8s %10s %10s %8s\n",
+		"blksize", "windowsize", "bytes", "throughput/s", "errors", "retrans", "timeouts", "dupacks")
+	for _, r := range results {
+		fmt.Printf("%-10d %-11d %10d %14.0f %8d %10d %10d %8d\n",
+			r.BlockSize, r.WindowSize, r.Bytes, r.ThroughputBps, r.Errors, r.Retransmissions, r.Timeouts, r.DuplicateACKs)
+	}
+}
+
+// printListing fetches and prints the server's directory listing via
+// the non-standard ListFiles extension, one name per line.
+func printListing(addr string, timeout time.Duration, capture *tftp.PacketCapture, logger tftp.Logger) error {
+	c := tftp.NewClient(addr)
+	c.Timeout = timeout
+	c.Capture = capture
+	c.Logger = logger
+
+	names, err := c.ListFiles(context.Background())
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		fmt.Println(name)
+	}
+	return nil
+}
+
+// updateFirmware downloads file, verifies it, and installs it to
+// destPath via Client.UpdateFirmware. When postInstallCmd is set, it's
+// run as the post-install hook with the installed path as its only
+// argument -- no shell involved, so it must name an executable
+// directly rather than a shell one-liner.
+func updateFirmware(addr string, timeout time.Duration, capture *tftp.PacketCapture, logger tftp.Logger, file, mode, sha256Sum, destPath, postInstallCmd string) error {
+	c := tftp.NewClient(addr)
+	c.Timeout = timeout
+	c.Capture = capture
+	c.Logger = logger
+
+	u := tftp.FirmwareUpdate{
+		Filename: file,
+		Mode:     mode,
+		SHA256:   sha256Sum,
+		DestPath: destPath,
+	}
+	if postInstallCmd != "" {
+		u.PostInstall = func(destPath string) error {
+			cmd := exec.Command(postInstallCmd, destPath)
+			cmd.Stdout = os.Stderr
+			cmd.Stderr = os.Stderr
+			return cmd.Run()
+		}
+	}
+	return c.UpdateFirmware(context.Background(), u)
+}
+
+// putFrom uploads inputPath (or stdin, when inputPath is empty) to the
+// server as file. When checksum is true, it also returns the hex-encoded
+// sha256 of the uploaded content, computed as it streams past rather
+// than by rereading the source afterward, so it works for stdin too.
+func putFrom(c *tftp.Client, file, mode, inputPath string, checksum bool) (tftp.Stats, string, error) {
+	r := io.Reader(os.Stdin)
+	if inputPath != "" {
+		f, err := os.Open(inputPath)
+		if err != nil {
+			return tftp.Stats{}, "", err
+		}
+		defer f.Close()
+		r = f
+	}
+	var h hash.Hash
+	if checksum {
+		h = sha256.New()
+		r = io.TeeReader(r, h)
+	}
+	stats, err := c.Put(context.Background(), file, mode, r)
+	if checksum && err == nil {
+		return stats, hex.EncodeToString(h.Sum(nil)), nil
+	}
+	return stats, "", err
+}
+
+// getToFile downloads file to destPath. When checksum is true, it also
+// returns the hex-encoded sha256 of the downloaded content, computed by
+// rereading the file GetFile just wrote, since GetFile itself hands
+// back no stream to tee from.
+func getToFile(c *tftp.Client, file, mode, destPath string, checksum bool) (tftp.Stats, string, error) {
+	if err := c.GetFile(context.Background(), file, mode, destPath); err != nil {
+		return tftp.Stats{}, "", err
+	}
+	fi, err := os.Stat(destPath)
+	if err != nil {
+		return tftp.Stats{}, "", err
+	}
+	stats := tftp.Stats{Bytes: fi.Size()}
+	if !checksum {
+		return stats, "", nil
+	}
+	f, err := os.Open(destPath)
+	if err != nil {
+		return stats, "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return stats, "", err
+	}
+	return stats, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func getToStdout(c *tftp.Client, file, mode string) (tftp.Stats, error) {
+	rc, err := c.Get(context.Background(), file, mode)
+	if err != nil {
+		return tftp.Stats{}, err
+	}
+	defer rc.Close()
+
+	if _, err := io.Copy(os.Stdout, rc); err != nil {
+		return tftp.Stats{}, err
+	}
+	return rc.Stats(), nil
+}
+
+// printProgress writes a single-line, carriage-return-updated progress
+// indicator to stderr. total is -1 when the server didn't report tsize
+// (RFC 2349), in which case only the running byte count is shown.
+func printProgress(file string, received, total int64) {
+	if total > 0 {
+		fmt.Fprintf(os.Stderr, "\r%s: %d/%d bytes (%.0f%%)", file, received, total, 100*float64(received)/float64(total))
+	} else {
+		fmt.Fprintf(os.Stderr, "\r%s: %d bytes", file, received)
+	}
+}
+
+// isTerminal reports whether f is attached to a character device (a
+// terminal) rather than a file or pipe, without pulling in a terminal
+// detection package: a progress indicator that rewrites its line with
+// \r only makes sense when something is there to render it live.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// stderrDebugLogger is the tftp.DebugLogger set on a Client when -debug
+// is passed. Both Printf and Debugf write straight to stderr with a
+// "client: " prefix, matching every other diagnostic line this command
+// prints.
+type stderrDebugLogger struct{}
+
+func (stderrDebugLogger) Printf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "client: "+format+"\n", args...)
+}
+
+func (stderrDebugLogger) Debugf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "client: "+format+"\n", args...)
+}
+
+// clientLogger builds the tftp.Logger a Client should use given -debug,
+// -log-level, and -log-format. -debug wins unconditionally and keeps
+// its existing hex-dump-to-stderr behavior unchanged, since that's a
+// packet trace rather than a severity-leveled log and the two don't mix
+// cleanly under one -log-level knob.
+func clientLogger(debug bool, level, format string) (tftp.Logger, error) {
+	if debug {
+		return stderrDebugLogger{}, nil
+	}
+	handler, err := clientLogHandler(level, format)
+	if err != nil {
+		return nil, err
+	}
+	return tftp.NewSlogLogger(slog.New(handler)), nil
+}
+
+// clientLogHandler builds the slog.Handler -log-level/-log-format
+// describe, writing to stderr, returning an error for a level or format
+// this binary doesn't recognize.
+func clientLogHandler(level, format string) (slog.Handler, error) {
+	var lvl slog.Level
+	switch level {
+	case "debug":
+		lvl = slog.LevelDebug
+	case "info":
+		lvl = slog.LevelInfo
+	case "warn":
+		lvl = slog.LevelWarn
+	case "error":
+		lvl = slog.LevelError
+	default:
+		return nil, fmt.Errorf("-log-level %q: must be debug, info, warn, or error", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch format {
+	case "console":
+		return slog.NewTextHandler(os.Stderr, opts), nil
+	case "json":
+		return slog.NewJSONHandler(os.Stderr, opts), nil
+	default:
+		return nil, fmt.Errorf("-log-format %q: must be console or json", format)
+	}
+}
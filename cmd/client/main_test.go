@@ -0,0 +1,371 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	tftp "github.com/cjvirtucio87/tftp-go"
+)
+
+type memHandler struct {
+	mu       sync.Mutex
+	data     []byte
+	files    map[string][]byte
+	uploaded []byte
+}
+
+func (h *memHandler) ReadFile(filename string) ([]byte, error) {
+	if data, ok := h.files[filename]; ok {
+		return data, nil
+	}
+	return h.data, nil
+}
+
+func (h *memHandler) WriteFile(filename string, data []byte) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.uploaded = data
+	return nil
+}
+
+func (h *memHandler) uploadedData() []byte {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.uploaded
+}
+
+func startTestServer(t *testing.T, data []byte) (string, *memHandler) {
+	t.Helper()
+	return startTestServerWithFiles(t, data, nil)
+}
+
+func startTestServerWithFiles(t *testing.T, data []byte, files map[string][]byte) (string, *memHandler) {
+	t.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	h := &memHandler{data: data, files: files}
+	s := tftp.NewServer("", h)
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+	return conn.LocalAddr().String(), h
+}
+
+func TestGetToFileWritesContents(t *testing.T) {
+	want := bytes.Repeat([]byte("x"), 4096)
+	addr, _ := startTestServer(t, want)
+
+	c := tftp.NewClient(addr)
+	c.Timeout = time.Second
+
+	dest := filepath.Join(t.TempDir(), "out.bin")
+	stats, checksum, err := getToFile(c, "file.bin", "octet", dest, true)
+	if err != nil {
+		t.Fatalf("getToFile: %v", err)
+	}
+	if stats.Bytes != int64(len(want)) {
+		t.Errorf("Stats.Bytes = %d, want %d", stats.Bytes, len(want))
+	}
+	got, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("wrote %d bytes, want %d", len(got), len(want))
+	}
+	wantSum := sha256.Sum256(want)
+	if checksum != hex.EncodeToString(wantSum[:]) {
+		t.Errorf("checksum = %q, want %q", checksum, hex.EncodeToString(wantSum[:]))
+	}
+}
+
+func TestPutFromFileUploadsContents(t *testing.T) {
+	addr, h := startTestServer(t, nil)
+
+	src := filepath.Join(t.TempDir(), "config.txt")
+	want := []byte("hostname switch-1\n")
+	if err := os.WriteFile(src, want, 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	c := tftp.NewClient(addr)
+	c.Timeout = time.Second
+
+	stats, checksum, err := putFrom(c, "config.txt", "octet", src, true)
+	if err != nil {
+		t.Fatalf("putFrom: %v", err)
+	}
+	if stats.Bytes != int64(len(want)) {
+		t.Errorf("Stats.Bytes = %d, want %d", stats.Bytes, len(want))
+	}
+	wantSum := sha256.Sum256(want)
+	if checksum != hex.EncodeToString(wantSum[:]) {
+		t.Errorf("checksum = %q, want %q", checksum, hex.EncodeToString(wantSum[:]))
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) && h.uploadedData() == nil {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := h.uploadedData(); !bytes.Equal(got, want) {
+		t.Fatalf("uploaded %q, want %q", got, want)
+	}
+}
+
+func TestPutFromStdinUploadsContents(t *testing.T) {
+	addr, h := startTestServer(t, nil)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Pipe: %v", err)
+	}
+	want := "interface GigabitEthernet0/1\n"
+	go func() {
+		w.WriteString(want)
+		w.Close()
+	}()
+	oldStdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = oldStdin }()
+
+	c := tftp.NewClient(addr)
+	c.Timeout = time.Second
+
+	if _, _, err := putFrom(c, "config.txt", "octet", "", false); err != nil {
+		t.Fatalf("putFrom: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) && h.uploadedData() == nil {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := h.uploadedData(); string(got) != want {
+		t.Fatalf("uploaded %q, want %q", got, want)
+	}
+}
+
+func TestPrintJSONResultSuccess(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Pipe: %v", err)
+	}
+	oldStdout := os.Stdout
+	os.Stdout = w
+	printJSONResult("config.txt", tftp.Stats{Bytes: 19, Duration: 250 * time.Millisecond}, "deadbeef", nil)
+	w.Close()
+	os.Stdout = oldStdout
+
+	var got jsonResult
+	if err := json.NewDecoder(r).Decode(&got); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	want := jsonResult{Filename: "config.txt", Bytes: 19, DurationMS: 250, Checksum: "deadbeef"}
+	if got != want {
+		t.Errorf("printJSONResult wrote %+v, want %+v", got, want)
+	}
+}
+
+func TestPrintJSONResultFailure(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Pipe: %v", err)
+	}
+	oldStdout := os.Stdout
+	os.Stdout = w
+	printJSONResult("config.txt", tftp.Stats{}, "", errors.New("file not found"))
+	w.Close()
+	os.Stdout = oldStdout
+
+	var got jsonResult
+	if err := json.NewDecoder(r).Decode(&got); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if got.Error != "file not found" {
+		t.Errorf("Error = %q, want %q", got.Error, "file not found")
+	}
+	if got.Checksum != "" {
+		t.Errorf("Checksum = %q, want empty on failure", got.Checksum)
+	}
+}
+
+func TestUpdateFirmwareInstallsVerifiedImage(t *testing.T) {
+	want := bytes.Repeat([]byte("f"), 4096)
+	sum := sha256.Sum256(want)
+	addr, _ := startTestServerWithFiles(t, nil, map[string][]byte{"firmware.bin": want})
+
+	dest := filepath.Join(t.TempDir(), "installed.bin")
+	err := updateFirmware(addr, time.Second, nil, nil, "firmware.bin", "octet", hex.EncodeToString(sum[:]), dest, "")
+	if err != nil {
+		t.Fatalf("updateFirmware: %v", err)
+	}
+	got, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("installed %d bytes, want %d", len(got), len(want))
+	}
+}
+
+func TestUpdateFirmwareRunsPostInstallCmd(t *testing.T) {
+	want := []byte("firmware contents")
+	sum := sha256.Sum256(want)
+	addr, _ := startTestServerWithFiles(t, nil, map[string][]byte{"firmware.bin": want})
+
+	dest := filepath.Join(t.TempDir(), "installed.bin")
+	marker := filepath.Join(t.TempDir(), "ran")
+	script := filepath.Join(t.TempDir(), "post-install.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\ncp \"$1\" \""+marker+"\"\n"), 0o755); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	err := updateFirmware(addr, time.Second, nil, nil, "firmware.bin", "octet", hex.EncodeToString(sum[:]), dest, script)
+	if err != nil {
+		t.Fatalf("updateFirmware: %v", err)
+	}
+	got, err := os.ReadFile(marker)
+	if err != nil {
+		t.Fatalf("post-install hook did not run: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("post-install hook saw %q, want %q", got, want)
+	}
+}
+
+func TestLoadManifest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	if err := os.WriteFile(path, []byte(`[{"remote":"kernel.bin"},{"remote":"initrd.img","local":"boot/initrd.img"}]`), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	entries, err := loadManifest(path)
+	if err != nil {
+		t.Fatalf("loadManifest: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Remote != "kernel.bin" || entries[1].Local != "boot/initrd.img" {
+		t.Fatalf("loadManifest = %+v, want two entries matching the manifest", entries)
+	}
+}
+
+func TestRunBatchDownloadsEveryEntry(t *testing.T) {
+	files := map[string][]byte{
+		"kernel.bin": bytes.Repeat([]byte("k"), 2048),
+		"initrd.img": bytes.Repeat([]byte("i"), 1024),
+	}
+	addr, _ := startTestServerWithFiles(t, nil, files)
+
+	manifestPath := filepath.Join(t.TempDir(), "manifest.json")
+	if err := os.WriteFile(manifestPath, []byte(`[{"remote":"initrd.img","local":"boot/initrd.img"}]`), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	outDir := t.TempDir()
+	if err := runBatch(addr, "octet", time.Second, outDir, 2, true, []string{"kernel.bin"}, manifestPath, nil, nil); err != nil {
+		t.Fatalf("runBatch: %v", err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(outDir, "kernel.bin"))
+	if err != nil {
+		t.Fatalf("ReadFile kernel.bin: %v", err)
+	}
+	if !bytes.Equal(got, files["kernel.bin"]) {
+		t.Error("kernel.bin contents did not match")
+	}
+	got, err = os.ReadFile(filepath.Join(outDir, "boot", "initrd.img"))
+	if err != nil {
+		t.Fatalf("ReadFile boot/initrd.img: %v", err)
+	}
+	if !bytes.Equal(got, files["initrd.img"]) {
+		t.Error("boot/initrd.img contents did not match")
+	}
+}
+
+func TestIsTerminalFalseForRegularFile(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "not-a-tty")
+	if err != nil {
+		t.Fatalf("CreateTemp: %v", err)
+	}
+	defer f.Close()
+
+	if isTerminal(f) {
+		t.Error("isTerminal(regular file) = true, want false")
+	}
+}
+
+func TestParseIntList(t *testing.T) {
+	got, err := parseIntList("512, 1024,4096")
+	if err != nil {
+		t.Fatalf("parseIntList: %v", err)
+	}
+	want := []int{512, 1024, 4096}
+	if len(got) != len(want) {
+		t.Fatalf("parseIntList = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("parseIntList = %v, want %v", got, want)
+		}
+	}
+
+	if _, err := parseIntList(""); err == nil {
+		t.Error("parseIntList(\"\"): want an error for an empty list")
+	}
+	if _, err := parseIntList("512,nope"); err == nil {
+		t.Error("parseIntList(\"512,nope\"): want an error for a non-numeric value")
+	}
+	if _, err := parseIntList("512,-1"); err == nil {
+		t.Error("parseIntList(\"512,-1\"): want an error for a non-positive value")
+	}
+}
+
+func TestRunBenchmarkSweepsEveryCombination(t *testing.T) {
+	want := bytes.Repeat([]byte("b"), 4096)
+	addr, _ := startTestServer(t, want)
+
+	results := runBenchmark(addr, "file.bin", "octet", time.Second, 2, []int{512, 1024}, []int{1}, nil, nil)
+	if len(results) != 2 {
+		t.Fatalf("runBenchmark returned %d results, want 2", len(results))
+	}
+	seen := map[[2]int]bool{}
+	for _, r := range results {
+		seen[[2]int{r.BlockSize, r.WindowSize}] = true
+		if r.Errors != 0 {
+			t.Errorf("result %+v: Errors = %d, want 0", r, r.Errors)
+		}
+		if r.Bytes != int64(len(want))*2 {
+			t.Errorf("result %+v: Bytes = %d, want %d", r, r.Bytes, int64(len(want))*2)
+		}
+	}
+	for _, bs := range []int{512, 1024} {
+		if !seen[[2]int{bs, 1}] {
+			t.Errorf("runBenchmark missing combination blocksize=%d windowsize=1", bs)
+		}
+	}
+}
+
+func TestRunBenchmarkRecordsErrorsForAnUnreachableServer(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	addr := conn.LocalAddr().String()
+	conn.Close() // nothing is listening on addr from this point on
+
+	results := runBenchmark(addr, "missing.bin", "octet", 200*time.Millisecond, 2, []int{512}, []int{1}, nil, nil)
+	if len(results) != 1 {
+		t.Fatalf("runBenchmark returned %d results, want 1", len(results))
+	}
+	if results[0].Errors != 2 {
+		t.Errorf("Errors = %d, want 2", results[0].Errors)
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	tftp "github.com/cjvirtucio87/tftp-go"
+)
+
+// replState holds the session settings a classic tftp(1) shell carries
+// between commands: the connected server, transfer mode, and the knobs
+// that otherwise come from this command's flags. connect resets addr
+// and lazily builds a fresh *tftp.Client off it; every other command
+// reads and writes this state directly, mirroring the traditional
+// tftp> prompt's "connect once, issue several get/put" workflow.
+type replState struct {
+	addr    string
+	mode    string
+	timeout time.Duration
+	blksize int
+	verbose bool
+
+	capture *tftp.PacketCapture
+	logger  tftp.Logger
+}
+
+// runREPL drives an interactive tftp(1)-style shell reading commands
+// from in and writing prompts/output to out, until "quit"/"exit" or
+// EOF. addr and mode seed the initial session state from -addr/-mode,
+// the same as a plain single-file invocation would use, so dropping
+// into -interactive with -addr already set skips having to "connect"
+// by hand.
+func runREPL(in io.Reader, out io.Writer, addr, mode string, timeout time.Duration, capture *tftp.PacketCapture, logger tftp.Logger) {
+	st := &replState{addr: addr, mode: mode, timeout: timeout, capture: capture, logger: logger}
+	scanner := bufio.NewScanner(in)
+	fmt.Fprint(out, "tftp> ")
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" {
+			if quit := st.dispatch(line, out); quit {
+				return
+			}
+		}
+		fmt.Fprint(out, "tftp> ")
+	}
+}
+
+// dispatch runs one REPL command line and reports whether it was
+// "quit" or "exit".
+func (st *replState) dispatch(line string, out io.Writer) bool {
+	fields := strings.Fields(line)
+	cmd, args := fields[0], fields[1:]
+	switch cmd {
+	case "connect":
+		st.cmdConnect(args, out)
+	case "get":
+		st.cmdGet(args, out)
+	case "put":
+		st.cmdPut(args, out)
+	case "mode":
+		st.cmdMode(args, out)
+	case "ascii":
+		st.mode = "netascii"
+	case "binary":
+		st.mode = "octet"
+	case "blksize":
+		st.cmdBlksize(args, out)
+	case "timeout":
+		st.cmdTimeout(args, out)
+	case "verbose":
+		st.verbose = !st.verbose
+		fmt.Fprintf(out, "verbose mode %s\n", onOff(st.verbose))
+	case "status":
+		st.cmdStatus(out)
+	case "help", "?":
+		printREPLHelp(out)
+	case "quit", "exit":
+		return true
+	default:
+		fmt.Fprintf(out, "?Invalid command: %s\n", cmd)
+	}
+	return false
+}
+
+func (st *replState) cmdConnect(args []string, out io.Writer) {
+	if len(args) != 1 {
+		fmt.Fprintln(out, "usage: connect host[:port]")
+		return
+	}
+	st.addr = args[0]
+	if st.verbose {
+		fmt.Fprintf(out, "connected to %s\n", st.addr)
+	}
+}
+
+func (st *replState) cmdMode(args []string, out io.Writer) {
+	if len(args) != 1 {
+		fmt.Fprintln(out, "usage: mode ascii|binary|netascii|octet")
+		return
+	}
+	switch args[0] {
+	case "ascii":
+		st.mode = "netascii"
+	case "binary":
+		st.mode = "octet"
+	case "netascii", "octet":
+		st.mode = args[0]
+	default:
+		fmt.Fprintf(out, "?Unknown mode: %s\n", args[0])
+		return
+	}
+	fmt.Fprintf(out, "mode set to %s\n", st.mode)
+}
+
+func (st *replState) cmdBlksize(args []string, out io.Writer) {
+	if len(args) != 1 {
+		fmt.Fprintln(out, "usage: blksize <size>")
+		return
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n <= 0 {
+		fmt.Fprintf(out, "?Invalid block size: %s\n", args[0])
+		return
+	}
+	st.blksize = n
+}
+
+func (st *replState) cmdTimeout(args []string, out io.Writer) {
+	if len(args) != 1 {
+		fmt.Fprintln(out, "usage: timeout <seconds>")
+		return
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n <= 0 {
+		fmt.Fprintf(out, "?Invalid timeout: %s\n", args[0])
+		return
+	}
+	st.timeout = time.Duration(n) * time.Second
+}
+
+func (st *replState) cmdStatus(out io.Writer) {
+	connected := st.addr
+	if connected == "" {
+		connected = "not connected"
+	}
+	fmt.Fprintf(out, "Connected to %s\n", connected)
+	fmt.Fprintf(out, "Mode: %s\n", st.mode)
+	fmt.Fprintf(out, "Verbose: %s\n", onOff(st.verbose))
+	fmt.Fprintf(out, "Timeout: %s\n", st.timeout)
+	if st.blksize > 0 {
+		fmt.Fprintf(out, "Block size: %d\n", st.blksize)
+	}
+}
+
+// client builds a *tftp.Client from the REPL's current session state.
+// It returns an error instead of a Client when no server has been
+// connect-ed yet.
+func (st *replState) client() (*tftp.Client, error) {
+	if st.addr == "" {
+		return nil, fmt.Errorf("not connected; use 'connect host[:port]' first")
+	}
+	c := tftp.NewClient(st.addr)
+	c.Timeout = st.timeout
+	c.BlockSize = st.blksize
+	c.Capture = st.capture
+	c.Logger = st.logger
+	return c, nil
+}
+
+func (st *replState) cmdGet(args []string, out io.Writer) {
+	if len(args) < 1 || len(args) > 2 {
+		fmt.Fprintln(out, "usage: get remote-file [local-file]")
+		return
+	}
+	c, err := st.client()
+	if err != nil {
+		fmt.Fprintf(out, "?%v\n", err)
+		return
+	}
+	remote := args[0]
+	local := remote
+	if len(args) == 2 {
+		local = args[1]
+	}
+	start := time.Now()
+	if err := c.GetFile(context.Background(), remote, st.mode, local); err != nil {
+		fmt.Fprintf(out, "?Get %s: %v\n", remote, err)
+		return
+	}
+	if st.verbose {
+		fmt.Fprintf(out, "Received %s in %s\n", local, time.Since(start).Round(time.Millisecond))
+	}
+}
+
+func (st *replState) cmdPut(args []string, out io.Writer) {
+	if len(args) < 1 || len(args) > 2 {
+		fmt.Fprintln(out, "usage: put local-file [remote-file]")
+		return
+	}
+	c, err := st.client()
+	if err != nil {
+		fmt.Fprintf(out, "?%v\n", err)
+		return
+	}
+	local := args[0]
+	remote := local
+	if len(args) == 2 {
+		remote = args[1]
+	}
+	stats, _, err := putFrom(c, remote, st.mode, local, false)
+	if err != nil {
+		fmt.Fprintf(out, "?Put %s: %v\n", local, err)
+		return
+	}
+	if st.verbose {
+		fmt.Fprintf(out, "Sent %s: %d bytes in %s\n", remote, stats.Bytes, stats.Duration.Round(time.Millisecond))
+	}
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+// printREPLHelp lists every command the REPL recognizes, in the same
+// rough order tftp(1)'s own "help" output does.
+func printREPLHelp(out io.Writer) {
+	fmt.Fprint(out, `connect host[:port]   set the server to transfer with
+get remote [local]     download remote, optionally as local
+put local [remote]     upload local, optionally as remote
+mode ascii|binary      set the transfer mode (aliases for netascii/octet)
+ascii                  shorthand for 'mode ascii'
+binary                 shorthand for 'mode binary'
+blksize <size>         set the blksize option requested on every transfer
+timeout <seconds>      set the per-block timeout
+verbose                toggle verbose output
+status                 show the current connection and settings
+help, ?                show this message
+quit, exit             leave the shell
+`)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestREPLGetWritesFile(t *testing.T) {
+	want := bytes.Repeat([]byte("x"), 4096)
+	addr, _ := startTestServer(t, want)
+
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "out.bin")
+	in := strings.NewReader("get file.bin " + dest + "\nquit\n")
+	var out bytes.Buffer
+	runREPL(in, &out, addr, "octet", time.Second, nil, nil)
+
+	got, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("got %d bytes, want %d matching", len(got), len(want))
+	}
+}
+
+func TestREPLPutUploadsFile(t *testing.T) {
+	addr, h := startTestServer(t, nil)
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "in.bin")
+	want := []byte("config data")
+	if err := os.WriteFile(src, want, 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	in := strings.NewReader("put " + src + " config.txt\nquit\n")
+	var out bytes.Buffer
+	runREPL(in, &out, addr, "octet", time.Second, nil, nil)
+
+	if got := h.uploadedData(); !bytes.Equal(got, want) {
+		t.Fatalf("uploaded %q, want %q", got, want)
+	}
+}
+
+func TestREPLConnectAndStatus(t *testing.T) {
+	in := strings.NewReader("connect example.com:69\nmode ascii\nstatus\nquit\n")
+	var out bytes.Buffer
+	runREPL(in, &out, "", "octet", time.Second, nil, nil)
+
+	got := out.String()
+	for _, want := range []string{"Connected to example.com:69", "Mode: netascii"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output %q missing %q", got, want)
+		}
+	}
+}
+
+func TestREPLUnknownCommand(t *testing.T) {
+	in := strings.NewReader("frobnicate\nquit\n")
+	var out bytes.Buffer
+	runREPL(in, &out, "", "octet", time.Second, nil, nil)
+
+	if !strings.Contains(out.String(), "?Invalid command: frobnicate") {
+		t.Errorf("output %q missing the invalid command message", out.String())
+	}
+}
+
+func TestREPLGetWithoutConnectFails(t *testing.T) {
+	in := strings.NewReader("get file.bin\nquit\n")
+	var out bytes.Buffer
+	runREPL(in, &out, "", "octet", time.Second, nil, nil)
+
+	if !strings.Contains(out.String(), "not connected") {
+		t.Errorf("output %q missing the not-connected message", out.String())
+	}
+}
@@ -0,0 +1,236 @@
+// Command conformance drives a handful of protocol-level checks against
+// any TFTP server, printing a pass/fail report covering basic transfer,
+// error codes, option negotiation, and final-block framing. It packages
+// the same checks tftp-go's own tests rely on as a standalone tool for
+// validating third-party servers, and doubles as a quick self-check
+// when pointed at this repository's own server.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	tftp "github.com/cjvirtucio87/tftp-go"
+)
+
+type result struct {
+	Name   string
+	Pass   bool
+	Detail string
+}
+
+type check func(addr, file, missing, mode string, timeout time.Duration) result
+
+func main() {
+	addr := flag.String("addr", "", "target TFTP server address (host:port)")
+	file := flag.String("file", "", "name of a file known to exist on the target server")
+	missing := flag.String("missing", "tftp-go-conformance-missing-probe", "filename expected NOT to exist on the target server")
+	mode := flag.String("mode", "octet", "transfer mode")
+	timeout := flag.Duration("timeout", 3*time.Second, "per-attempt timeout")
+	flag.Parse()
+
+	if *addr == "" || *file == "" {
+		fmt.Fprintln(os.Stderr, "conformance: -addr and -file are required")
+		os.Exit(2)
+	}
+
+	checks := []check{
+		checkBasicRead,
+		checkMissingFileError,
+		checkFinalBlockLength,
+		checkBlksizeOption,
+		checkWindowsizeOption,
+	}
+
+	results := make([]result, len(checks))
+	for i, c := range checks {
+		results[i] = c(*addr, *file, *missing, *mode, *timeout)
+	}
+
+	printReport(*addr, results)
+}
+
+func printReport(addr string, results []result) {
+	fmt.Printf("TFTP conformance report for %s\n\n", addr)
+
+	failed := 0
+	for _, r := range results {
+		status := "PASS"
+		if !r.Pass {
+			status = "FAIL"
+			failed++
+		}
+		fmt.Printf("[%s] %-45s %s\n", status, r.Name, r.Detail)
+	}
+
+	fmt.Printf("\n%d/%d checks passed\n", len(results)-failed, len(results))
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func checkBasicRead(addr, file, _, mode string, timeout time.Duration) result {
+	const name = "basic RRQ read"
+	c := tftp.NewClient(addr)
+	c.Timeout = timeout
+	data, err := c.GetBytes(file, mode)
+	if err != nil {
+		return result{name, false, err.Error()}
+	}
+	return result{name, true, fmt.Sprintf("%d bytes", len(data))}
+}
+
+func checkMissingFileError(addr, _, missing, mode string, timeout time.Duration) result {
+	const name = "RRQ of missing file returns ERROR"
+	c := tftp.NewClient(addr)
+	c.Timeout = timeout
+	c.Retries = 1
+
+	_, err := c.GetBytes(missing, mode)
+	if err == nil {
+		return result{name, false, "expected an error, transfer succeeded"}
+	}
+	var pkt *tftp.ERROR
+	if errors.As(err, &pkt) {
+		return result{name, true, fmt.Sprintf("code=%d message=%q", pkt.Code, pkt.Message)}
+	}
+	return result{name, false, fmt.Sprintf("error was not a protocol ERROR packet: %v", err)}
+}
+
+// checkFinalBlockLength drives the RRQ/DATA/ACK exchange directly so it
+// can observe RFC 1350's EOF signal: the last DATA block must be
+// strictly shorter than the negotiated block size (512 here, since no
+// options are requested).
+func checkFinalBlockLength(addr, file, _, mode string, timeout time.Duration) result {
+	const name = "final DATA block shorter than blksize (RFC 1350 EOF)"
+
+	remote, conn, err := dial(addr)
+	if err != nil {
+		return result{name, false, err.Error()}
+	}
+	defer conn.Close()
+
+	req := &tftp.RRQ{Filename: file, Mode: mode}
+	if err := send(conn, req, remote); err != nil {
+		return result{name, false, err.Error()}
+	}
+
+	buf := make([]byte, 65507)
+	var from *net.UDPAddr
+	expected := uint16(1)
+	for {
+		conn.SetReadDeadline(time.Now().Add(timeout))
+		n, rAddr, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			return result{name, false, fmt.Sprintf("read error: %v", err)}
+		}
+		if from == nil {
+			from = rAddr
+		}
+
+		p, err := tftp.ParsePacket(buf[:n])
+		if err != nil {
+			return result{name, false, err.Error()}
+		}
+		switch pkt := p.(type) {
+		case *tftp.DATA:
+			if pkt.Block != expected {
+				return result{name, false, fmt.Sprintf("got block %d, want %d", pkt.Block, expected)}
+			}
+			if err := send(conn, &tftp.ACK{Block: pkt.Block}, from); err != nil {
+				return result{name, false, err.Error()}
+			}
+			if len(pkt.Payload) < tftp.DefaultBlockSize {
+				return result{name, true, fmt.Sprintf("final block was %d bytes", len(pkt.Payload))}
+			}
+			expected++
+		case *tftp.ERROR:
+			return result{name, false, fmt.Sprintf("server returned ERROR: %s", pkt.Message)}
+		default:
+			return result{name, false, fmt.Sprintf("unexpected packet type %T", pkt)}
+		}
+	}
+}
+
+// checkBlksizeOption asks for a 1024-byte block size and accepts either
+// a matching OACK (RFC 2348) or a server that silently ignores the
+// option and falls back to 512-byte DATA blocks, since honoring blksize
+// is optional. It only fails on an actual protocol violation.
+func checkBlksizeOption(addr, file, _, mode string, timeout time.Duration) result {
+	const name = "blksize option negotiation (RFC 2348)"
+	return checkOption(addr, file, mode, timeout, name, tftp.OptBlockSize, "1024")
+}
+
+// checkWindowsizeOption mirrors checkBlksizeOption for RFC 7440's
+// windowsize option.
+func checkWindowsizeOption(addr, file, _, mode string, timeout time.Duration) result {
+	const name = "windowsize option negotiation (RFC 7440)"
+	return checkOption(addr, file, mode, timeout, name, tftp.OptWindowSize, "4")
+}
+
+func checkOption(addr, file, mode string, timeout time.Duration, name, opt, value string) result {
+	remote, conn, err := dial(addr)
+	if err != nil {
+		return result{name, false, err.Error()}
+	}
+	defer conn.Close()
+
+	req := &tftp.RRQ{Filename: file, Mode: mode, Options: tftp.Options{opt: value}}
+	if err := send(conn, req, remote); err != nil {
+		return result{name, false, err.Error()}
+	}
+
+	buf := make([]byte, 65507)
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	n, from, err := conn.ReadFromUDP(buf)
+	if err != nil {
+		return result{name, false, fmt.Sprintf("read error: %v", err)}
+	}
+
+	p, err := tftp.ParsePacket(buf[:n])
+	if err != nil {
+		return result{name, false, err.Error()}
+	}
+	switch pkt := p.(type) {
+	case *tftp.OACK:
+		got, ok := pkt.Options[opt]
+		if !ok {
+			return result{name, false, "OACK did not echo the requested option"}
+		}
+		if err := send(conn, &tftp.ACK{Block: 0}, from); err != nil {
+			return result{name, false, err.Error()}
+		}
+		return result{name, true, fmt.Sprintf("server negotiated %s=%s", opt, got)}
+	case *tftp.DATA:
+		return result{name, true, fmt.Sprintf("server ignored the option, fell back to %d-byte default blocks", len(pkt.Payload))}
+	case *tftp.ERROR:
+		return result{name, false, fmt.Sprintf("server returned ERROR: %s", pkt.Message)}
+	default:
+		return result{name, false, fmt.Sprintf("unexpected packet type %T", pkt)}
+	}
+}
+
+func dial(addr string) (*net.UDPAddr, *net.UDPConn, error) {
+	remote, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return remote, conn, nil
+}
+
+func send(conn *net.UDPConn, p tftp.Packet, addr *net.UDPAddr) error {
+	b, err := p.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	_, err = conn.WriteToUDP(b, addr)
+	return err
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	tftp "github.com/cjvirtucio87/tftp-go"
+)
+
+type memHandler struct {
+	files map[string][]byte
+}
+
+func (h *memHandler) ReadFile(name string) ([]byte, error) {
+	data, ok := h.files[name]
+	if !ok {
+		return nil, tftp.NewError(tftp.ErrCodeNotFound, "not found")
+	}
+	return data, nil
+}
+
+func (h *memHandler) WriteFile(name string, data []byte) error {
+	h.files[name] = data
+	return nil
+}
+
+func startServer(t *testing.T, files map[string][]byte) string {
+	t.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s := tftp.NewServer("", &memHandler{files: files})
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+	return conn.LocalAddr().String()
+}
+
+func TestChecksAgainstOwnServer(t *testing.T) {
+	want := bytes.Repeat([]byte("x"), tftp.DefaultBlockSize*2+7)
+	addr := startServer(t, map[string][]byte{"file.bin": want})
+
+	checks := []check{
+		checkBasicRead,
+		checkMissingFileError,
+		checkFinalBlockLength,
+		checkBlksizeOption,
+		checkWindowsizeOption,
+	}
+
+	for _, c := range checks {
+		r := c(addr, "file.bin", "missing-probe", "octet", time.Second)
+		if !r.Pass {
+			t.Errorf("%s failed: %s", r.Name, r.Detail)
+		}
+	}
+}
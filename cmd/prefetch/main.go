@@ -0,0 +1,179 @@
+// Command prefetch downloads every artifact listed in a manifest from a
+// single origin TFTP server into a local directory, verifying each
+// download's checksum. It's meant for warming edge provisioning servers
+// ahead of a rollout, before they're reachable over the link that makes
+// one-at-a-time TFTP fetches too slow.
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	tftp "github.com/cjvirtucio87/tftp-go"
+)
+
+// manifestEntry is one artifact to fetch. Path is used both as the
+// filename requested from the origin server and, relative to -out, as
+// the destination on disk, so a manifest can mirror a directory tree.
+// SHA256 is optional; when present, a mismatch after download is
+// treated as a failure and the partial file is removed.
+type manifestEntry struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+func main() {
+	addr := flag.String("addr", "", "origin TFTP server address (host:port)")
+	manifestLoc := flag.String("manifest", "", "path or URL to the JSON manifest of artifacts to fetch")
+	outDir := flag.String("out", ".", "directory to write fetched artifacts into")
+	mode := flag.String("mode", "octet", "transfer mode")
+	workers := flag.Int("workers", 4, "number of concurrent downloads")
+	timeout := flag.Duration("timeout", tftp.DefaultTimeout, "per-block timeout")
+	flag.Parse()
+
+	if *addr == "" || *manifestLoc == "" {
+		fmt.Fprintln(os.Stderr, "prefetch: -addr and -manifest are required")
+		os.Exit(2)
+	}
+
+	entries, err := loadManifest(*manifestLoc)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "prefetch: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := run(*addr, *outDir, *mode, *timeout, *workers, entries); err != nil {
+		fmt.Fprintf(os.Stderr, "prefetch: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// loadManifest reads a JSON array of manifestEntry from a local file
+// path or, if loc looks like an HTTP(S) URL, fetches it over the
+// network first.
+func loadManifest(loc string) ([]manifestEntry, error) {
+	var r io.ReadCloser
+	if strings.HasPrefix(loc, "http://") || strings.HasPrefix(loc, "https://") {
+		resp, err := http.Get(loc)
+		if err != nil {
+			return nil, fmt.Errorf("fetch manifest: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("fetch manifest: unexpected status %s", resp.Status)
+		}
+		r = resp.Body
+	} else {
+		f, err := os.Open(loc)
+		if err != nil {
+			return nil, fmt.Errorf("open manifest: %w", err)
+		}
+		r = f
+	}
+	defer r.Close()
+
+	var entries []manifestEntry
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+	return entries, nil
+}
+
+// run fans the manifest out across workers concurrent TFTP clients,
+// each connecting to the same origin server, and reports every failure
+// rather than stopping at the first one so a bad artifact doesn't block
+// the rest of the warm-up.
+func run(addr, outDir, mode string, timeout time.Duration, workers int, entries []manifestEntry) error {
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan manifestEntry)
+	errs := make(chan error, len(entries))
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := tftp.NewClient(addr)
+			c.Timeout = timeout
+			for entry := range jobs {
+				if err := fetchOne(c, outDir, mode, entry); err != nil {
+					errs <- fmt.Errorf("%s: %w", entry.Path, err)
+				}
+			}
+		}()
+	}
+
+	for _, e := range entries {
+		jobs <- e
+	}
+	close(jobs)
+	wg.Wait()
+	close(errs)
+
+	failures := 0
+	for err := range errs {
+		failures++
+		fmt.Fprintln(os.Stderr, "prefetch:", err)
+	}
+	if failures > 0 {
+		return fmt.Errorf("%d of %d artifacts failed", failures, len(entries))
+	}
+	return nil
+}
+
+// fetchOne downloads a single manifest entry, preserving its path
+// structure under outDir, and verifies its checksum when one was given.
+func fetchOne(c *tftp.Client, outDir, mode string, entry manifestEntry) error {
+	dest := filepath.Join(outDir, filepath.FromSlash(entry.Path))
+	rel, err := filepath.Rel(outDir, dest)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("manifest path escapes output directory")
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+
+	if err := c.GetFile(context.Background(), entry.Path, mode, dest); err != nil {
+		return err
+	}
+
+	if entry.SHA256 != "" {
+		if err := verifyHash(dest, entry.SHA256); err != nil {
+			os.Remove(dest)
+			return err
+		}
+	}
+	return nil
+}
+
+func verifyHash(path, want string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	got := hex.EncodeToString(h.Sum(nil))
+	if !strings.EqualFold(got, want) {
+		return fmt.Errorf("sha256 mismatch: got %s, want %s", got, want)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tftp "github.com/cjvirtucio87/tftp-go"
+)
+
+func TestLoadManifestFromFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "manifest.json")
+	if err := os.WriteFile(path, []byte(`[{"path":"a/b.bin","sha256":"deadbeef"}]`), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	entries, err := loadManifest(path)
+	if err != nil {
+		t.Fatalf("loadManifest: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Path != "a/b.bin" || entries[0].SHA256 != "deadbeef" {
+		t.Fatalf("loadManifest = %+v, want one entry for a/b.bin", entries)
+	}
+}
+
+func TestFetchOneRejectsPathEscape(t *testing.T) {
+	dir := t.TempDir()
+	c := tftp.NewClient("127.0.0.1:0")
+	err := fetchOne(c, dir, "octet", manifestEntry{Path: "../escape.bin"})
+	if err == nil {
+		t.Fatal("expected fetchOne to reject a manifest path that escapes -out")
+	}
+}
+
+func TestVerifyHashMismatch(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "f.bin")
+	if err := os.WriteFile(path, []byte("hello"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := verifyHash(path, "0000000000000000000000000000000000000000000000000000000000000000"); err == nil {
+		t.Fatal("expected verifyHash to fail for a mismatched checksum")
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+
+	tftp "github.com/cjvirtucio87/tftp-go"
+)
+
+// fileConfig is the subset of this binary's configuration that can be
+// changed while it's running, loaded from -config and re-read on
+// SIGHUP. Everything else (addr, root, writable, ...) only takes effect
+// at startup, since applying it later would mean rebinding the socket
+// or replacing the backend out from under in-flight transfers.
+type fileConfig struct {
+	LogLevel        string             `json:"logLevel"`
+	LogFormat       string             `json:"logFormat"`
+	RateLimit       int64              `json:"rateLimit"`
+	GlobalRateLimit int64              `json:"globalRateLimit"`
+	FilenameRules   []fileFilenameRule `json:"filenameRules"`
+	AllowCIDRs      []string           `json:"allowCIDRs"`
+	DenyCIDRs       []string           `json:"denyCIDRs"`
+}
+
+// fileFilenameRule is a tftp.FilenameRule in a form that survives a
+// round trip through JSON; exactly one of Exact, Prefix, or Regexp
+// should be set per rule, matching tftp.FilenameRule itself.
+type fileFilenameRule struct {
+	Exact       string `json:"exact"`
+	Prefix      string `json:"prefix"`
+	Regexp      string `json:"regexp"`
+	Replacement string `json:"replacement"`
+}
+
+// loadFileConfig reads and parses the JSON config file at path.
+func loadFileConfig(path string) (fileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fileConfig{}, err
+	}
+	var fc fileConfig
+	if err := json.Unmarshal(data, &fc); err != nil {
+		return fileConfig{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return fc, nil
+}
+
+// filenameRules compiles fc's FilenameRules into tftp.FilenameRules,
+// ready to assign to Server.FilenameRules.
+func (fc fileConfig) filenameRules() ([]tftp.FilenameRule, error) {
+	rules := make([]tftp.FilenameRule, 0, len(fc.FilenameRules))
+	for _, r := range fc.FilenameRules {
+		rule := tftp.FilenameRule{Exact: r.Exact, Prefix: r.Prefix, Replacement: r.Replacement}
+		if r.Regexp != "" {
+			re, err := regexp.Compile(r.Regexp)
+			if err != nil {
+				return nil, fmt.Errorf("filenameRules: %q: %w", r.Regexp, err)
+			}
+			rule.Regexp = re
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// aclValidator builds a tftp.Validator enforcing fc's AllowCIDRs and
+// DenyCIDRs, or reports ok=false if neither is set, so the caller can
+// leave Server.Validators untouched in the overwhelmingly common case
+// where no ACL is configured. A client matching DenyCIDRs is always
+// rejected; if AllowCIDRs is non-empty, a client matching none of them
+// is rejected too. Deny is checked first, so a network present in both
+// lists is denied.
+func (fc fileConfig) aclValidator() (tftp.Validator, bool, error) {
+	if len(fc.AllowCIDRs) == 0 && len(fc.DenyCIDRs) == 0 {
+		return nil, false, nil
+	}
+	allow, err := parseCIDRs(fc.AllowCIDRs)
+	if err != nil {
+		return nil, false, fmt.Errorf("allowCIDRs: %w", err)
+	}
+	deny, err := parseCIDRs(fc.DenyCIDRs)
+	if err != nil {
+		return nil, false, fmt.Errorf("denyCIDRs: %w", err)
+	}
+	return func(s *tftp.Session) *tftp.Rejection {
+		host, _, err := net.SplitHostPort(s.Addr.String())
+		if err != nil {
+			host = s.Addr.String()
+		}
+		ip := net.ParseIP(host)
+		if ip == nil || containsAny(deny, ip) {
+			return &tftp.Rejection{Code: tftp.ErrCodeAccessViolation, Message: "access denied"}
+		}
+		if len(allow) > 0 && !containsAny(allow, ip) {
+			return &tftp.Rejection{Code: tftp.ErrCodeAccessViolation, Message: "access denied"}
+		}
+		return nil
+	}, true, nil
+}
+
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", c, err)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+func containsAny(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyFileConfig loads path and applies its reloadable settings onto
+// the already-running s: the log level/format (a fresh logger replaces
+// the old one outright), RateLimit, FilenameRules, and an ACL validator
+// built from AllowCIDRs/DenyCIDRs (replacing whatever tftp.Validator
+// this function previously installed — this binary doesn't add any
+// Validators of its own besides this one). s is already serving
+// requests on a goroutine per session by the time this runs (it's
+// called from the SIGHUP handler), so every field it touches is set
+// through Server's Set* methods rather than assigned directly: a plain
+// assignment would race a session goroutine reading the same field via
+// Server's internal accessors.
+//
+// GlobalRateLimit is applied via SetGlobalRateLimit for completeness,
+// but a caveat applies: Server lazily builds its global limiter from
+// that value the first time any transfer needs it, and never rebuilds
+// it afterward, so a reload's new GlobalRateLimit only takes effect if
+// the server hasn't served its first request yet. RateLimit has no such
+// caveat: it's read fresh for every new transfer.
+func applyFileConfig(s *tftp.Server, path string) error {
+	fc, err := loadFileConfig(path)
+	if err != nil {
+		return err
+	}
+
+	logger, err := newLogger(fc.LogLevel, fc.LogFormat)
+	if err != nil {
+		return err
+	}
+
+	rules, err := fc.filenameRules()
+	if err != nil {
+		return err
+	}
+
+	acl, hasACL, err := fc.aclValidator()
+	if err != nil {
+		return err
+	}
+
+	s.SetLogger(logger)
+	s.SetRateLimit(fc.RateLimit)
+	s.SetGlobalRateLimit(fc.GlobalRateLimit)
+	s.SetFilenameRules(rules)
+	if hasACL {
+		s.SetValidators([]tftp.Validator{acl})
+	} else {
+		s.SetValidators(nil)
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	tftp "github.com/cjvirtucio87/tftp-go"
+)
+
+func writeConfig(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
+
+func TestLoadFileConfigParsesEveryField(t *testing.T) {
+	path := writeConfig(t, `{
+		"logLevel": "debug",
+		"logFormat": "json",
+		"rateLimit": 1000,
+		"globalRateLimit": 5000,
+		"filenameRules": [{"prefix": "/old/", "replacement": "/new/"}],
+		"allowCIDRs": ["10.0.0.0/8"],
+		"denyCIDRs": ["10.0.0.1/32"]
+	}`)
+
+	fc, err := loadFileConfig(path)
+	if err != nil {
+		t.Fatalf("loadFileConfig: %v", err)
+	}
+	if fc.LogLevel != "debug" || fc.LogFormat != "json" {
+		t.Errorf("LogLevel/LogFormat = %q/%q, want debug/json", fc.LogLevel, fc.LogFormat)
+	}
+	if fc.RateLimit != 1000 || fc.GlobalRateLimit != 5000 {
+		t.Errorf("RateLimit/GlobalRateLimit = %d/%d, want 1000/5000", fc.RateLimit, fc.GlobalRateLimit)
+	}
+	if len(fc.FilenameRules) != 1 || fc.FilenameRules[0].Prefix != "/old/" {
+		t.Errorf("FilenameRules = %+v, want one prefix rule", fc.FilenameRules)
+	}
+	if len(fc.AllowCIDRs) != 1 || len(fc.DenyCIDRs) != 1 {
+		t.Errorf("AllowCIDRs/DenyCIDRs = %v/%v, want one each", fc.AllowCIDRs, fc.DenyCIDRs)
+	}
+}
+
+func TestLoadFileConfigRejectsMissingFile(t *testing.T) {
+	if _, err := loadFileConfig(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatal("loadFileConfig: want an error for a missing file")
+	}
+}
+
+func TestFilenameRulesCompilesRegexp(t *testing.T) {
+	fc := fileConfig{FilenameRules: []fileFilenameRule{{Regexp: `^boot-(\d+)\.img$`, Replacement: "images/boot-$1.img"}}}
+	rules, err := fc.filenameRules()
+	if err != nil {
+		t.Fatalf("filenameRules: %v", err)
+	}
+	if len(rules) != 1 || rules[0].Regexp == nil {
+		t.Fatalf("filenameRules = %+v, want one compiled regexp rule", rules)
+	}
+}
+
+func TestFilenameRulesRejectsBadRegexp(t *testing.T) {
+	fc := fileConfig{FilenameRules: []fileFilenameRule{{Regexp: `(`}}}
+	if _, err := fc.filenameRules(); err == nil {
+		t.Fatal("filenameRules: want an error for invalid regexp")
+	}
+}
+
+func TestACLValidatorAbsentWithoutCIDRs(t *testing.T) {
+	fc := fileConfig{}
+	v, ok, err := fc.aclValidator()
+	if err != nil || ok || v != nil {
+		t.Fatalf("aclValidator = %v, %v, %v, want nil, false, nil", v, ok, err)
+	}
+}
+
+func TestACLValidatorEnforcesAllowAndDeny(t *testing.T) {
+	fc := fileConfig{AllowCIDRs: []string{"10.0.0.0/8"}, DenyCIDRs: []string{"10.0.0.1/32"}}
+	v, ok, err := fc.aclValidator()
+	if err != nil || !ok {
+		t.Fatalf("aclValidator: %v, ok=%v", err, ok)
+	}
+
+	allowed := &tftp.Session{Addr: &net.UDPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 69}}
+	if rej := v(allowed); rej != nil {
+		t.Errorf("v(%s) = %+v, want allowed", allowed.Addr, rej)
+	}
+
+	denied := &tftp.Session{Addr: &net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 69}}
+	if rej := v(denied); rej == nil {
+		t.Error("v(10.0.0.1): want a Rejection, got nil")
+	}
+
+	outside := &tftp.Session{Addr: &net.UDPAddr{IP: net.IPv4(192, 168, 1, 1), Port: 69}}
+	if rej := v(outside); rej == nil {
+		t.Error("v(192.168.1.1): want a Rejection for an address outside AllowCIDRs, got nil")
+	}
+}
+
+func TestApplyFileConfigUpdatesServer(t *testing.T) {
+	path := writeConfig(t, `{
+		"logLevel": "warn",
+		"logFormat": "console",
+		"rateLimit": 4096,
+		"filenameRules": [{"exact": "a", "replacement": "b"}],
+		"denyCIDRs": ["10.0.0.1/32"]
+	}`)
+
+	s := tftp.NewServer("", &tftp.FileHandler{Dir: t.TempDir()})
+	if err := applyFileConfig(s, path); err != nil {
+		t.Fatalf("applyFileConfig: %v", err)
+	}
+	if s.RateLimit != 4096 {
+		t.Errorf("RateLimit = %d, want 4096", s.RateLimit)
+	}
+	if len(s.FilenameRules) != 1 || s.FilenameRules[0].Exact != "a" {
+		t.Errorf("FilenameRules = %+v, want one exact rule", s.FilenameRules)
+	}
+	if len(s.Validators) != 1 {
+		t.Errorf("Validators = %v, want one ACL validator", s.Validators)
+	}
+}
+
+func TestApplyFileConfigRejectsBadFile(t *testing.T) {
+	s := tftp.NewServer("", &tftp.FileHandler{Dir: t.TempDir()})
+	if err := applyFileConfig(s, filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatal("applyFileConfig: want an error for a missing file")
+	}
+}
+
+// TestApplyFileConfigConcurrentWithTrafficDoesNotRace reloads
+// repeatedly via applyFileConfig (the SIGHUP path) while traffic is
+// in flight, the same way cmd/server's signal handler can run
+// concurrently with session goroutines. It exists to be run under
+// -race: applyFileConfig used to assign Logger/RateLimit/
+// GlobalRateLimit/FilenameRules/Validators directly, racing every
+// session goroutine's reads of those fields.
+func TestApplyFileConfigConcurrentWithTrafficDoesNotRace(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "boot.img"), []byte("kernel"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	path := writeConfig(t, `{"logLevel": "warn", "logFormat": "console", "rateLimit": 1000000}`)
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s := tftp.NewServer("", &tftp.FileHandler{Dir: dir})
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	stop := make(chan struct{})
+	var trafficWG, reloadWG sync.WaitGroup
+
+	trafficWG.Add(1)
+	go func() {
+		defer trafficWG.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			c := tftp.NewClient(conn.LocalAddr().String())
+			c.Timeout = time.Second
+			c.GetBytes("boot.img", "octet")
+		}
+	}()
+
+	reloadWG.Add(1)
+	go func() {
+		defer reloadWG.Done()
+		for i := 0; i < 50; i++ {
+			if err := applyFileConfig(s, path); err != nil {
+				t.Errorf("applyFileConfig: %v", err)
+				return
+			}
+		}
+	}()
+
+	reloadWG.Wait()
+	close(stop)
+	trafficWG.Wait()
+}
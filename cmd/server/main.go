@@ -0,0 +1,294 @@
+// Command server runs a standalone TFTP server backed by a directory on
+// disk, usable as a drop-in tftpd for serving a PXE/boot directory.
+// -root is read-only by default, matching what a provisioning server
+// actually needs: clients fetch boot images, but a misbehaving or
+// malicious one can't overwrite them; pass -writable to also accept
+// WRQ uploads into -root.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	tftp "github.com/cjvirtucio87/tftp-go"
+)
+
+// config is every flag validate and main itself need, gathered into one
+// value so validateConfig can check them without reaching back into
+// package-level flag.Value pointers.
+type config struct {
+	addr         string
+	root         string
+	writable     bool
+	maxBlockSize int
+	timeout      time.Duration
+	retries      int
+	logLevel     string
+	logFormat    string
+	faultDrop    float64
+	faultDup     float64
+	faultReorder float64
+	faultDelay   time.Duration
+	faultSeed    int64
+	shutdownWait time.Duration
+	configPath   string
+}
+
+// hiddenFlagPrefix marks a flag's usage text as internal-only: the
+// flag still parses normally, but usage() omits it, since -fault-*
+// exists to reproduce realistic network misbehavior in development and
+// CI, not for an operator to reach for on a production box.
+const hiddenFlagPrefix = "[internal] "
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage of %s:\n", os.Args[0])
+	flag.VisitAll(func(f *flag.Flag) {
+		if strings.HasPrefix(f.Usage, hiddenFlagPrefix) {
+			return
+		}
+		fmt.Fprintf(out, "  -%s\n    \t%s\n", f.Name, f.Usage)
+	})
+}
+
+func main() {
+	cfg := config{}
+	flag.StringVar(&cfg.addr, "addr", ":69", "address to listen on (host:port)")
+	flag.StringVar(&cfg.root, "root", "", "directory to serve files from (required)")
+	flag.BoolVar(&cfg.writable, "writable", false, "allow clients to upload (WRQ) into -root; read-only by default")
+	flag.IntVar(&cfg.maxBlockSize, "max-block-size", 0, "cap the blksize option (RFC 2348) a client may negotiate; 0 means the protocol maximum")
+	flag.DurationVar(&cfg.timeout, "timeout", tftp.DefaultTimeout, "per-block retransmission timeout before a retry")
+	flag.IntVar(&cfg.retries, "retries", tftp.DefaultRetries, "retransmission attempts before a transfer is abandoned")
+	flag.StringVar(&cfg.logLevel, "log-level", "warn", "minimum severity to log: debug, info, warn, or error")
+	flag.StringVar(&cfg.logFormat, "log-format", "console", "log output format: console or json")
+	flag.Float64Var(&cfg.faultDrop, "fault-drop", 0, hiddenFlagPrefix+"probability [0,1] an outgoing datagram is dropped")
+	flag.Float64Var(&cfg.faultDup, "fault-duplicate", 0, hiddenFlagPrefix+"probability [0,1] an outgoing datagram is also sent a second time")
+	flag.Float64Var(&cfg.faultReorder, "fault-reorder", 0, hiddenFlagPrefix+"probability [0,1] an outgoing datagram is delayed enough to arrive out of order")
+	flag.DurationVar(&cfg.faultDelay, "fault-delay", 0, hiddenFlagPrefix+"max random delay applied to each outgoing datagram")
+	flag.Int64Var(&cfg.faultSeed, "fault-seed", 0, hiddenFlagPrefix+"seed for -fault-* randomness; 0 picks a random seed each run")
+	flag.DurationVar(&cfg.shutdownWait, "shutdown-wait", 10*time.Second, "on SIGTERM/SIGINT, how long to let in-flight transfers finish before exiting")
+	flag.StringVar(&cfg.configPath, "config", "", "JSON file of reloadable settings (log level/format, rate limits, filename rules, IP allow/deny lists); re-read on SIGHUP")
+	validate := flag.Bool("validate", false, "check -root, -addr, and other flags for errors and exit without binding the port")
+	flag.Usage = usage
+	flag.Parse()
+
+	if errs := validateConfig(cfg); len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Fprintln(os.Stderr, "server: validate:", err)
+		}
+		os.Exit(2)
+	}
+	if *validate {
+		fmt.Fprintln(os.Stderr, "server: validate: config OK")
+		return
+	}
+
+	if cfg.maxBlockSize > 0 {
+		tftp.MaxBlockSize = cfg.maxBlockSize
+	}
+
+	s := tftp.NewServer(cfg.addr, newHandler(cfg.root, cfg.writable))
+	s.Timeout = cfg.timeout
+	s.Retries = cfg.retries
+	logger, err := newLogger(cfg.logLevel, cfg.logFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "server: %v\n", err)
+		os.Exit(2)
+	}
+	s.Logger = logger
+	if mw, ok := faultMiddleware(cfg); ok {
+		s.Middleware = append(s.Middleware, mw)
+		fmt.Fprintln(os.Stderr, "server: fault injection enabled; do not use this flag combination in production")
+	}
+
+	if cfg.configPath != "" {
+		if err := applyFileConfig(s, cfg.configPath); err != nil {
+			fmt.Fprintf(os.Stderr, "server: -config %q: %v\n", cfg.configPath, err)
+			os.Exit(2)
+		}
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT)
+	reloadCh := make(chan os.Signal, 1)
+	signal.Notify(reloadCh, syscall.SIGHUP)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.ListenAndServe()
+	}()
+
+	fmt.Fprintf(os.Stderr, "server: serving %s on %s (writable=%v)\n", cfg.root, cfg.addr, cfg.writable)
+
+	for {
+		select {
+		case err := <-serveErr:
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "server: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case sig := <-sigCh:
+			fmt.Fprintf(os.Stderr, "server: received %s, draining (up to %s for in-flight transfers)\n", sig, cfg.shutdownWait)
+			ctx, cancel := context.WithTimeout(context.Background(), cfg.shutdownWait)
+			defer cancel()
+			if err := s.Shutdown(ctx); err != nil {
+				fmt.Fprintf(os.Stderr, "server: shutdown: %v\n", err)
+				os.Exit(1)
+			}
+			<-serveErr
+			return
+		case <-reloadCh:
+			if cfg.configPath == "" {
+				fmt.Fprintln(os.Stderr, "server: received SIGHUP but no -config file was given; ignoring")
+				continue
+			}
+			if err := applyFileConfig(s, cfg.configPath); err != nil {
+				fmt.Fprintf(os.Stderr, "server: reload -config %q: %v\n", cfg.configPath, err)
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "server: reloaded configuration from %s\n", cfg.configPath)
+		}
+	}
+}
+
+// faultMiddleware builds the tftp.FaultInjector cfg's -fault-* flags
+// describe, and reports false if none of them were set, so main can
+// skip wrapping every session's conn in the overwhelmingly common case
+// where fault injection isn't in use.
+func faultMiddleware(cfg config) (tftp.PacketMiddleware, bool) {
+	if cfg.faultDrop == 0 && cfg.faultDup == 0 && cfg.faultReorder == 0 && cfg.faultDelay == 0 {
+		return nil, false
+	}
+	fc := tftp.FaultConfig{
+		DropProbability:      cfg.faultDrop,
+		DuplicateProbability: cfg.faultDup,
+		ReorderProbability:   cfg.faultReorder,
+		MaxDelay:             cfg.faultDelay,
+	}
+	if cfg.faultSeed != 0 {
+		fc.Rand = rand.New(rand.NewSource(cfg.faultSeed))
+	}
+	return tftp.FaultInjector(fc), true
+}
+
+// validateConfig checks cfg the same way main would use it, without
+// binding cfg.addr's port or touching cfg.root beyond a stat: a
+// misconfigured root or an address nothing on this host can resolve are
+// exactly the mistakes -validate exists to catch before a deploy. ACL
+// validation is a no-op for now, since this binary doesn't yet expose a
+// flag for configuring one; it has a place to slot in here once it does.
+func validateConfig(cfg config) []error {
+	var errs []error
+	if cfg.root == "" {
+		errs = append(errs, errors.New("-root is required"))
+	} else if info, err := os.Stat(cfg.root); err != nil {
+		errs = append(errs, fmt.Errorf("-root %q: %w", cfg.root, err))
+	} else if !info.IsDir() {
+		errs = append(errs, fmt.Errorf("-root %q: not a directory", cfg.root))
+	}
+	if _, err := net.ResolveUDPAddr("udp", cfg.addr); err != nil {
+		errs = append(errs, fmt.Errorf("-addr %q: %w", cfg.addr, err))
+	}
+	if cfg.maxBlockSize < 0 {
+		errs = append(errs, fmt.Errorf("-max-block-size %d: must not be negative", cfg.maxBlockSize))
+	}
+	if cfg.timeout <= 0 {
+		errs = append(errs, fmt.Errorf("-timeout %s: must be positive", cfg.timeout))
+	}
+	if cfg.retries < 0 {
+		errs = append(errs, fmt.Errorf("-retries %d: must not be negative", cfg.retries))
+	}
+	if cfg.shutdownWait < 0 {
+		errs = append(errs, fmt.Errorf("-shutdown-wait %s: must not be negative", cfg.shutdownWait))
+	}
+	if cfg.configPath != "" {
+		if _, err := os.Stat(cfg.configPath); err != nil {
+			errs = append(errs, fmt.Errorf("-config %q: %w", cfg.configPath, err))
+		}
+	}
+	switch cfg.logLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		errs = append(errs, fmt.Errorf("-log-level %q: must be debug, info, warn, or error", cfg.logLevel))
+	}
+	switch cfg.logFormat {
+	case "console", "json":
+	default:
+		errs = append(errs, fmt.Errorf("-log-format %q: must be console or json", cfg.logFormat))
+	}
+	return errs
+}
+
+// logHandler builds the slog.Handler -log-level/-log-format describe,
+// writing to w, returning an error for a level or format this binary
+// doesn't recognize. It's split out from newLogger so validateConfig
+// can check -log-level/-log-format are well-formed without needing a
+// *tftp.SlogLogger of its own.
+func logHandler(level, format string, w *os.File) (slog.Handler, slog.Level, error) {
+	var lvl slog.Level
+	switch level {
+	case "debug":
+		lvl = slog.LevelDebug
+	case "info":
+		lvl = slog.LevelInfo
+	case "warn":
+		lvl = slog.LevelWarn
+	case "error":
+		lvl = slog.LevelError
+	default:
+		return nil, 0, fmt.Errorf("-log-level %q: must be debug, info, warn, or error", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch format {
+	case "console":
+		return slog.NewTextHandler(w, opts), lvl, nil
+	case "json":
+		return slog.NewJSONHandler(w, opts), lvl, nil
+	default:
+		return nil, 0, fmt.Errorf("-log-format %q: must be console or json", format)
+	}
+}
+
+// newLogger builds a tftp.Logger from -log-level/-log-format, writing to
+// stderr. Setting level to "debug" also turns on this package's
+// per-packet trace (see tftp.DebugLogger), since SlogLogger implements
+// that capability too — one knob instead of a separate -debug flag.
+func newLogger(level, format string) (*tftp.SlogLogger, error) {
+	handler, _, err := logHandler(level, format, os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	return tftp.NewSlogLogger(slog.New(handler)), nil
+}
+
+// newHandler wraps a FileHandler rooted at dir, rejecting WRQ uploads
+// unless writable is set.
+func newHandler(dir string, writable bool) tftp.Handler {
+	h := &tftp.FileHandler{Dir: dir}
+	if writable {
+		return h
+	}
+	return readOnlyHandler{h}
+}
+
+// readOnlyHandler answers every WriteFile with an error instead of
+// reaching the underlying FileHandler, keeping -root's default
+// read-only even though FileHandler itself is always read/write.
+type readOnlyHandler struct {
+	*tftp.FileHandler
+}
+
+func (readOnlyHandler) WriteFile(filename string, data []byte) error {
+	return errors.New("server is read-only; pass -writable to allow uploads")
+}
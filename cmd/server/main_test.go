@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewHandlerReadOnlyByDefaultRejectsWrites(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "boot.img"), []byte("kernel"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	h := newHandler(dir, false)
+	data, err := h.ReadFile("boot.img")
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(data) != "kernel" {
+		t.Errorf("ReadFile = %q, want %q", data, "kernel")
+	}
+
+	if err := h.WriteFile("new.img", []byte("x")); err == nil {
+		t.Fatal("WriteFile: want an error on a read-only handler")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "new.img")); !os.IsNotExist(err) {
+		t.Fatal("WriteFile: file was written despite being rejected")
+	}
+}
+
+func TestNewHandlerWritableAllowsWrites(t *testing.T) {
+	dir := t.TempDir()
+	h := newHandler(dir, true)
+
+	if err := h.WriteFile("new.img", []byte("x")); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "new.img"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(got) != "x" {
+		t.Errorf("wrote %q, want %q", got, "x")
+	}
+}
+
+func validConfig(t *testing.T) config {
+	t.Helper()
+	return config{
+		addr:      "127.0.0.1:0",
+		root:      t.TempDir(),
+		timeout:   time.Second,
+		retries:   5,
+		logLevel:  "warn",
+		logFormat: "console",
+	}
+}
+
+func TestValidateConfigAcceptsAValidConfig(t *testing.T) {
+	if errs := validateConfig(validConfig(t)); len(errs) != 0 {
+		t.Fatalf("validateConfig = %v, want no errors", errs)
+	}
+}
+
+func TestValidateConfigRejectsMissingRoot(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.root = ""
+	if errs := validateConfig(cfg); len(errs) == 0 {
+		t.Fatal("validateConfig: want an error for a missing -root")
+	}
+}
+
+func TestValidateConfigRejectsRootThatIsAFile(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.root = filepath.Join(cfg.root, "not-a-dir")
+	if err := os.WriteFile(cfg.root, []byte("x"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if errs := validateConfig(cfg); len(errs) == 0 {
+		t.Fatal("validateConfig: want an error when -root is a file, not a directory")
+	}
+}
+
+func TestValidateConfigRejectsNonexistentRoot(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.root = filepath.Join(cfg.root, "does-not-exist")
+	if errs := validateConfig(cfg); len(errs) == 0 {
+		t.Fatal("validateConfig: want an error for a nonexistent -root")
+	}
+}
+
+func TestValidateConfigRejectsUnresolvableAddr(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.addr = "not a valid address"
+	if errs := validateConfig(cfg); len(errs) == 0 {
+		t.Fatal("validateConfig: want an error for an unresolvable -addr")
+	}
+}
+
+func TestValidateConfigRejectsBadTimeoutAndRetries(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.timeout = 0
+	cfg.retries = -1
+	errs := validateConfig(cfg)
+	if len(errs) != 2 {
+		t.Fatalf("validateConfig = %v, want 2 errors (timeout and retries)", errs)
+	}
+}
+
+func TestValidateConfigRejectsMissingConfigFile(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.configPath = filepath.Join(t.TempDir(), "nope.json")
+	if errs := validateConfig(cfg); len(errs) != 1 {
+		t.Fatalf("validateConfig = %v, want 1 error (missing -config file)", errs)
+	}
+}
+
+func TestValidateConfigAcceptsExistingConfigFile(t *testing.T) {
+	cfg := validConfig(t)
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{}`), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	cfg.configPath = path
+	if errs := validateConfig(cfg); len(errs) != 0 {
+		t.Fatalf("validateConfig = %v, want no errors", errs)
+	}
+}
+
+func TestValidateConfigRejectsNegativeShutdownWait(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.shutdownWait = -time.Second
+	if errs := validateConfig(cfg); len(errs) != 1 {
+		t.Fatalf("validateConfig = %v, want 1 error (shutdown-wait)", errs)
+	}
+}
+
+func TestValidateConfigRejectsBadLogLevelAndFormat(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.logLevel = "verbose"
+	cfg.logFormat = "xml"
+	errs := validateConfig(cfg)
+	if len(errs) != 2 {
+		t.Fatalf("validateConfig = %v, want 2 errors (log level and format)", errs)
+	}
+}
+
+func TestNewLoggerRejectsUnknownLevel(t *testing.T) {
+	if _, err := newLogger("trace", "console"); err == nil {
+		t.Fatal("newLogger: want an error for an unrecognized -log-level")
+	}
+}
+
+func TestNewLoggerRejectsUnknownFormat(t *testing.T) {
+	if _, err := newLogger("warn", "yaml"); err == nil {
+		t.Fatal("newLogger: want an error for an unrecognized -log-format")
+	}
+}
+
+func TestNewLoggerBuildsAUsableLogger(t *testing.T) {
+	for _, format := range []string{"console", "json"} {
+		logger, err := newLogger("debug", format)
+		if err != nil {
+			t.Fatalf("newLogger(%q): %v", format, err)
+		}
+		logger.Printf("hello %s", "world")
+		logger.Debugf("debug %s", "detail")
+	}
+}
+
+func TestFaultMiddlewareAbsentByDefault(t *testing.T) {
+	if _, ok := faultMiddleware(validConfig(t)); ok {
+		t.Fatal("faultMiddleware: want no middleware when no -fault-* flag is set")
+	}
+}
+
+func TestFaultMiddlewarePresentWhenAFaultFlagIsSet(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.faultDrop = 0.5
+	if _, ok := faultMiddleware(cfg); !ok {
+		t.Fatal("faultMiddleware: want middleware once a -fault-* flag is set")
+	}
+}
+
+func TestUsageHidesFaultFlags(t *testing.T) {
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	var buf bytes.Buffer
+	fs.SetOutput(&buf)
+	fs.String("root", "", "directory to serve files from (required)")
+	fs.Float64("fault-drop", 0, hiddenFlagPrefix+"probability an outgoing datagram is dropped")
+
+	old := flag.CommandLine
+	flag.CommandLine = fs
+	defer func() { flag.CommandLine = old }()
+	usage()
+
+	out := buf.String()
+	if !strings.Contains(out, "-root") {
+		t.Errorf("usage() output %q: want -root listed", out)
+	}
+	if strings.Contains(out, "-fault-drop") {
+		t.Errorf("usage() output %q: want -fault-drop hidden", out)
+	}
+}
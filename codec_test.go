@@ -0,0 +1,58 @@
+package tftp
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDATAAppendBinaryReusesBuffer(t *testing.T) {
+	dst := make([]byte, 0, 64)
+	p := &DATA{Block: 3, Payload: []byte("abc")}
+
+	out, err := p.AppendBinary(dst)
+	if err != nil {
+		t.Fatalf("AppendBinary: %v", err)
+	}
+	if &out[0] != &dst[:cap(dst)][0] {
+		t.Fatal("AppendBinary should have grown dst in place, not allocated a new backing array")
+	}
+
+	var got DATA
+	if err := got.UnmarshalBinary(out); err != nil {
+		t.Fatalf("UnmarshalBinary: %v", err)
+	}
+	if got.Block != p.Block || !bytes.Equal(got.Payload, p.Payload) {
+		t.Fatalf("round trip mismatch: got %+v, want %+v", got, p)
+	}
+}
+
+func TestDATADecodeAliasesInput(t *testing.T) {
+	wire, _ := (&DATA{Block: 5, Payload: []byte("hello")}).MarshalBinary()
+
+	var p DATA
+	if err := p.Decode(wire); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if &p.Payload[0] != &wire[4] {
+		t.Fatal("Decode should alias Payload into the input slice")
+	}
+
+	var copied DATA
+	if err := copied.UnmarshalBinary(wire); err != nil {
+		t.Fatalf("UnmarshalBinary: %v", err)
+	}
+	wire[4] = 'X'
+	if copied.Payload[0] == 'X' {
+		t.Fatal("UnmarshalBinary must copy Payload, not alias it")
+	}
+}
+
+func TestOpcodeOf(t *testing.T) {
+	wire, _ := (&ACK{Block: 1}).MarshalBinary()
+	if opcodeOf(wire) != OpACK {
+		t.Fatalf("opcodeOf() = %v, want OpACK", opcodeOf(wire))
+	}
+	if opcodeOf(nil) != 0 {
+		t.Fatal("opcodeOf(nil) should be 0")
+	}
+}
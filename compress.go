@@ -0,0 +1,73 @@
+package tftp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+)
+
+// gzipCompress returns data compressed with gzip's default level, the
+// form OptCompress transmits over the wire.
+func gzipCompress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// gzipDecompress reverses gzipCompress, for a WRQ upload that
+// negotiated OptCompress.
+func gzipDecompress(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+// gzipDecodingWriter decompresses a gzip byte stream as it arrives,
+// writing the decompressed bytes to the wrapped Writer. gzip.Reader only
+// consumes from an io.Reader, so writes are relayed to it through a
+// pipe, with a goroutine pumping the decompressed side out to w; this
+// lets a Get's streaming receive loop feed it DATA payloads block by
+// block instead of needing the whole compressed transfer buffered
+// first.
+type gzipDecodingWriter struct {
+	pw   *io.PipeWriter
+	done chan error
+}
+
+func newGzipDecodingWriter(w io.Writer) *gzipDecodingWriter {
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		zr, err := gzip.NewReader(pr)
+		if err != nil {
+			pr.CloseWithError(err)
+			done <- err
+			return
+		}
+		_, err = io.Copy(w, zr)
+		pr.CloseWithError(err)
+		done <- err
+	}()
+	return &gzipDecodingWriter{pw: pw, done: done}
+}
+
+func (g *gzipDecodingWriter) Write(p []byte) (int, error) {
+	return g.pw.Write(p)
+}
+
+// Flush signals that no more compressed bytes are coming and waits for
+// the decompression goroutine to drain whatever it has buffered into w,
+// returning any error gzip.Reader or w surfaced along the way.
+func (g *gzipDecodingWriter) Flush() error {
+	g.pw.Close()
+	return <-g.done
+}
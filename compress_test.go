@@ -0,0 +1,100 @@
+package tftp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestClientGetUsesCompressionWhenNegotiated(t *testing.T) {
+	// Highly repetitive content compresses well, so the wire transfer
+	// should end up smaller than the original.
+	want := bytes.Repeat([]byte("compress-me "), 2000)
+	addr, _ := startTestServer(t, map[string][]byte{"config.txt": want})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+	c.Compress = true
+
+	rc, err := c.Get(context.Background(), "config.txt", "octet")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	defer rc.Close()
+
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("Get returned %d bytes, want %d", len(got), len(want))
+	}
+	if stats := rc.Stats(); stats.Bytes >= int64(len(want)) {
+		t.Errorf("wire Bytes = %d, want less than the uncompressed %d bytes", stats.Bytes, len(want))
+	}
+}
+
+func TestServerIgnoresCompressionForPayloadProvider(t *testing.T) {
+	want := bytes.Repeat([]byte("p"), DefaultBlockSize*2)
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s := NewServer("", nil)
+	s.PayloadProvider = func(ctx context.Context, filename string, clientAddr net.Addr) (io.ReadCloser, int64, error) {
+		return io.NopCloser(bytes.NewReader(want)), int64(len(want)), nil
+	}
+	s.conn = conn
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	c.Compress = true
+
+	got, err := c.GetBytes("anything", "octet")
+	if err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("GetBytes returned %d bytes that don't match the uncompressed source", len(got))
+	}
+}
+
+func TestClientPutUsesCompressionWhenNegotiated(t *testing.T) {
+	addr, h := startTestServer(t, map[string][]byte{})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+	c.Compress = true
+
+	data := bytes.Repeat([]byte("upload-me "), 2000)
+	stats, err := c.Put(context.Background(), "up.txt", "octet", bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if stats.Bytes >= int64(len(data)) {
+		t.Errorf("wire Bytes = %d, want less than the uncompressed %d bytes", stats.Bytes, len(data))
+	}
+
+	// The server ACKs the final DATA block (unblocking Put) just before
+	// it calls Handler.WriteFile, so give it a brief moment to land.
+	var got []byte
+	var ok bool
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if got, ok = h.get("up.txt"); ok {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !ok {
+		t.Fatal("server never recorded the uploaded file")
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("stored %d bytes, want %d matching the original upload", len(got), len(data))
+	}
+}
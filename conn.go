@@ -0,0 +1,92 @@
+package tftp
+
+import (
+	"errors"
+	"net"
+	"time"
+)
+
+// errDatagramTooLarge is returned when a buffer sized to hold the
+// largest packet a session should ever see (maxPacketSize, plus one
+// byte of slack) fills completely. UDP silently discards whatever of an
+// oversized datagram doesn't fit the receive buffer, so filling that
+// extra byte of slack is the only signal that truncation happened; it's
+// treated as a hard rejection rather than quietly parsing short data.
+var errDatagramTooLarge = errors.New("tftp: datagram exceeds negotiated packet size")
+
+// maxPacketSize returns the largest datagram a session negotiated at
+// blockSize should ever need to receive: a full DATA packet. ACKs and
+// OACKs are always smaller, but sizing every receive buffer to this
+// keeps blksize negotiation (RFC 2348) from being able to silently
+// truncate one.
+func maxPacketSize(blockSize int) int {
+	return blockSize + 4
+}
+
+// packetConn is the minimal surface client and server code needs from a
+// UDP socket. It is satisfied by *net.UDPConn and lets tests substitute
+// an in-memory transport. It's also the surface left once a
+// PacketMiddleware chain has wrapped the real socket; net.PacketConn
+// implements it directly, so no adapter is needed at that boundary.
+type packetConn interface {
+	ReadFrom(b []byte) (n int, addr net.Addr, err error)
+	WriteTo(b []byte, addr net.Addr) (n int, err error)
+	SetReadDeadline(t time.Time) error
+	Close() error
+	LocalAddr() net.Addr
+}
+
+// PacketMiddleware wraps a net.PacketConn to add a cross-cutting
+// concern — metrics, an independent capture, extra throttling — around
+// every read and write a Client or Server session makes, without
+// touching the transfer logic itself. A middleware's returned
+// net.PacketConn should delegate to the one it was given, since it
+// replaces that connection everywhere for the rest of the session.
+//
+// Applying any PacketMiddleware opts a session's per-transfer socket out
+// of the platform-specific send/receive batching (Linux UDP GSO and
+// recvmmsg): those paths need the real *net.UDPConn to reach the
+// underlying file descriptor, which a wrapped net.PacketConn no longer
+// exposes. Sessions fall back to one syscall per packet instead.
+type PacketMiddleware func(net.PacketConn) net.PacketConn
+
+// applyMiddleware folds mw over conn in order, each middleware wrapping
+// the previous result, so the last middleware in the slice ends up the
+// outermost layer: it sees a write first and a read last.
+func applyMiddleware(conn net.PacketConn, mw []PacketMiddleware) net.PacketConn {
+	for _, m := range mw {
+		conn = m(conn)
+	}
+	return conn
+}
+
+func readPacket(conn packetConn, buf []byte) (Packet, net.Addr, error) {
+	n, addr, err := conn.ReadFrom(buf)
+	if err != nil {
+		return nil, addr, err
+	}
+	p, err := ParsePacket(buf[:n])
+	if err != nil {
+		return nil, addr, err
+	}
+	return p, addr, nil
+}
+
+func writePacket(conn packetConn, p Packet, addr net.Addr, capture *PacketCapture, debug DebugLogger) error {
+	b, err := p.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	if _, err := conn.WriteTo(b, addr); err != nil {
+		return err
+	}
+	if capture != nil {
+		if local, ok := conn.LocalAddr().(*net.UDPAddr); ok {
+			if remote, ok := addr.(*net.UDPAddr); ok {
+				capture.sent(local, remote, b)
+			}
+		}
+	}
+	debugPacket(debug, "sent", addr, b)
+	return nil
+}
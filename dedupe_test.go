@@ -0,0 +1,92 @@
+package tftp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDispatchDropsDuplicateRRQForActiveSession(t *testing.T) {
+	var calls int32
+	block := make(chan struct{})
+
+	s := &Server{Timeout: time.Second, Retries: DefaultRetries}
+	s.PayloadProvider = func(ctx context.Context, filename string, clientAddr net.Addr) (io.ReadCloser, int64, error) {
+		atomic.AddInt32(&calls, 1)
+		<-block
+		return io.NopCloser(bytes.NewReader([]byte("data"))), 4, nil
+	}
+
+	serverConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	go s.Serve(serverConn)
+	defer s.Close()
+
+	clientConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer clientConn.Close()
+
+	wire, err := (&RRQ{Filename: "file.bin", Mode: "octet"}).MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary: %v", err)
+	}
+	serverAddr := serverConn.LocalAddr().(*net.UDPAddr)
+	if _, err := clientConn.WriteToUDP(wire, serverAddr); err != nil {
+		t.Fatalf("WriteToUDP: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&calls) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("PayloadProvider calls = %d after first RRQ, want 1", calls)
+	}
+
+	if _, err := clientConn.WriteToUDP(wire, serverAddr); err != nil {
+		t.Fatalf("WriteToUDP (duplicate): %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("PayloadProvider calls = %d after duplicate RRQ, want still 1", got)
+	}
+
+	close(block)
+}
+
+func TestHasActiveSessionFalseWithNoSessions(t *testing.T) {
+	s := &Server{}
+	addr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 12345}
+	if s.hasActiveSession(addr) {
+		t.Fatal("hasActiveSession: want false when no sessions are registered")
+	}
+}
+
+func TestHasActiveSessionTrueForRegisteredAddr(t *testing.T) {
+	s := &Server{}
+	addr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 12345}
+	sess := Session{Addr: addr, Filename: "file.bin", Op: OpRRQ}
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer conn.Close()
+	as := s.registerSession(conn, sess)
+	defer s.unregisterSession(as.id)
+
+	if !s.hasActiveSession(addr) {
+		t.Fatal("hasActiveSession: want true for a registered addr")
+	}
+	other := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 54321}
+	if s.hasActiveSession(other) {
+		t.Fatal("hasActiveSession: want false for an unrelated addr")
+	}
+}
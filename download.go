@@ -0,0 +1,201 @@
+package tftp
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// GetFile downloads filename from the server and writes it to destPath.
+// The transfer is streamed into a temporary file next to destPath and
+// renamed into place only on success; on any failure the temporary file
+// is removed, so an interrupted download never leaves a truncated file
+// for downstream tooling to pick up.
+//
+// When the server reports tsize, the temporary file is preallocated to
+// that size as a hint to the filesystem, and the receive path itself
+// fails the transfer if the number of bytes actually received doesn't
+// match it — catching a connection that drops mid-transfer without a
+// DATA short enough to look like a clean EOF. The temporary file is
+// truncated to the number of bytes actually written before it's renamed
+// into place, so a size that turns out to be wrong (e.g. tsize reports
+// the wire size of an OptCompress/OptEncrypt transfer, not the decoded
+// size GetFile writes) never leaves trailing zero bytes in destPath.
+//
+// A windowed transfer (see Client.WindowSize) that isn't also using
+// netascii, OptCompress, OptEncrypt, or Validators is received directly
+// into the temporary file via io.WriterAt, tolerating blocks that arrive
+// out of order within a window instead of failing the transfer the way
+// the ordinary streaming path does — see receiveDataFromAt. Any of those
+// features still goes through the streaming path, since each needs the
+// byte stream (or, for Validators, the whole payload) in order.
+func (c *Client) GetFile(ctx context.Context, filename, mode, destPath string) (err error) {
+	dir := filepath.Dir(destPath)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(destPath)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	opts := c.requestOptions(filename, 0)
+	if c.canReceiveOutOfOrder(mode) {
+		pc, data, from, nego, start, connErr := c.rrqConnect(ctx, filename, mode, opts)
+		if connErr != nil {
+			err = connErr
+			return err
+		}
+		if nego.WindowSize > 1 && !nego.Compressed && !nego.Encrypted {
+			err = c.getFileAt(ctx, pc, data, from, nego, start, tmp)
+			pc.Close()
+			if err != nil {
+				return err
+			}
+			if err = tmp.Close(); err != nil {
+				return err
+			}
+			return os.Rename(tmpPath, destPath)
+		}
+
+		// The server didn't actually negotiate a window, so fall back to
+		// the ordinary streaming path — but over the connection and
+		// handshake already established above, rather than paying for a
+		// second RRQ.
+		rc, streamNego, streamErr := c.getStream(ctx, pc, data, from, nego, start, mode)
+		if streamErr != nil {
+			err = streamErr
+			return err
+		}
+		defer rc.Close()
+		err = c.copyGetReaderToFile(rc, streamNego, tmp)
+		if err != nil {
+			return err
+		}
+		if err = tmp.Close(); err != nil {
+			return err
+		}
+		return os.Rename(tmpPath, destPath)
+	}
+
+	rc, nego, err := c.get(ctx, filename, mode, opts)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	if err = c.copyGetReaderToFile(rc, nego, tmp); err != nil {
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, destPath)
+}
+
+// copyGetReaderToFile drains rc into tmp, preallocating it to
+// nego.TransferSize up front as a filesystem hint and truncating it back
+// down afterward if fewer bytes actually arrived — the shared tail of
+// GetFile's streaming path, used whether the handshake came from get or
+// from GetFile's own out-of-order-eligible-but-not-windowed fallback.
+func (c *Client) copyGetReaderToFile(rc io.Reader, nego NegotiatedOptions, tmp *os.File) error {
+	if nego.TransferSize > 0 {
+		if err := tmp.Truncate(nego.TransferSize); err != nil {
+			return err
+		}
+	}
+
+	var written int64
+	var err error
+	if len(c.Validators) == 0 {
+		if written, err = io.Copy(tmp, rc); err != nil {
+			return err
+		}
+	} else {
+		// Validators need the full content before it's trusted, so
+		// buffer it rather than streaming straight to disk.
+		data, readErr := io.ReadAll(rc)
+		if readErr != nil {
+			return readErr
+		}
+		for _, v := range c.Validators {
+			if verr := v(data); verr != nil {
+				return verr
+			}
+		}
+		var n int
+		if n, err = tmp.Write(data); err != nil {
+			return err
+		}
+		written = int64(n)
+	}
+
+	if nego.TransferSize > 0 && written != nego.TransferSize {
+		return tmp.Truncate(written)
+	}
+	return nil
+}
+
+// canReceiveOutOfOrder reports whether GetFile may attempt the
+// out-of-order-tolerant io.WriterAt receive path for mode — it still
+// depends on the server actually negotiating a window (checked once the
+// handshake completes), but netascii, Compress, EncryptionKey, and
+// Validators are all known from the Client's own configuration up
+// front, since each rules the WriterAt path out regardless of what the
+// server does.
+func (c *Client) canReceiveOutOfOrder(mode string) bool {
+	return !strings.EqualFold(mode, "netascii") && !c.Compress && len(c.EncryptionKey) == 0 && len(c.Validators) == 0
+}
+
+// getFileAt drives receiveDataFromAt straight into tmp, preallocating it
+// to nego.TransferSize (when reported) and truncating it back down
+// afterward if fewer bytes actually arrived, mirroring GetFile's own
+// tsize handling for the streaming path.
+func (c *Client) getFileAt(ctx context.Context, pc net.PacketConn, data *DATA, from net.Addr, nego NegotiatedOptions, start time.Time, tmp *os.File) error {
+	if nego.TransferSize > 0 {
+		if err := tmp.Truncate(nego.TransferSize); err != nil {
+			return err
+		}
+	}
+
+	timeout := c.Timeout
+	if c.AdaptiveTimeout {
+		timeout = seedTimeoutFromRTT(time.Since(start))
+	}
+	total := int64(-1)
+	if nego.TransferSize > 0 {
+		total = nego.TransferSize
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			pc.Close()
+		case <-done:
+		}
+	}()
+
+	var xferStats transferStats
+	err := receiveDataFromAt(pc, from, nego, timeout, c.Retries, data, tmp, c.ProgressFunc, total, &xferStats, c.Capture, debugLoggerOf(c.Logger), c.RetryPolicy)
+	if err != nil {
+		if cerr := ctx.Err(); cerr != nil {
+			err = cerr
+		}
+		return err
+	}
+	if nego.TransferSize > 0 && xferStats.bytes != nego.TransferSize {
+		return tmp.Truncate(xferStats.bytes)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package tftp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestClientGetFile(t *testing.T) {
+	want := bytes.Repeat([]byte("d"), DefaultBlockSize*3)
+	addr, _ := startTestServer(t, map[string][]byte{"file.bin": want})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "out.bin")
+	if err := c.GetFile(context.Background(), "file.bin", "octet", dest); err != nil {
+		t.Fatalf("GetFile: %v", err)
+	}
+
+	got, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("GetFile wrote %d bytes, want %d", len(got), len(want))
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected only the final file in %s, found %d entries", dir, len(entries))
+	}
+}
+
+func TestClientGetFileFailureLeavesNoFile(t *testing.T) {
+	addr, _ := startTestServer(t, map[string][]byte{})
+
+	c := NewClient(addr)
+	c.Timeout = 200 * time.Millisecond
+	c.Retries = 1
+
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "out.bin")
+	if err := c.GetFile(context.Background(), "missing.bin", "octet", dest); err == nil {
+		t.Fatal("expected GetFile to fail for a missing source file")
+	}
+
+	if _, err := os.Stat(dest); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected no file at %s, stat err = %v", dest, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected no leftover temp files, found %d", len(entries))
+	}
+}
+
+// TestReceiveDataFromDetectsTruncatedTransfer sends a single short DATA
+// block that, on its own, looks like a clean end-of-transfer (a payload
+// shorter than the block size), but whose byte count falls short of the
+// tsize the caller was told to expect. receiveDataFrom must treat that
+// as a failure rather than silently handing back a truncated result.
+func TestReceiveDataFromDetectsTruncatedTransfer(t *testing.T) {
+	receiver, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer receiver.Close()
+
+	sender, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer sender.Close()
+
+	nego := NegotiatedOptions{BlockSize: 512, WindowSize: 1}
+	var buf bytes.Buffer
+
+	done := make(chan error, 1)
+	go func() {
+		done <- receiveDataFrom(receiver, sender.LocalAddr().(*net.UDPAddr), nego, 50*time.Millisecond, 1, nil, &buf, nil, 100, nil, nil, nil, nil)
+	}()
+
+	pkt, _ := (&DATA{Block: 1, Payload: []byte("short")}).MarshalBinary()
+	if _, err := sender.WriteToUDP(pkt, receiver.LocalAddr().(*net.UDPAddr)); err != nil {
+		t.Fatalf("WriteToUDP: %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("receiveDataFrom: want an error for a transfer short of the negotiated tsize")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for receiveDataFrom to detect the truncation")
+	}
+}
+
+// TestClientGetFilePreallocatesAndTruncatesToActualSize exercises
+// GetFile with OptCompress negotiated, where tsize reports the
+// compressed wire size rather than the decoded file size GetFile
+// actually writes, and checks the result is exactly the original
+// content with no leftover zero-padding from preallocating to the
+// wrong size.
+func TestClientGetFilePreallocatesAndTruncatesToActualSize(t *testing.T) {
+	want := bytes.Repeat([]byte("preallocate-me "), 2000)
+	addr, _ := startTestServer(t, map[string][]byte{"config.txt": want})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+	c.Compress = true
+
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "out.txt")
+	if err := c.GetFile(context.Background(), "config.txt", "octet", dest); err != nil {
+		t.Fatalf("GetFile: %v", err)
+	}
+
+	got, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("GetFile wrote %d bytes that don't match the original %d", len(got), len(want))
+	}
+}
+
+// TestClientGetFileUsesWriterAtPathForWindowedTransfer exercises
+// GetFile end to end with a negotiated window, the case getFileAt and
+// receiveDataFromAt exist to serve; the out-of-order tolerance itself
+// is covered directly by TestReceiveDataFromAtWritesReorderedBlocksCorrectly,
+// since a real loopback socket doesn't reorder packets reliably enough
+// to exercise that path here. The server's Middleware is set to a
+// no-op wrapper purely so sendData takes its per-packet WriteTo path
+// instead of batchSend's GSO fast path (see sendData's doc comment) —
+// that fast path relies on a kernel that actually honors UDP_SEGMENT,
+// which isn't a given in every environment this suite runs in.
+func TestClientGetFileUsesWriterAtPathForWindowedTransfer(t *testing.T) {
+	want := bytes.Repeat([]byte("w"), DefaultBlockSize*6+3)
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s := NewServer("", &memHandler{files: map[string][]byte{"file.bin": want}})
+	s.conn = conn
+	s.Middleware = []PacketMiddleware{noopWrapMiddleware}
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	c.WindowSize = 4
+
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "out.bin")
+	if err := c.GetFile(context.Background(), "file.bin", "octet", dest); err != nil {
+		t.Fatalf("GetFile: %v", err)
+	}
+
+	got, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("GetFile wrote %d bytes, want %d matching", len(got), len(want))
+	}
+}
+
+// noopPacketConn wraps a net.PacketConn without changing its behavior,
+// just its concrete type — enough to make sendData's type assertion
+// for the *net.UDPConn-only GSO fast path fail, same as any real
+// PacketMiddleware would.
+type noopPacketConn struct{ net.PacketConn }
+
+func noopWrapMiddleware(pc net.PacketConn) net.PacketConn { return noopPacketConn{pc} }
+
+// TestClientGetFile above already exercises the case where
+// canReceiveOutOfOrder(mode) holds but no window gets negotiated (it
+// never sets WindowSize), taking GetFile's fallback branch that reuses
+// the already-connected socket via getStream instead of reconnecting.
@@ -0,0 +1,79 @@
+package tftp
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io"
+)
+
+// aesGCMEncrypt encrypts data whole, under key (which must be 32 bytes,
+// for AES-256), the form OptEncrypt transmits over the wire: a random
+// 12-byte nonce followed by the AES-256-GCM sealed output, with the
+// nonce authenticated as part of the seal.
+func aesGCMEncrypt(key, data []byte) ([]byte, error) {
+	gcm, err := newAESGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, data, nil), nil
+}
+
+// aesGCMDecrypt reverses aesGCMEncrypt.
+func aesGCMDecrypt(key, data []byte) ([]byte, error) {
+	gcm, err := newAESGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("tftp: encrypted stream shorter than a nonce")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptingDecodingWriter buffers an encrypted byte stream as it
+// arrives and decrypts it in one shot on Flush, writing the plaintext
+// to the wrapped Writer. Unlike gzipDecodingWriter, this can't decrypt
+// incrementally: AES-256-GCM authenticates the whole ciphertext as a
+// single unit, so there's no partial result to hand out before the last
+// byte (and its trailing authentication tag) has arrived.
+type encryptingDecodingWriter struct {
+	buf bytes.Buffer
+	w   io.Writer
+	key []byte
+}
+
+func newEncryptingDecodingWriter(w io.Writer, key []byte) *encryptingDecodingWriter {
+	return &encryptingDecodingWriter{w: w, key: key}
+}
+
+func (e *encryptingDecodingWriter) Write(p []byte) (int, error) {
+	return e.buf.Write(p)
+}
+
+// Flush decrypts everything buffered since construction and writes the
+// plaintext to the wrapped Writer, returning any error decryption or
+// the Writer surfaced.
+func (e *encryptingDecodingWriter) Flush() error {
+	data, err := aesGCMDecrypt(e.key, e.buf.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(data)
+	return err
+}
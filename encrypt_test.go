@@ -0,0 +1,173 @@
+package tftp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestAESGCMEncryptDecryptRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte("k"), 32)
+	want := []byte("sensitive firmware bytes")
+
+	ciphertext, err := aesGCMEncrypt(key, want)
+	if err != nil {
+		t.Fatalf("aesGCMEncrypt: %v", err)
+	}
+	if bytes.Equal(ciphertext, want) {
+		t.Fatal("aesGCMEncrypt returned the plaintext unchanged")
+	}
+
+	got, err := aesGCMDecrypt(key, ciphertext)
+	if err != nil {
+		t.Fatalf("aesGCMDecrypt: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("aesGCMDecrypt = %q, want %q", got, want)
+	}
+}
+
+func TestAESGCMDecryptRejectsWrongKeyOrTamperedCiphertext(t *testing.T) {
+	key := bytes.Repeat([]byte("k"), 32)
+	ciphertext, err := aesGCMEncrypt(key, []byte("payload"))
+	if err != nil {
+		t.Fatalf("aesGCMEncrypt: %v", err)
+	}
+
+	if _, err := aesGCMDecrypt(bytes.Repeat([]byte("x"), 32), ciphertext); err == nil {
+		t.Error("aesGCMDecrypt: want an error for the wrong key")
+	}
+
+	tampered := append([]byte(nil), ciphertext...)
+	tampered[len(tampered)-1] ^= 0xff
+	if _, err := aesGCMDecrypt(key, tampered); err == nil {
+		t.Error("aesGCMDecrypt: want an error for tampered ciphertext")
+	}
+}
+
+func TestClientGetUsesEncryptionWhenNegotiated(t *testing.T) {
+	want := []byte("contains a secret provisioning token")
+	addr, _ := startTestServer(t, map[string][]byte{"secret.img": want})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+	c.EncryptionKey = bytes.Repeat([]byte("k"), 32)
+
+	rc, err := c.Get(context.Background(), "secret.img", "octet")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	defer rc.Close()
+
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("Get returned %q, want %q", got, want)
+	}
+}
+
+func TestServerIgnoresEncryptionWithoutConfiguredKey(t *testing.T) {
+	want := []byte("plain content")
+	addr, _ := startTestServer(t, map[string][]byte{"file.txt": want})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+	c.EncryptionKey = bytes.Repeat([]byte("k"), 32)
+
+	got, err := c.GetBytes("file.txt", "octet")
+	if err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("GetBytes returned %q that doesn't match the unencrypted source", got)
+	}
+}
+
+func TestServerIgnoresEncryptionForPayloadProvider(t *testing.T) {
+	want := bytes.Repeat([]byte("p"), DefaultBlockSize*2)
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s := NewServer("", nil)
+	s.EncryptionKey = bytes.Repeat([]byte("k"), 32)
+	s.PayloadProvider = func(ctx context.Context, filename string, clientAddr net.Addr) (io.ReadCloser, int64, error) {
+		return io.NopCloser(bytes.NewReader(want)), int64(len(want)), nil
+	}
+	s.conn = conn
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	c.EncryptionKey = s.EncryptionKey
+
+	got, err := c.GetBytes("anything", "octet")
+	if err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("GetBytes returned %d bytes that don't match the unencrypted source", len(got))
+	}
+}
+
+func TestClientPutUsesEncryptionWhenNegotiated(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	h := &memHandler{files: map[string][]byte{}}
+	s := NewServer("", h)
+	s.EncryptionKey = bytes.Repeat([]byte("k"), 32)
+	s.conn = conn
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	c.EncryptionKey = s.EncryptionKey
+
+	data := []byte("uploaded secret content")
+	if _, err := c.Put(context.Background(), "up.img", "octet", bytes.NewReader(data)); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	var got []byte
+	var ok bool
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if got, ok = h.get("up.img"); ok {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !ok {
+		t.Fatal("server never recorded the uploaded file")
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("stored %q, want %q matching the original upload", got, data)
+	}
+}
+
+func TestClientGetUsesCompressionAndEncryptionTogether(t *testing.T) {
+	want := bytes.Repeat([]byte("compress-and-encrypt "), 2000)
+	addr, _ := startTestServer(t, map[string][]byte{"config.txt": want})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+	c.Compress = true
+	c.EncryptionKey = bytes.Repeat([]byte("k"), 32)
+
+	got, err := c.GetBytes("config.txt", "octet")
+	if err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("GetBytes returned %d bytes, want %d matching the original", len(got), len(want))
+	}
+}
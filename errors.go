@@ -0,0 +1,46 @@
+package tftp
+
+import "fmt"
+
+// ErrorCode is the numeric error code carried by an ERROR packet, as
+// defined in RFC 1350.
+type ErrorCode uint16
+
+const (
+	ErrCodeUndefined       ErrorCode = 0
+	ErrCodeNotFound        ErrorCode = 1
+	ErrCodeAccessViolation ErrorCode = 2
+	ErrCodeDiskFull        ErrorCode = 3
+	ErrCodeIllegalOp       ErrorCode = 4
+	ErrCodeUnknownID       ErrorCode = 5
+	ErrCodeFileExists      ErrorCode = 6
+	ErrCodeNoSuchUser      ErrorCode = 7
+)
+
+// Error implements the standard error interface, so an ERROR packet
+// received off the wire can be returned directly from Client methods
+// without an intermediate wrapper type.
+func (p *ERROR) Error() string {
+	return fmt.Sprintf("tftp: error %d: %s", p.Code, p.Message)
+}
+
+// NewError builds an ERROR packet with the given code and message. It is
+// used both to send an ERROR on the wire and, since *ERROR implements
+// error, to return that failure from client code.
+func NewError(code ErrorCode, message string) *ERROR {
+	return &ERROR{Code: code, Message: message}
+}
+
+// payloadErrorCode picks the ErrorCode and message to report for an
+// error from a Handler or PayloadProvider. A *ERROR carries its own
+// code through unchanged, so a PayloadProvider can signal something
+// more specific than "not found" (e.g. ErrCodeDiskFull for a cold
+// cache); any other error is reported as ErrCodeNotFound, matching
+// Handler.ReadFile's existing contract of signaling "no such file" with
+// a plain error.
+func payloadErrorCode(err error) (ErrorCode, string) {
+	if e, ok := err.(*ERROR); ok {
+		return e.Code, e.Message
+	}
+	return ErrCodeNotFound, err.Error()
+}
@@ -0,0 +1,52 @@
+package tftp
+
+import "time"
+
+// EventKind identifies which stage of a transfer a TransferEvent
+// reports.
+type EventKind int
+
+const (
+	// EventStart is emitted once a request has passed validation and
+	// been registered as an active session, before any DATA has been
+	// sent or received.
+	EventStart EventKind = iota
+	// EventProgress is emitted after each block is sent or ACKed,
+	// with Stats reflecting the running total for the transfer so far.
+	EventProgress
+	// EventComplete is emitted when a transfer finishes successfully.
+	EventComplete
+	// EventError is emitted when a transfer ends in failure, with Err
+	// set to the cause.
+	EventError
+)
+
+// String renders k the way it appears in log messages, e.g. when an
+// event is dropped for lack of a fast-enough reader (see
+// Server.Events).
+func (k EventKind) String() string {
+	switch k {
+	case EventStart:
+		return "start"
+	case EventProgress:
+		return "progress"
+	case EventComplete:
+		return "complete"
+	case EventError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// TransferEvent is a snapshot of one RRQ/WRQ's progress, emitted to a
+// Server.Events subscriber. Stats is zero for EventStart, a running
+// total for EventProgress, and the final tally for
+// EventComplete/EventError. Err is always nil except on EventError.
+type TransferEvent struct {
+	Kind    EventKind
+	Session Session
+	Stats   Stats
+	Err     error
+	Time    time.Time
+}
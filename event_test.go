@@ -0,0 +1,121 @@
+package tftp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestServerEventsReportsStartProgressAndComplete(t *testing.T) {
+	want := bytes.Repeat([]byte("e"), DefaultBlockSize*3)
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s := NewServer("", &memHandler{files: map[string][]byte{"file.bin": want}})
+	events := s.Events()
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	got, err := c.GetBytes("file.bin", "octet")
+	if err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("GetBytes returned %d bytes, want %d", len(got), len(want))
+	}
+
+	var sawStart, sawProgress, sawComplete bool
+	deadline := time.After(time.Second)
+	for !sawComplete {
+		select {
+		case ev := <-events:
+			switch ev.Kind {
+			case EventStart:
+				sawStart = true
+			case EventProgress:
+				sawProgress = true
+			case EventComplete:
+				sawComplete = true
+				if ev.Stats.Bytes != int64(len(want)) {
+					t.Errorf("EventComplete Stats.Bytes = %d, want %d", ev.Stats.Bytes, len(want))
+				}
+			case EventError:
+				t.Fatalf("unexpected EventError: %v", ev.Err)
+			}
+		case <-deadline:
+			t.Fatalf("timed out waiting for EventComplete: start=%v progress=%v", sawStart, sawProgress)
+		}
+	}
+	if !sawStart {
+		t.Error("never saw an EventStart")
+	}
+	if !sawProgress {
+		t.Error("never saw an EventProgress")
+	}
+}
+
+// rejectingHandler answers every WriteFile with an error, so a WRQ
+// reaches s.reportTransfer's failure path after a full receiveData.
+type rejectingHandler struct{ *memHandler }
+
+func (rejectingHandler) WriteFile(name string, data []byte) error {
+	return errors.New("rejected by test handler")
+}
+
+func TestServerEventsReportsErrorOnFailedTransfer(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s := NewServer("", rejectingHandler{&memHandler{files: map[string][]byte{}}})
+	events := s.Events()
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	// The server ACKs every block as receiveData assembles them, before
+	// ever calling Handler.WriteFile, so its rejection here isn't
+	// visible to Put's return value — only to Events.
+	c.Put(context.Background(), "file.bin", "octet", bytes.NewReader([]byte("payload")))
+
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case ev := <-events:
+			if ev.Kind == EventError {
+				if ev.Err == nil {
+					t.Error("EventError: want a non-nil Err")
+				}
+				return
+			}
+		case <-deadline:
+			t.Fatal("timed out waiting for EventError")
+		}
+	}
+}
+
+func TestServerEventsUnusedByDefault(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s := NewServer("", &memHandler{files: map[string][]byte{"file.bin": []byte("hi")}})
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	if _, err := c.GetBytes("file.bin", "octet"); err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+	if s.eventsOn.Load() {
+		t.Error("eventsOn should stay false until Events is called")
+	}
+}
@@ -0,0 +1,85 @@
+package tftp
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestClientGetFailsOverToNextAddr(t *testing.T) {
+	// A bound socket nobody ever answers on, standing in for a server
+	// that's down but whose address still exists on the network (so the
+	// request is silently dropped rather than bouncing an ICMP error).
+	dead, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer dead.Close()
+
+	want := []byte("kernel image bytes")
+	addr, _ := startTestServer(t, map[string][]byte{"boot.img": want})
+
+	c := NewClient(dead.LocalAddr().String())
+	c.Addrs = []string{dead.LocalAddr().String(), addr}
+	c.Timeout = 50 * time.Millisecond
+	c.Retries = 1
+
+	got, err := c.GetBytes("boot.img", "octet")
+	if err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("GetBytes = %q, want %q", got, want)
+	}
+}
+
+func TestClientPutFailsOverToNextAddr(t *testing.T) {
+	dead, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer dead.Close()
+
+	addr, h := startTestServer(t, map[string][]byte{})
+
+	c := NewClient(dead.LocalAddr().String())
+	c.Addrs = []string{dead.LocalAddr().String(), addr}
+	c.Timeout = 50 * time.Millisecond
+	c.Retries = 1
+
+	data := []byte("uploaded via the secondary server")
+	if _, err := c.Put(context.Background(), "up.img", "octet", bytes.NewReader(data)); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	var got []byte
+	var ok bool
+	for time.Now().Before(deadline) {
+		if got, ok = h.get("up.img"); ok {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !ok {
+		t.Fatal("server never recorded the uploaded file")
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("stored %q, want %q", got, data)
+	}
+}
+
+func TestClientGetReturnsServerErrorWithoutFailover(t *testing.T) {
+	addr, _ := startTestServer(t, map[string][]byte{})
+
+	c := NewClient(addr)
+	c.Addrs = []string{addr, "127.0.0.1:1"}
+	c.Timeout = 50 * time.Millisecond
+	c.Retries = 1
+
+	if _, err := c.GetBytes("missing.img", "octet"); err == nil {
+		t.Fatal("GetBytes: want an error for a nonexistent file, got nil")
+	}
+}
@@ -0,0 +1,129 @@
+package tftp
+
+import (
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+// FaultConfig configures the probabilistic misbehavior FaultInjector
+// applies to outgoing datagrams: dropped, duplicated, reordered, or
+// delayed, the way a real lossy link does. Each probability is
+// independent and in [0, 1]; the zero value disables every fault.
+type FaultConfig struct {
+	// DropProbability is the chance a written datagram is silently
+	// discarded instead of sent.
+	DropProbability float64
+	// DuplicateProbability is the chance a written datagram is also
+	// sent a second time, after an independently chosen delay.
+	DuplicateProbability float64
+	// ReorderProbability is the chance a written datagram is held back
+	// by an extra, larger delay so a later datagram can overtake it,
+	// producing the out-of-order arrivals retransmission logic has to
+	// tolerate on a real network.
+	ReorderProbability float64
+	// MaxDelay caps a random delay, uniformly chosen from [0,
+	// MaxDelay], applied to every datagram that isn't dropped. Zero
+	// disables the delay.
+	MaxDelay time.Duration
+	// Rand seeds the injector's randomness for a reproducible run. A
+	// nil Rand seeds one from the current time.
+	Rand *rand.Rand
+}
+
+// reorderDelayFactor multiplies MaxDelay for a datagram chosen for
+// reordering, so it reliably lands behind whatever follows it rather
+// than just sometimes winning the race against MaxDelay's own jitter.
+const reorderDelayFactor = 4
+
+// FaultInjector returns a PacketMiddleware that applies cfg's faults to
+// every datagram a Client or Server session writes, so retransmission
+// logic can be exercised against realistic network misbehavior in a
+// test -- or via a hidden CLI flag -- without needing an actual lossy
+// link. Faults are injected only on writes: every datagram a session
+// reads was written by its peer's own session, so injecting on the
+// write side alone already exercises both ends of the exchange.
+func FaultInjector(cfg FaultConfig) PacketMiddleware {
+	rng := cfg.Rand
+	if rng == nil {
+		rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	shared := &faultRand{rng: rng}
+	return func(conn net.PacketConn) net.PacketConn {
+		return &faultConn{PacketConn: conn, cfg: cfg, rand: shared}
+	}
+}
+
+// faultRand guards a *rand.Rand shared by every faultConn a single
+// FaultInjector call produces, so one seed drives every session's
+// randomness deterministically no matter how many sessions the
+// middleware ends up wrapping.
+type faultRand struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+func (f *faultRand) chance(p float64) bool {
+	if p <= 0 {
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rng.Float64() < p
+}
+
+func (f *faultRand) delay(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return time.Duration(f.rng.Int63n(int64(max) + 1))
+}
+
+// faultConn wraps a net.PacketConn, applying FaultConfig's faults to
+// every WriteTo. Reads pass straight through to the embedded conn.
+type faultConn struct {
+	net.PacketConn
+	cfg  FaultConfig
+	rand *faultRand
+}
+
+func (c *faultConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	if c.rand.chance(c.cfg.DropProbability) {
+		return len(b), nil
+	}
+
+	cp := make([]byte, len(b))
+	copy(cp, b)
+
+	delay := c.rand.delay(c.cfg.MaxDelay)
+	if c.rand.chance(c.cfg.ReorderProbability) {
+		delay += c.rand.delay(c.cfg.MaxDelay)*reorderDelayFactor + time.Millisecond
+	}
+	c.sendAfter(delay, cp, addr)
+
+	if c.rand.chance(c.cfg.DuplicateProbability) {
+		dup := make([]byte, len(b))
+		copy(dup, b)
+		c.sendAfter(c.rand.delay(c.cfg.MaxDelay), dup, addr)
+	}
+
+	return len(b), nil
+}
+
+// sendAfter writes b to addr once delay elapses. A zero delay writes
+// synchronously so the common, fault-free case pays no goroutine or
+// timer overhead; any write error once delayed has nowhere left to go,
+// since WriteTo already returned success to its caller, so it's simply
+// dropped the same as a real misbehaving link would drop it.
+func (c *faultConn) sendAfter(delay time.Duration, b []byte, addr net.Addr) {
+	if delay <= 0 {
+		c.PacketConn.WriteTo(b, addr)
+		return
+	}
+	time.AfterFunc(delay, func() {
+		c.PacketConn.WriteTo(b, addr)
+	})
+}
@@ -0,0 +1,155 @@
+package tftp
+
+import (
+	"math/rand"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testPacketConnPair is a trimmed-down stand-in for tftptest.PacketConnPair,
+// duplicated here rather than imported to avoid this package's test binary
+// importing tftptest, which itself imports this package.
+func testPacketConnPair() (net.PacketConn, net.PacketConn) {
+	aToB := make(chan []byte, 64)
+	bToA := make(chan []byte, 64)
+	a := &testPacketConn{addr: testAddr("a"), send: aToB, recv: bToA}
+	b := &testPacketConn{addr: testAddr("b"), send: bToA, recv: aToB}
+	return a, b
+}
+
+type testAddr string
+
+func (a testAddr) Network() string { return "test" }
+func (a testAddr) String() string  { return string(a) }
+
+type testPacketConn struct {
+	addr net.Addr
+	send chan<- []byte
+	recv <-chan []byte
+
+	mu       sync.Mutex
+	deadline time.Time
+}
+
+func (c *testPacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	c.mu.Lock()
+	deadline := c.deadline
+	c.mu.Unlock()
+
+	var timeoutCh <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		timeoutCh = timer.C
+	}
+	select {
+	case msg := <-c.recv:
+		return copy(b, msg), c.addr, nil
+	case <-timeoutCh:
+		return 0, nil, testTimeoutErr{}
+	}
+}
+
+func (c *testPacketConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	c.send <- append([]byte(nil), b...)
+	return len(b), nil
+}
+
+func (c *testPacketConn) Close() error                  { return nil }
+func (c *testPacketConn) LocalAddr() net.Addr           { return c.addr }
+func (c *testPacketConn) SetDeadline(t time.Time) error { return c.SetReadDeadline(t) }
+func (c *testPacketConn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.deadline = t
+	c.mu.Unlock()
+	return nil
+}
+func (c *testPacketConn) SetWriteDeadline(t time.Time) error { return nil }
+
+type testTimeoutErr struct{}
+
+func (testTimeoutErr) Error() string   { return "test: i/o timeout" }
+func (testTimeoutErr) Timeout() bool   { return true }
+func (testTimeoutErr) Temporary() bool { return true }
+
+func TestFaultInjectorZeroValuePassesThrough(t *testing.T) {
+	a, b := testPacketConnPair()
+	a = FaultInjector(FaultConfig{})(a)
+
+	if _, err := a.WriteTo([]byte("hello"), b.LocalAddr()); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	buf := make([]byte, 16)
+	n, _, err := b.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("ReadFrom: %v", err)
+	}
+	if string(buf[:n]) != "hello" {
+		t.Errorf("ReadFrom = %q, want %q", buf[:n], "hello")
+	}
+}
+
+func TestFaultInjectorDropsEveryDatagram(t *testing.T) {
+	a, b := testPacketConnPair()
+	a = FaultInjector(FaultConfig{DropProbability: 1, Rand: rand.New(rand.NewSource(1))})(a)
+
+	if _, err := a.WriteTo([]byte("hello"), b.LocalAddr()); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	b.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	buf := make([]byte, 16)
+	if _, _, err := b.ReadFrom(buf); err == nil {
+		t.Fatal("ReadFrom: want a timeout, DropProbability: 1 should have discarded the datagram")
+	}
+}
+
+func TestFaultInjectorDuplicatesEveryDatagram(t *testing.T) {
+	a, b := testPacketConnPair()
+	a = FaultInjector(FaultConfig{DuplicateProbability: 1, Rand: rand.New(rand.NewSource(1))})(a)
+
+	if _, err := a.WriteTo([]byte("hello"), b.LocalAddr()); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		b.SetReadDeadline(time.Now().Add(time.Second))
+		buf := make([]byte, 16)
+		n, _, err := b.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("ReadFrom %d: %v", i, err)
+		}
+		if string(buf[:n]) != "hello" {
+			t.Errorf("ReadFrom %d = %q, want %q", i, buf[:n], "hello")
+		}
+	}
+}
+
+func TestFaultInjectorDelaysDatagrams(t *testing.T) {
+	a, b := testPacketConnPair()
+	a = FaultInjector(FaultConfig{MaxDelay: 50 * time.Millisecond, Rand: rand.New(rand.NewSource(1))})(a)
+
+	if _, err := a.WriteTo([]byte("hello"), b.LocalAddr()); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	b.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 16)
+	if _, _, err := b.ReadFrom(buf); err != nil {
+		t.Fatalf("ReadFrom: %v", err)
+	}
+}
+
+func TestFaultInjectorSharesOneRandAcrossConns(t *testing.T) {
+	inj := FaultInjector(FaultConfig{DropProbability: 1, Rand: rand.New(rand.NewSource(1))})
+
+	_, b1 := testPacketConnPair()
+	a1 := inj(&testPacketConn{addr: testAddr("a1"), send: make(chan []byte, 1)})
+	a2 := inj(&testPacketConn{addr: testAddr("a2"), send: make(chan []byte, 1)})
+
+	if _, err := a1.WriteTo([]byte("x"), b1.LocalAddr()); err != nil {
+		t.Fatalf("WriteTo a1: %v", err)
+	}
+	if _, err := a2.WriteTo([]byte("y"), b1.LocalAddr()); err != nil {
+		t.Fatalf("WriteTo a2: %v", err)
+	}
+}
@@ -0,0 +1,358 @@
+package tftp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// errUnsafeFilename is returned when a requested filename resolves
+// outside Dir, e.g. via a ".." path segment or an absolute path.
+var errUnsafeFilename = errors.New("tftp: filename escapes handler directory")
+
+// sha256SidecarSuffix marks an RRQ as asking for a file's checksum
+// sidecar rather than the file itself (see ReadFile), the same
+// "<filename>.sha256" convention Client.GetVerified already reads from
+// when it isn't handed an expected digest directly.
+const sha256SidecarSuffix = ".sha256"
+
+// FileHandler is a Handler backed by a directory on disk, for embedders
+// that just want to serve and accept files from a filesystem without
+// writing their own Handler. ReadFile reads a file straight out of Dir;
+// WriteFile writes a WRQ's content to a temporary file in Dir and
+// renames it into place once complete, so a reader never observes a
+// partially written file.
+type FileHandler struct {
+	Dir string
+
+	// Fsync, if true, fsyncs a WRQ's temporary file before it's renamed
+	// into place and fsyncs Dir afterward, so an upload (e.g. a config
+	// backup) survives a power loss on the host instead of leaving a
+	// truncated file, or a file that was written but never durably
+	// renamed into place, behind. Both syncs are needed: POSIX doesn't
+	// guarantee a rename itself is durable just because the renamed
+	// file was fsynced first.
+	Fsync bool
+
+	// FsyncEveryBytes, if non-zero, also fsyncs the temporary file
+	// every FsyncEveryBytes written, rather than only once at the end.
+	// WriteFile receives a WRQ's content already fully assembled in
+	// memory, with the DATA block boundaries it arrived in no longer
+	// visible, so this approximates "fsync every N blocks" at a byte
+	// granularity instead. Has no effect unless Fsync is also true.
+	FsyncEveryBytes int64
+
+	// Sandbox, if true, enforces Dir at the kernel level via
+	// openat2(2)'s RESOLVE_BENEATH on every read and write, instead of
+	// relying solely on resolve's path-cleaning — so a bug in resolve,
+	// or a symlink race, can't be exploited to escape Dir. It only
+	// covers flat filenames: one containing a path separator, or equal
+	// to "." or "..", is rejected outright rather than attempting to
+	// sandbox a multi-component path. Available only on linux/amd64
+	// with a 5.6+ kernel; ReadFile and WriteFile return
+	// errSandboxUnsupported immediately everywhere else, so a
+	// deployment that turned this on for the hard guarantee never runs
+	// unknowingly without it.
+	Sandbox bool
+
+	digests digestCache
+}
+
+// digestCache remembers a FileHandler's computed sha256 digests, keyed
+// by filename, so serving "<filename>.sha256" repeatedly doesn't rehash
+// an unchanged file on every request. An entry is recomputed once the
+// file's size or modification time no longer matches what it was hashed
+// under.
+type digestCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedDigest
+}
+
+type cachedDigest struct {
+	modTime time.Time
+	size    int64
+	hex     string
+}
+
+// get returns the hex sha256 digest of data (the current content of
+// filename, whose metadata is modTime/size), reusing a cached digest
+// when filename's metadata hasn't changed since it was last computed.
+func (c *digestCache) get(filename string, modTime time.Time, size int64, data []byte) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[filename]; ok && e.modTime.Equal(modTime) && e.size == size {
+		return e.hex
+	}
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+	if c.entries == nil {
+		c.entries = make(map[string]cachedDigest)
+	}
+	c.entries[filename] = cachedDigest{modTime: modTime, size: size, hex: digest}
+	return digest
+}
+
+// sandboxTmpSeq numbers WriteFile's temporary files when Sandbox is
+// set, since openBeneath can't use os.CreateTemp's own random-suffix
+// generator (it needs a name to hand to openat2, not an already-open
+// file).
+var sandboxTmpSeq atomic.Uint64
+
+// errSandboxedFilenameHasPathComponents is returned by a sandboxed
+// FileHandler for any filename RESOLVE_BENEATH can't be trusted to
+// reason about as a single, unambiguous path component.
+var errSandboxedFilenameHasPathComponents = errors.New("tftp: sandboxed FileHandler rejects filenames with path separators or . / ..")
+
+// errSandboxUnsupported is returned by openBeneath/renameBeneath on any
+// platform/architecture other than linux/amd64, since the openat2(2)
+// RESOLVE_BENEATH mechanism FileHandler.Sandbox relies on doesn't exist
+// elsewhere. Rather than silently falling back to ordinary path-cleaned
+// opens, Sandbox fails outright so a deployment that turned it on for
+// the hard guarantee never runs unknowingly without it.
+var errSandboxUnsupported = errors.New("tftp: FileHandler.Sandbox requires a linux/amd64 host with a 5.6+ kernel")
+
+// normalizeRequestedFilename converts filename into the forward-slash,
+// drive-letter-free form resolve expects, so a firmware client that
+// requests a Windows-style path ("C:\boot\pxelinux.0") resolves the
+// same way it would have if it had sent forward slashes and no drive
+// letter instead. This is applied unconditionally, not just when this
+// host is itself running Windows, since it's the client — not this
+// host's OS — that decides which separator style shows up on the wire.
+func normalizeRequestedFilename(filename string) string {
+	filename = strings.ReplaceAll(filename, `\`, "/")
+	if len(filename) >= 2 && filename[1] == ':' && isASCIILetter(filename[0]) {
+		filename = filename[2:]
+	}
+	return filename
+}
+
+func isASCIILetter(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// resolve joins filename onto h.Dir, rejecting one that would resolve
+// outside of it.
+func (h *FileHandler) resolve(filename string) (string, error) {
+	filename = normalizeRequestedFilename(filename)
+	full := filepath.Join(h.Dir, filename)
+	rel, err := filepath.Rel(h.Dir, full)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errUnsafeFilename
+	}
+	return full, nil
+}
+
+// ReadFile implements Handler. A filename ending in sha256SidecarSuffix
+// is treated as a request for that file's checksum sidecar rather than
+// a file of its own: an on-disk sidecar with that exact name is served
+// as-is if one exists, so an operator can still ship a hand-curated or
+// multi-algorithm one, and otherwise one is generated on the fly from
+// the underlying file's sha256 digest (see digestCache), in the same
+// "<hex>  <filename>" format sha256sum produces and Client.GetVerified
+// already knows how to parse.
+func (h *FileHandler) ReadFile(filename string) ([]byte, error) {
+	base, isSidecar := strings.CutSuffix(filename, sha256SidecarSuffix)
+	if isSidecar {
+		if data, _, _, err := h.readFileWithInfo(filename); err == nil {
+			return data, nil
+		}
+		return h.sha256Sidecar(base)
+	}
+	data, _, _, err := h.readFileWithInfo(filename)
+	return data, err
+}
+
+// sha256Sidecar returns the generated checksum sidecar content for
+// filename, computing (and caching) its sha256 digest along the way.
+func (h *FileHandler) sha256Sidecar(filename string) ([]byte, error) {
+	data, modTime, size, err := h.readFileWithInfo(filename)
+	if err != nil {
+		return nil, err
+	}
+	digest := h.digests.get(filename, modTime, size, data)
+	return []byte(digest + "  " + filename + "\n"), nil
+}
+
+// readFileWithInfo is ReadFile's non-sidecar codepath, also returning
+// filename's modification time and size so sha256Sidecar can cache
+// against them without a second read.
+func (h *FileHandler) readFileWithInfo(filename string) ([]byte, time.Time, int64, error) {
+	if h.Sandbox {
+		return h.readFileWithInfoSandboxed(filename)
+	}
+	path, err := h.resolve(filename)
+	if err != nil {
+		return nil, time.Time{}, 0, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, time.Time{}, 0, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, time.Time{}, 0, err
+	}
+	return data, info.ModTime(), info.Size(), nil
+}
+
+// readFileWithInfoSandboxed is readFileWithInfo's Sandbox codepath: it
+// hands filename to openBeneath unresolved, rather than through
+// resolve, so the guarantee comes from the kernel rejecting the open
+// rather than from this package's own path-cleaning.
+func (h *FileHandler) readFileWithInfoSandboxed(filename string) ([]byte, time.Time, int64, error) {
+	if isUnsafeSandboxFilename(filename) {
+		return nil, time.Time{}, 0, errSandboxedFilenameHasPathComponents
+	}
+	f, err := openBeneath(h.Dir, filename, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, time.Time{}, 0, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, time.Time{}, 0, err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, time.Time{}, 0, err
+	}
+	return data, info.ModTime(), info.Size(), nil
+}
+
+// isUnsafeSandboxFilename reports whether filename has more structure
+// than openBeneath/renameBeneath can safely treat as a single path
+// component beneath Dir.
+func isUnsafeSandboxFilename(filename string) bool {
+	return filename == "" || filename == "." || filename == ".." ||
+		strings.ContainsRune(filename, '/') || strings.ContainsRune(filename, '\\')
+}
+
+// WriteFile implements Handler.
+func (h *FileHandler) WriteFile(filename string, data []byte) error {
+	if h.Sandbox {
+		return h.writeFileSandboxed(filename, data)
+	}
+	final, err := h.resolve(filename)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(h.Dir, ".tftp-*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName) // no-op once the rename below succeeds
+
+	if err := h.writeChunked(tmp, data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if h.Fsync {
+		if err := tmp.Sync(); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpName, final); err != nil {
+		return err
+	}
+	if h.Fsync {
+		return fsyncDir(h.Dir)
+	}
+	return nil
+}
+
+// writeFileSandboxed is WriteFile's Sandbox codepath: the temporary
+// file is created directly beneath Dir via an ordinary openat2 call
+// (its name is one this package generated itself, not attacker
+// controlled, so it needs no RESOLVE_BENEATH guarantee), but the final
+// rename onto filename goes through renameBeneath, whose only defense
+// against filename escaping Dir is the isUnsafeSandboxFilename check
+// below — renameat(2) itself has no RESOLVE_BENEATH equivalent.
+func (h *FileHandler) writeFileSandboxed(filename string, data []byte) error {
+	if isUnsafeSandboxFilename(filename) {
+		return errSandboxedFilenameHasPathComponents
+	}
+
+	tmpName := fmt.Sprintf(".tftp-%d.tmp", sandboxTmpSeq.Add(1))
+	f, err := openBeneath(h.Dir, tmpName, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	renamed := false
+	defer func() {
+		if !renamed {
+			os.Remove(filepath.Join(h.Dir, tmpName)) // no-op once the rename below succeeds
+		}
+	}()
+
+	if err := h.writeChunked(f, data); err != nil {
+		f.Close()
+		return err
+	}
+	if h.Fsync {
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := renameBeneath(h.Dir, tmpName, filename); err != nil {
+		return err
+	}
+	renamed = true
+	if h.Fsync {
+		return fsyncDir(h.Dir)
+	}
+	return nil
+}
+
+// writeChunked writes data to f, fsyncing every FsyncEveryBytes along
+// the way when configured to.
+func (h *FileHandler) writeChunked(f *os.File, data []byte) error {
+	if !h.Fsync || h.FsyncEveryBytes <= 0 {
+		_, err := f.Write(data)
+		return err
+	}
+	for len(data) > 0 {
+		n := int64(len(data))
+		if n > h.FsyncEveryBytes {
+			n = h.FsyncEveryBytes
+		}
+		if _, err := f.Write(data[:n]); err != nil {
+			return err
+		}
+		if err := f.Sync(); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+// fsyncDir fsyncs dir itself, which is what actually makes a rename
+// into it durable, separately from fsyncing the renamed file's own
+// contents. Windows has no equivalent of fsyncing a directory handle,
+// so a failure to even open one there is treated as a no-op instead of
+// failing the whole write.
+func fsyncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return nil
+	}
+	defer d.Close()
+	return d.Sync()
+}
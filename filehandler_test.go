@@ -0,0 +1,240 @@
+package tftp
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestFileHandlerRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	h := &FileHandler{Dir: dir}
+
+	want := []byte("hello from disk")
+	if err := os.WriteFile(filepath.Join(dir, "existing.txt"), want, 0o644); err != nil {
+		t.Fatalf("WriteFile (setup): %v", err)
+	}
+	got, err := h.ReadFile("existing.txt")
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("ReadFile = %q, want %q", got, want)
+	}
+
+	upload := bytes.Repeat([]byte("u"), DefaultBlockSize*2+7)
+	if err := h.WriteFile("uploaded.bin", upload); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	got, err = os.ReadFile(filepath.Join(dir, "uploaded.bin"))
+	if err != nil {
+		t.Fatalf("ReadFile after WriteFile: %v", err)
+	}
+	if !bytes.Equal(got, upload) {
+		t.Fatalf("uploaded.bin = %d bytes, want %d", len(got), len(upload))
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	for _, e := range entries {
+		if filepath.Ext(e.Name()) == ".tmp" {
+			t.Errorf("leftover temp file after WriteFile: %s", e.Name())
+		}
+	}
+}
+
+func TestFileHandlerRejectsPathEscape(t *testing.T) {
+	dir := t.TempDir()
+	h := &FileHandler{Dir: dir}
+
+	if _, err := h.ReadFile("../escape.txt"); err != errUnsafeFilename {
+		t.Errorf("ReadFile(\"../escape.txt\") error = %v, want errUnsafeFilename", err)
+	}
+	if err := h.WriteFile("../escape.txt", []byte("x")); err != errUnsafeFilename {
+		t.Errorf("WriteFile(\"../escape.txt\") error = %v, want errUnsafeFilename", err)
+	}
+}
+
+// TestFileHandlerSandboxRoundTrip exercises the openat2 RESOLVE_BENEATH
+// codepath where it's available (linux/amd64, kernel 5.6+), and settles
+// for confirming the documented ENOSYS failure mode everywhere else —
+// including a linux/amd64 build running on the older kernel this test
+// suite itself may be running under.
+func TestFileHandlerSandboxRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	h := &FileHandler{Dir: dir, Sandbox: true}
+
+	upload := bytes.Repeat([]byte("x"), DefaultBlockSize+5)
+	err := h.WriteFile("sandboxed.bin", upload)
+	if errors.Is(err, syscall.ENOSYS) || errors.Is(err, errSandboxUnsupported) {
+		t.Skipf("openat2 RESOLVE_BENEATH unavailable in this environment: %v", err)
+	}
+	if err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	got, err := h.ReadFile("sandboxed.bin")
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if !bytes.Equal(got, upload) {
+		t.Fatalf("ReadFile = %d bytes, want %d", len(got), len(upload))
+	}
+}
+
+func TestFileHandlerSandboxRejectsPathSeparators(t *testing.T) {
+	dir := t.TempDir()
+	h := &FileHandler{Dir: dir, Sandbox: true}
+
+	for _, name := range []string{"../escape.txt", "sub/dir.txt", ".", ".."} {
+		if _, err := h.ReadFile(name); err != errSandboxedFilenameHasPathComponents {
+			t.Errorf("ReadFile(%q) error = %v, want errSandboxedFilenameHasPathComponents", name, err)
+		}
+		if err := h.WriteFile(name, []byte("x")); err != errSandboxedFilenameHasPathComponents {
+			t.Errorf("WriteFile(%q) error = %v, want errSandboxedFilenameHasPathComponents", name, err)
+		}
+	}
+}
+
+func TestFileHandlerNormalizesBackslashesAndDriveLetters(t *testing.T) {
+	dir := t.TempDir()
+	h := &FileHandler{Dir: dir}
+
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	want := []byte("windows-style request")
+	if err := os.WriteFile(filepath.Join(dir, "sub", "boot.cfg"), want, 0o644); err != nil {
+		t.Fatalf("WriteFile (setup): %v", err)
+	}
+
+	for _, name := range []string{`sub\boot.cfg`, `C:\sub\boot.cfg`, `c:\sub\boot.cfg`} {
+		got, err := h.ReadFile(name)
+		if err != nil {
+			t.Fatalf("ReadFile(%q): %v", name, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("ReadFile(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestFileHandlerRejectsBackslashPathEscape(t *testing.T) {
+	dir := t.TempDir()
+	h := &FileHandler{Dir: dir}
+
+	if _, err := h.ReadFile(`..\escape.txt`); err != errUnsafeFilename {
+		t.Errorf(`ReadFile("..\escape.txt") error = %v, want errUnsafeFilename`, err)
+	}
+	if err := h.WriteFile(`..\escape.txt`, []byte("x")); err != errUnsafeFilename {
+		t.Errorf(`WriteFile("..\escape.txt") error = %v, want errUnsafeFilename`, err)
+	}
+}
+
+func TestFileHandlerFsyncEveryBytesStillWritesEverything(t *testing.T) {
+	dir := t.TempDir()
+	h := &FileHandler{Dir: dir, Fsync: true, FsyncEveryBytes: 37}
+
+	upload := bytes.Repeat([]byte("s"), DefaultBlockSize*3+11)
+	if err := h.WriteFile("synced.bin", upload); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "synced.bin"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if !bytes.Equal(got, upload) {
+		t.Fatalf("synced.bin = %d bytes, want %d", len(got), len(upload))
+	}
+}
+
+func TestFileHandlerGeneratesSHA256Sidecar(t *testing.T) {
+	dir := t.TempDir()
+	h := &FileHandler{Dir: dir}
+
+	content := []byte("firmware image bytes")
+	if err := os.WriteFile(filepath.Join(dir, "image.bin"), content, 0o644); err != nil {
+		t.Fatalf("WriteFile (setup): %v", err)
+	}
+
+	got, err := h.ReadFile("image.bin.sha256")
+	if err != nil {
+		t.Fatalf("ReadFile(image.bin.sha256): %v", err)
+	}
+
+	sum := sha256.Sum256(content)
+	want := hex.EncodeToString(sum[:])
+	fields := strings.Fields(string(got))
+	if len(fields) == 0 || fields[0] != want {
+		t.Errorf("ReadFile(image.bin.sha256) = %q, want it to start with %q", got, want)
+	}
+}
+
+func TestFileHandlerSHA256SidecarPrefersOnDiskOverride(t *testing.T) {
+	dir := t.TempDir()
+	h := &FileHandler{Dir: dir}
+
+	if err := os.WriteFile(filepath.Join(dir, "image.bin"), []byte("content"), 0o644); err != nil {
+		t.Fatalf("WriteFile (setup): %v", err)
+	}
+	custom := []byte("deadbeef  image.bin\n")
+	if err := os.WriteFile(filepath.Join(dir, "image.bin.sha256"), custom, 0o644); err != nil {
+		t.Fatalf("WriteFile (sidecar setup): %v", err)
+	}
+
+	got, err := h.ReadFile("image.bin.sha256")
+	if err != nil {
+		t.Fatalf("ReadFile(image.bin.sha256): %v", err)
+	}
+	if !bytes.Equal(got, custom) {
+		t.Errorf("ReadFile(image.bin.sha256) = %q, want the on-disk sidecar %q served as-is", got, custom)
+	}
+}
+
+func TestFileHandlerSHA256SidecarRecomputesAfterChange(t *testing.T) {
+	dir := t.TempDir()
+	h := &FileHandler{Dir: dir}
+	path := filepath.Join(dir, "image.bin")
+
+	if err := os.WriteFile(path, []byte("version one"), 0o644); err != nil {
+		t.Fatalf("WriteFile (setup): %v", err)
+	}
+	first, err := h.ReadFile("image.bin.sha256")
+	if err != nil {
+		t.Fatalf("ReadFile(image.bin.sha256): %v", err)
+	}
+
+	newer := time.Now().Add(time.Second)
+	if err := os.WriteFile(path, []byte("version two, a different length"), 0o644); err != nil {
+		t.Fatalf("WriteFile (update): %v", err)
+	}
+	if err := os.Chtimes(path, newer, newer); err != nil {
+		t.Fatalf("Chtimes: %v", err)
+	}
+
+	second, err := h.ReadFile("image.bin.sha256")
+	if err != nil {
+		t.Fatalf("ReadFile(image.bin.sha256) after update: %v", err)
+	}
+	if bytes.Equal(first, second) {
+		t.Errorf("sidecar digest unchanged after the underlying file's content changed")
+	}
+}
+
+func TestFileHandlerSHA256SidecarOfMissingFile(t *testing.T) {
+	dir := t.TempDir()
+	h := &FileHandler{Dir: dir}
+
+	if _, err := h.ReadFile("missing.bin.sha256"); err == nil {
+		t.Error("ReadFile(missing.bin.sha256): want an error for a file that doesn't exist")
+	}
+}
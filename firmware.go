@@ -0,0 +1,77 @@
+package tftp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FirmwareUpdate describes a single "pull firmware over TFTP, then
+// flash" operation: what file to fetch, what digest to expect, where
+// to install it, and what to run once it's in place.
+type FirmwareUpdate struct {
+	// Filename is the remote file to fetch.
+	Filename string
+	// Mode is the transfer mode, usually "octet".
+	Mode string
+	// SHA256 is the expected hex-encoded digest of Filename's content.
+	// Empty fetches the digest from "<Filename>.sha256" instead; see
+	// GetVerified.
+	SHA256 string
+	// DestPath is where the verified firmware is installed, atomically
+	// via a temp file and rename in the same directory.
+	DestPath string
+	// PostInstall, if set, runs once DestPath holds the new firmware --
+	// typically the step that actually flashes it or schedules a
+	// reboot. A PostInstall error is returned to the caller, but the
+	// new firmware is left installed: blindly rolling it back is
+	// riskier than leaving that decision to a human.
+	PostInstall func(destPath string) error
+}
+
+// UpdateFirmware downloads, verifies, and installs u, then runs
+// u.PostInstall if set. It's the one call a provisioning or upgrade
+// tool needs for the "pull firmware over TFTP then flash" sequence,
+// instead of composing GetVerified, an atomic install, and a hook
+// invocation by hand every time.
+func (c *Client) UpdateFirmware(ctx context.Context, u FirmwareUpdate) error {
+	data, err := c.GetVerified(ctx, u.Filename, u.Mode, u.SHA256)
+	if err != nil {
+		return fmt.Errorf("tftp: update firmware: %w", err)
+	}
+	if err := writeFileAtomic(u.DestPath, data); err != nil {
+		return fmt.Errorf("tftp: update firmware: install %s: %w", u.DestPath, err)
+	}
+	if u.PostInstall != nil {
+		if err := u.PostInstall(u.DestPath); err != nil {
+			return fmt.Errorf("tftp: update firmware: post-install: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to destPath via a temporary file in the
+// same directory followed by a rename, so installing new firmware is
+// all-or-nothing even if the process is interrupted mid-write.
+func writeFileAtomic(destPath string, data []byte) (err error) {
+	dir := filepath.Dir(destPath)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(destPath)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+	if _, err = tmp.Write(data); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, destPath)
+}
@@ -0,0 +1,99 @@
+package tftp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestClientUpdateFirmwareInstallsAndRunsPostInstall(t *testing.T) {
+	want := bytes.Repeat([]byte("f"), DefaultBlockSize+7)
+	sum := sha256.Sum256(want)
+	addr, _ := startTestServer(t, map[string][]byte{"firmware.bin": want})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+
+	dest := filepath.Join(t.TempDir(), "installed.bin")
+	var hookPath string
+	err := c.UpdateFirmware(context.Background(), FirmwareUpdate{
+		Filename: "firmware.bin",
+		Mode:     "octet",
+		SHA256:   hex.EncodeToString(sum[:]),
+		DestPath: dest,
+		PostInstall: func(destPath string) error {
+			hookPath = destPath
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("UpdateFirmware: %v", err)
+	}
+	if hookPath != dest {
+		t.Errorf("PostInstall destPath = %q, want %q", hookPath, dest)
+	}
+	got, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("installed %d bytes, want %d", len(got), len(want))
+	}
+}
+
+func TestClientUpdateFirmwareRejectsMismatch(t *testing.T) {
+	addr, _ := startTestServer(t, map[string][]byte{"firmware.bin": []byte("tampered")})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+
+	dest := filepath.Join(t.TempDir(), "installed.bin")
+	err := c.UpdateFirmware(context.Background(), FirmwareUpdate{
+		Filename: "firmware.bin",
+		Mode:     "octet",
+		SHA256:   hex.EncodeToString(make([]byte, sha256.Size)),
+		DestPath: dest,
+	})
+	if err == nil {
+		t.Fatal("UpdateFirmware: want an error on a checksum mismatch")
+	}
+	if _, statErr := os.Stat(dest); !os.IsNotExist(statErr) {
+		t.Fatal("UpdateFirmware: a failed download must not install anything")
+	}
+}
+
+func TestClientUpdateFirmwareLeavesInstallOnPostInstallFailure(t *testing.T) {
+	want := []byte("good firmware")
+	sum := sha256.Sum256(want)
+	addr, _ := startTestServer(t, map[string][]byte{"firmware.bin": want})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+
+	dest := filepath.Join(t.TempDir(), "installed.bin")
+	err := c.UpdateFirmware(context.Background(), FirmwareUpdate{
+		Filename: "firmware.bin",
+		Mode:     "octet",
+		SHA256:   hex.EncodeToString(sum[:]),
+		DestPath: dest,
+		PostInstall: func(destPath string) error {
+			return errors.New("flash failed")
+		},
+	})
+	if err == nil {
+		t.Fatal("UpdateFirmware: want the PostInstall error surfaced")
+	}
+	got, readErr := os.ReadFile(dest)
+	if readErr != nil {
+		t.Fatalf("ReadFile: %v", readErr)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatal("UpdateFirmware: firmware should stay installed even if PostInstall fails")
+	}
+}
@@ -0,0 +1,69 @@
+package tftp
+
+import (
+	"context"
+	"sync"
+)
+
+// FileRequest describes one file to fetch in a GetAll batch.
+type FileRequest struct {
+	Filename string
+	Mode     string
+}
+
+// FileResult is the outcome of one FileRequest within a GetAll batch,
+// returned in the same order the requests were given.
+type FileResult struct {
+	Filename string
+	Data     []byte
+	Err      error
+}
+
+// GetAll downloads each of requests concurrently, running up to
+// concurrency transfers at once (each over its own socket, same as any
+// other Get), and returns one FileResult per request in the same order
+// they were given. It's meant for provisioning flows that need several
+// related files — kernel, initrd, config — in one shot rather than one
+// at a time. A failure on one file is reported in its own FileResult
+// rather than aborting the rest of the batch. Canceling ctx stops every
+// in-flight transfer and fails any request not yet started with
+// ctx.Err().
+func (c *Client) GetAll(ctx context.Context, requests []FileRequest, concurrency int) []FileResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]FileResult, len(requests))
+	type job struct {
+		index int
+		req   FileRequest
+	}
+	jobs := make(chan job)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				data, err := c.getBytes(ctx, j.req.Filename, j.req.Mode)
+				results[j.index] = FileResult{Filename: j.req.Filename, Data: data, Err: err}
+			}
+		}()
+	}
+
+	for i, req := range requests {
+		if ctx.Err() != nil {
+			results[i] = FileResult{Filename: req.Filename, Err: ctx.Err()}
+			continue
+		}
+		select {
+		case jobs <- job{i, req}:
+		case <-ctx.Done():
+			results[i] = FileResult{Filename: req.Filename, Err: ctx.Err()}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
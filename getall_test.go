@@ -0,0 +1,89 @@
+package tftp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestClientGetAllFetchesEveryFile(t *testing.T) {
+	addr, _ := startTestServer(t, map[string][]byte{
+		"kernel": bytes.Repeat([]byte("k"), DefaultBlockSize+5),
+		"initrd": bytes.Repeat([]byte("i"), DefaultBlockSize*2),
+		"config": []byte("root=/dev/sda1"),
+	})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+
+	requests := []FileRequest{
+		{Filename: "kernel", Mode: "octet"},
+		{Filename: "initrd", Mode: "octet"},
+		{Filename: "config", Mode: "octet"},
+	}
+	results := c.GetAll(context.Background(), requests, 2)
+
+	if len(results) != len(requests) {
+		t.Fatalf("got %d results, want %d", len(results), len(requests))
+	}
+	for i, want := range requests {
+		got := results[i]
+		if got.Filename != want.Filename {
+			t.Errorf("results[%d].Filename = %q, want %q", i, got.Filename, want.Filename)
+		}
+		if got.Err != nil {
+			t.Errorf("results[%d].Err = %v, want nil", i, got.Err)
+		}
+	}
+	if !bytes.Equal(results[2].Data, []byte("root=/dev/sda1")) {
+		t.Errorf("config contents = %q, want %q", results[2].Data, "root=/dev/sda1")
+	}
+}
+
+func TestClientGetAllReportsPerFileErrors(t *testing.T) {
+	addr, _ := startTestServer(t, map[string][]byte{"present": []byte("ok")})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+
+	requests := []FileRequest{
+		{Filename: "present", Mode: "octet"},
+		{Filename: "missing", Mode: "octet"},
+	}
+	results := c.GetAll(context.Background(), requests, 2)
+
+	if results[0].Err != nil {
+		t.Errorf("present file Err = %v, want nil", results[0].Err)
+	}
+	if results[1].Err == nil {
+		t.Error("missing file Err = nil, want an error")
+	}
+}
+
+func TestClientGetAllRespectsCancellation(t *testing.T) {
+	// A listener that never answers.
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer conn.Close()
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = 50 * time.Millisecond
+	c.Retries = 100
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	requests := []FileRequest{{Filename: "a", Mode: "octet"}, {Filename: "b", Mode: "octet"}}
+	results := c.GetAll(ctx, requests, 1)
+
+	for i, res := range results {
+		if !errors.Is(res.Err, context.Canceled) {
+			t.Errorf("results[%d].Err = %v, want context.Canceled", i, res.Err)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+//go:build linux
+
+package tftp
+
+import (
+	"net"
+	"syscall"
+)
+
+// UDP_SEGMENT (see linux/udp.h) isn't exposed by the syscall package, so
+// the values are mirrored here.
+const (
+	solUDP     = 0x11 // SOL_UDP
+	udpSegment = 103  // UDP_SEGMENT
+)
+
+// batchSend hands an entire window of same-size DATA packets to the
+// kernel in a single sendto(2) call using UDP generic segmentation
+// offload, instead of one syscall per packet. It falls back to
+// sequential writes whenever GSO doesn't apply (a single-packet window,
+// non-uniform segment sizes, or a kernel/socket that rejects the
+// UDP_SEGMENT option).
+func batchSend(conn *net.UDPConn, addr *net.UDPAddr, packets [][]byte) error {
+	if len(packets) <= 1 {
+		return sendSequential(conn, addr, packets)
+	}
+
+	segSize := len(packets[0])
+	for _, p := range packets[:len(packets)-1] {
+		if len(p) != segSize {
+			return sendSequential(conn, addr, packets)
+		}
+	}
+	if len(packets[len(packets)-1]) > segSize {
+		return sendSequential(conn, addr, packets)
+	}
+
+	total := 0
+	for _, p := range packets {
+		total += len(p)
+	}
+	buf := make([]byte, 0, total)
+	for _, p := range packets {
+		buf = append(buf, p...)
+	}
+
+	rc, err := conn.SyscallConn()
+	if err != nil {
+		return sendSequential(conn, addr, packets)
+	}
+	var sockErr error
+	if ctrlErr := rc.Control(func(fd uintptr) {
+		sockErr = syscall.SetsockoptInt(int(fd), solUDP, udpSegment, segSize)
+	}); ctrlErr != nil || sockErr != nil {
+		return sendSequential(conn, addr, packets)
+	}
+
+	_, err = conn.WriteToUDP(buf, addr)
+
+	// Clear the option so a later single-packet write on this same
+	// session socket isn't unexpectedly segmented.
+	rc.Control(func(fd uintptr) {
+		syscall.SetsockoptInt(int(fd), solUDP, udpSegment, 0)
+	})
+
+	return err
+}
+
+func sendSequential(conn *net.UDPConn, addr *net.UDPAddr, packets [][]byte) error {
+	for _, p := range packets {
+		if _, err := conn.WriteToUDP(p, addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+//go:build !linux
+
+package tftp
+
+import "net"
+
+// batchSend writes each packet individually. UDP GSO is Linux-specific;
+// other platforms fall back to one syscall per packet.
+func batchSend(conn *net.UDPConn, addr *net.UDPAddr, packets [][]byte) error {
+	for _, p := range packets {
+		if _, err := conn.WriteToUDP(p, addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
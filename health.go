@@ -0,0 +1,46 @@
+package tftp
+
+import "net/http"
+
+// HealthChecker is an optional capability of Handler: if implemented,
+// Healthy is consulted by the /readyz handler registered via
+// Server.RegisterHealthHandlers, so a Handler backed by a flaky
+// datastore can report itself unready before a Kubernetes readiness
+// probe routes traffic to it.
+type HealthChecker interface {
+	Healthy() error
+}
+
+// RegisterHealthHandlers wires /healthz and /readyz onto mux for a
+// Kubernetes liveness/readiness probe: /healthz reports whether the
+// server's UDP socket is bound, and /readyz additionally consults
+// Handler's HealthChecker, if it implements one. Both endpoints respond
+// 200 with "ok" on success, or 503 with the failure reason. The server
+// itself never starts an HTTP listener; embedders plug this into
+// whatever *http.ServeMux already serves their process's other probes.
+func (s *Server) RegisterHealthHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", s.serveHealthz)
+	mux.HandleFunc("/readyz", s.serveReadyz)
+}
+
+func (s *Server) serveHealthz(w http.ResponseWriter, r *http.Request) {
+	if s.getConn() == nil {
+		http.Error(w, "tftp: socket not bound", http.StatusServiceUnavailable)
+		return
+	}
+	w.Write([]byte("ok"))
+}
+
+func (s *Server) serveReadyz(w http.ResponseWriter, r *http.Request) {
+	if s.getConn() == nil {
+		http.Error(w, "tftp: socket not bound", http.StatusServiceUnavailable)
+		return
+	}
+	if hc, ok := s.Handler.(HealthChecker); ok {
+		if err := hc.Healthy(); err != nil {
+			http.Error(w, "tftp: "+err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+	}
+	w.Write([]byte("ok"))
+}
@@ -0,0 +1,71 @@
+package tftp
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHealthzReflectsSocketBinding(t *testing.T) {
+	s := NewServer("", &memHandler{files: map[string][]byte{}})
+	mux := http.NewServeMux()
+	s.RegisterHealthHandlers(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("/healthz before Serve = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer conn.Close()
+	s.conn = conn
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("/healthz once bound = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+type fakeHealthChecker struct {
+	err error
+}
+
+func (h *fakeHealthChecker) ReadFile(name string) ([]byte, error) {
+	return nil, errors.New("not found")
+}
+func (h *fakeHealthChecker) WriteFile(name string, data []byte) error { return nil }
+func (h *fakeHealthChecker) Healthy() error                           { return h.err }
+
+func TestReadyzConsultsHealthChecker(t *testing.T) {
+	h := &fakeHealthChecker{}
+	s := NewServer("", h)
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer conn.Close()
+	s.conn = conn
+
+	mux := http.NewServeMux()
+	s.RegisterHealthHandlers(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("/readyz with a healthy backend = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	h.err = errors.New("database unreachable")
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("/readyz with an unhealthy backend = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
@@ -0,0 +1,54 @@
+//go:build linux
+
+package tftp
+
+import (
+	"errors"
+	"net"
+	"syscall"
+)
+
+// IP_RECVERR (see linux/in.h) isn't exposed by the syscall package, so
+// its value is mirrored here.
+const ipRecvErr = 11 // IP_RECVERR
+
+// errConnRefused replaces a timeout once the kernel has already told us
+// a request can never be answered: an ICMP port-unreachable, surfaced as
+// ECONNREFUSED on conn's error queue, means nobody is listening on the
+// destination port. There's no point burning through the rest of
+// Client.Retries waiting for a reply that will never arrive.
+var errConnRefused = errors.New("tftp: connection refused (ICMP port unreachable)")
+
+// enableICMPErrors asks the kernel to queue the ICMP error provoked by
+// any datagram conn sends, so a later call to connRefused can retrieve
+// it without ever connect(2)ing conn. TFTP's per-session ephemeral
+// server ports mean a reply is never expected to come from the address
+// the request was sent to, so connecting the socket would make the
+// kernel filter out a legitimate reply as if it were unsolicited.
+func enableICMPErrors(conn *net.UDPConn) {
+	rc, err := conn.SyscallConn()
+	if err != nil {
+		return
+	}
+	rc.Control(func(fd uintptr) {
+		syscall.SetsockoptInt(int(fd), syscall.SOL_IP, ipRecvErr, 1)
+	})
+}
+
+// connRefused reports whether conn's error queue holds a queued
+// ECONNREFUSED — the kernel's translation of an ICMP port-unreachable —
+// without touching conn's ordinary receive buffer: MSG_ERRQUEUE reads
+// from a queue entirely separate from the datagrams ReadFrom sees.
+func connRefused(conn *net.UDPConn) bool {
+	rc, err := conn.SyscallConn()
+	if err != nil {
+		return false
+	}
+	var refused bool
+	rc.Control(func(fd uintptr) {
+		buf := make([]byte, 512)
+		_, _, _, _, err := syscall.Recvmsg(int(fd), buf, nil, syscall.MSG_ERRQUEUE|syscall.MSG_DONTWAIT)
+		refused = errors.Is(err, syscall.ECONNREFUSED)
+	})
+	return refused
+}
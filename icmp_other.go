@@ -0,0 +1,21 @@
+//go:build !linux
+
+package tftp
+
+import (
+	"errors"
+	"net"
+)
+
+// errConnRefused exists on every platform so callers can reference it
+// unconditionally, but connRefused never returns true outside Linux, so
+// it's never actually returned there: the error-queue mechanism that
+// detects an ICMP port-unreachable without connect(2)ing the socket is
+// Linux-specific.
+var errConnRefused = errors.New("tftp: connection refused (ICMP port unreachable)")
+
+// enableICMPErrors is a no-op outside Linux.
+func enableICMPErrors(conn *net.UDPConn) {}
+
+// connRefused always reports false outside Linux.
+func connRefused(conn *net.UDPConn) bool { return false }
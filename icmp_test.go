@@ -0,0 +1,41 @@
+package tftp
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestClientFailsFastOnICMPPortUnreachable exercises connRefused end to
+// end: a closed UDP port should provoke a real ICMP port-unreachable,
+// which the client should surface as errConnRefused well before
+// Client.Retries would otherwise be exhausted. Outside Linux, and
+// inside any sandbox where loopback ICMP delivery is itself disabled,
+// connRefused never fires, so the client falls back to its ordinary
+// retry-and-give-up behavior — not a bug, just a best-effort
+// optimization going unused, which is why this test skips rather than
+// fails when that happens.
+func TestClientFailsFastOnICMPPortUnreachable(t *testing.T) {
+	closed, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	addr := closed.LocalAddr().String()
+	closed.Close()
+
+	c := NewClient(addr)
+	c.Timeout = 100 * time.Millisecond
+	c.Retries = 10
+
+	start := time.Now()
+	_, err = c.GetBytes("file.bin", "octet")
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, errConnRefused) {
+		t.Skipf("ICMP port-unreachable wasn't delivered to the client socket in this environment (err=%v, elapsed=%v); nothing to verify", err, elapsed)
+	}
+	if elapsed >= c.Timeout*time.Duration(c.Retries) {
+		t.Errorf("GetBytes took %v to report errConnRefused, want well under the full %d-retry timeout budget", elapsed, c.Retries)
+	}
+}
@@ -0,0 +1,95 @@
+package tftp
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestListenAndServeAllServesEveryListener(t *testing.T) {
+	want := bytes.Repeat([]byte("l"), DefaultBlockSize+3)
+	h := &memHandler{files: map[string][]byte{"file.bin": want}}
+
+	var mu sync.Mutex
+	var sessions []Session
+	s := NewServer("", h)
+	s.OnTransferComplete = func(sess Session, stats Stats, err error) {
+		mu.Lock()
+		sessions = append(sessions, sess)
+		mu.Unlock()
+	}
+
+	specs := []ListenSpec{
+		{Addr: "127.0.0.1:0", Label: "vlan10"},
+		{Addr: "127.0.0.1:0", Label: "vlan20"},
+	}
+	go s.ListenAndServeAll(specs...)
+	defer s.Close()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		s.connsMu.Lock()
+		n := len(s.conns)
+		s.connsMu.Unlock()
+		if n == len(specs) || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	s.connsMu.Lock()
+	if len(s.conns) != len(specs) {
+		s.connsMu.Unlock()
+		t.Fatalf("ListenAndServeAll bound %d listeners, want %d", len(s.conns), len(specs))
+	}
+	addrs := make([]string, len(s.conns))
+	for i, conn := range s.conns {
+		addrs[i] = conn.LocalAddr().String()
+	}
+	s.connsMu.Unlock()
+
+	for i, spec := range specs {
+		c := NewClient(addrs[i])
+		c.Timeout = time.Second
+		got, err := c.GetBytes("file.bin", "octet")
+		if err != nil {
+			t.Fatalf("GetBytes via %s: %v", spec.Label, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("GetBytes via %s returned %d bytes, want %d", spec.Label, len(got), len(want))
+		}
+	}
+
+	deadline = time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		n := len(sessions)
+		mu.Unlock()
+		if n >= len(specs) || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(sessions) != len(specs) {
+		t.Fatalf("OnTransferComplete fired %d times, want %d", len(sessions), len(specs))
+	}
+	labels := map[string]bool{}
+	for _, sess := range sessions {
+		labels[sess.Listener] = true
+	}
+	for _, spec := range specs {
+		if !labels[spec.Label] {
+			t.Errorf("session labels = %v, want %s present", labels, spec.Label)
+		}
+	}
+}
+
+func TestListenAndServeAllRequiresAtLeastOneSpec(t *testing.T) {
+	s := NewServer("", &memHandler{files: map[string][]byte{}})
+	if err := s.ListenAndServeAll(); err == nil {
+		t.Fatal("ListenAndServeAll(): want an error with no specs")
+	}
+}
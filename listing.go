@@ -0,0 +1,135 @@
+package tftp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+)
+
+// ListFilename is the private, non-standard filename an RRQ requests to
+// receive a directory listing instead of a real file. No RFC defines
+// directory listing for TFTP, so this is deliberately namespaced like
+// this package's other vendor extensions (see OptOffset) and, by
+// design, indistinguishable on the wire from an ordinary RRQ: a server
+// that doesn't support it, or whose Handler doesn't implement Lister,
+// just answers with ErrCodeNotFound like it would for any other
+// missing file.
+const ListFilename = "tftp-go-listing"
+
+// Lister is an optional capability of a Handler. When the configured
+// Handler implements it, the server answers an RRQ for ListFilename
+// with the listing encoded one filename per line, octet mode, instead
+// of treating it as a request for a real file — useful for checking
+// what a provisioning server is actually willing to serve without
+// guessing from its backing store.
+type Lister interface {
+	ListFiles() ([]string, error)
+}
+
+// ReaderAtHandler is an optional capability of a Handler. When the
+// configured Handler implements it, the server calls OpenReaderAt
+// instead of ReadFile to serve an RRQ, reading each block directly off
+// of the returned io.ReaderAt on demand instead of first loading the
+// whole file into memory — the only practical way to serve a multi-GB
+// image. size must be the exact number of bytes the RRQ should
+// transfer. If the returned io.ReaderAt is also an io.Closer, the
+// server closes it once the transfer finishes, successfully or not.
+type ReaderAtHandler interface {
+	OpenReaderAt(filename string) (r io.ReaderAt, size int64, err error)
+}
+
+// openBlockSource resolves filename to a blockSource for an RRQ:
+// PayloadProvider's stream when one is configured, ListFilename's
+// listing when that's what was requested, a ReaderAtHandler's on-demand
+// reader when the configured Handler supports one, or the Handler's
+// in-memory ReadFile result otherwise — falling back to relaying
+// filename from Upstream (see its doc comment) when Handler is unset or
+// ReadFile can't find it. It also returns the source's
+// total size in bytes and, when the source owns a resource that needs
+// releasing (e.g. an open file or PayloadProvider stream), an io.Closer
+// the caller must close once the transfer is done.
+//
+// wantCompress is whether the client negotiated OptCompress, and
+// wantEncrypt is whether it negotiated OptEncrypt; the returned
+// compressed/encrypted bools report whether this call actually served
+// gzip-compressed/AES-256-GCM-encrypted content, which is only possible
+// for the in-memory Handler.ReadFile path. Compressing or encrypting a
+// PayloadProvider/ReaderAtHandler stream, or the ListFilename listing,
+// would mean buffering it in full just to transform it, defeating the
+// point of those streaming paths, so wantCompress/wantEncrypt are
+// silently ignored for all of them; the caller is responsible for not
+// acknowledging OptCompress/OptEncrypt back to the client when
+// compressed/encrypted come back false. wantEncrypt is also ignored
+// when the Server has no EncryptionKey configured, since there's
+// nothing to encrypt with.
+func (s *Server) openBlockSource(filename string, blockSize int, clientAddr net.Addr, wantCompress, wantEncrypt bool) (blocks blockSource, size int64, closer io.Closer, compressed, encrypted bool, err error) {
+	if s.PayloadProvider != nil {
+		rc, size, err := s.PayloadProvider(context.Background(), filename, clientAddr)
+		if err != nil {
+			return nil, 0, nil, false, false, err
+		}
+		return newSequentialBlockSource(rc, size, blockSize), size, rc, false, false, nil
+	}
+	if filename == ListFilename {
+		lister, ok := s.Handler.(Lister)
+		if !ok {
+			return nil, 0, nil, false, false, fmt.Errorf("tftp: server does not support %s", ListFilename)
+		}
+		names, err := lister.ListFiles()
+		if err != nil {
+			return nil, 0, nil, false, false, err
+		}
+		data := []byte(strings.Join(names, "\n"))
+		return NewBlockIterator(data, blockSize), int64(len(data)), nil, false, false, nil
+	}
+	if rah, ok := s.Handler.(ReaderAtHandler); ok {
+		r, size, err := rah.OpenReaderAt(filename)
+		if err != nil {
+			return nil, 0, nil, false, false, err
+		}
+		closer, _ := r.(io.Closer)
+		var blocks blockSource = NewReaderAtBlockIterator(r, size, blockSize)
+		if s.PrefetchBlocks > 0 {
+			blocks = newPrefetchBlockSource(blocks, s.PrefetchBlocks)
+		}
+		return blocks, size, closer, false, false, nil
+	}
+	data, err := s.readBackend(filename)
+	if err != nil {
+		return nil, 0, nil, false, false, err
+	}
+	if wantCompress {
+		data, err = gzipCompress(data)
+		if err != nil {
+			return nil, 0, nil, false, false, err
+		}
+		compressed = true
+	}
+	if wantEncrypt && len(s.EncryptionKey) > 0 {
+		data, err = aesGCMEncrypt(s.EncryptionKey, data)
+		if err != nil {
+			return nil, 0, nil, false, false, err
+		}
+		encrypted = true
+	}
+	return NewBlockIterator(data, blockSize), int64(len(data)), nil, compressed, encrypted, nil
+}
+
+// ListFiles asks the server for its ListFiles listing by requesting
+// ListFilename like any other RRQ. A server whose Handler doesn't
+// implement Lister (or any server that isn't this package's) answers
+// with ErrCodeNotFound, which this returns unwrapped so the caller can
+// use errors.As to tell "not supported" apart from a real transport
+// failure.
+func (c *Client) ListFiles(ctx context.Context) ([]string, error) {
+	data, err := c.getBytes(ctx, ListFilename, "octet")
+	if err != nil {
+		return nil, fmt.Errorf("tftp: list files: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return strings.Split(string(data), "\n"), nil
+}
@@ -0,0 +1,65 @@
+package tftp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+type listingHandler struct {
+	memHandler
+	names []string
+}
+
+func (h *listingHandler) ListFiles() ([]string, error) {
+	return h.names, nil
+}
+
+func TestClientListFiles(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer conn.Close()
+
+	h := &listingHandler{names: []string{"kernel.bin", "initrd.img"}}
+	s := NewServer("", h)
+	s.conn = conn
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+
+	got, err := c.ListFiles(context.Background())
+	if err != nil {
+		t.Fatalf("ListFiles: %v", err)
+	}
+	want := []string{"kernel.bin", "initrd.img"}
+	if len(got) != len(want) {
+		t.Fatalf("ListFiles = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ListFiles[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestClientListFilesUnsupportedByServer(t *testing.T) {
+	addr, _ := startTestServer(t, map[string][]byte{"file.bin": []byte("data")})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+
+	if _, err := c.ListFiles(context.Background()); err == nil {
+		t.Fatal("expected ListFiles to fail against a Handler without Lister support")
+	} else {
+		var tftpErr *ERROR
+		if errors.As(err, &tftpErr) && tftpErr.Code != ErrCodeNotFound {
+			t.Errorf("Code = %v, want %v", tftpErr.Code, ErrCodeNotFound)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package tftp
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestClientGetUsesExplicitLocalAddr(t *testing.T) {
+	want := []byte("pinned source address")
+	addr, _ := startTestServer(t, map[string][]byte{"file.bin": want})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+	c.LocalAddr = "127.0.0.1:0"
+
+	got, err := c.GetBytes("file.bin", "octet")
+	if err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("GetBytes returned %q, want %q", got, want)
+	}
+}
+
+func TestClientGetRejectsUnresolvableLocalAddr(t *testing.T) {
+	c := NewClient("127.0.0.1:1")
+	c.LocalAddr = "not-a-valid-address"
+
+	if _, err := c.Get(context.Background(), "file.bin", "octet"); err == nil {
+		t.Fatal("expected an error resolving an invalid LocalAddr")
+	}
+}
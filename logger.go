@@ -0,0 +1,128 @@
+package tftp
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+)
+
+// Logger receives diagnostic output from Client and Server: retry
+// exhaustion, rejected requests, quota warnings, and similar events
+// worth observing but not worth returning as an error. It's deliberately
+// narrower than the stdlib log.Logger so embedders can adapt it to
+// whatever structured logging library they already use.
+type Logger interface {
+	Printf(format string, args ...any)
+}
+
+// noopLogger discards everything. It's the default Logger for both
+// Client and Server, so embedders aren't forced to wire one up just to
+// avoid stray output going to the process's default log destination.
+type noopLogger struct{}
+
+func (noopLogger) Printf(string, ...any) {}
+
+// DebugLogger is an optional, much chattier capability of a Logger.
+// When a Client or Server's configured Logger implements it, every
+// packet sent or received is also logged via Debugf with its opcode,
+// block number (for DATA/ACK), length, and a truncated hex dump — noisy
+// enough that it isn't part of the plain Logger interface, but
+// invaluable for tracing a malformed-packet dispute with a third-party
+// implementation down to the exact bytes that triggered it. A Logger
+// that doesn't implement DebugLogger just gets the ordinary, far
+// quieter Printf-level logging.
+type DebugLogger interface {
+	Logger
+	Debugf(format string, args ...any)
+}
+
+// debugLoggerOf returns l as a DebugLogger if it implements the
+// optional capability, or nil otherwise. l may itself be nil.
+func debugLoggerOf(l Logger) DebugLogger {
+	dbg, _ := l.(DebugLogger)
+	return dbg
+}
+
+// WarnLogger is an optional capability of a Logger for conditions more
+// severe than the common case Printf covers: a rejected request, a
+// retry budget exhausted, a transfer that failed partway through — the
+// kind of thing an operator wants to filter or alert on separately from
+// routine traffic. A Logger that doesn't implement WarnLogger just gets
+// these messages folded into the ordinary Printf level.
+type WarnLogger interface {
+	Logger
+	Warnf(format string, args ...any)
+}
+
+// warnf writes to l's Warnf if it implements WarnLogger, falling back
+// to Printf otherwise. l may be nil, in which case warnf is a no-op.
+func warnf(l Logger, format string, args ...any) {
+	if l == nil {
+		return
+	}
+	if w, ok := l.(WarnLogger); ok {
+		w.Warnf(format, args...)
+		return
+	}
+	l.Printf(format, args...)
+}
+
+// FieldLogger is an optional capability of a Logger that can attach
+// structured key/value pairs — a session ID, a client address — to the
+// messages it logs next, instead of having the caller interpolate them
+// into the format string. keyvals is an alternating key, value, key,
+// value... list, the same convention log/slog's With uses.
+type FieldLogger interface {
+	Logger
+	WithFields(keyvals ...any) Logger
+}
+
+// withFields returns l.WithFields(keyvals...) if l implements
+// FieldLogger, or l unchanged otherwise, so a call site can attach
+// fields unconditionally regardless of whether the configured Logger
+// opted into FieldLogger. l may be nil, in which case withFields
+// returns nil.
+func withFields(l Logger, keyvals ...any) Logger {
+	if l == nil {
+		return nil
+	}
+	if f, ok := l.(FieldLogger); ok {
+		return f.WithFields(keyvals...)
+	}
+	return l
+}
+
+// warnWithFields is warnf's counterpart for messages that should carry
+// structured fields: it attaches keyvals via withFields, then logs
+// through the result's WarnLogger capability if present, or Printf
+// otherwise. l may be nil, in which case warnWithFields is a no-op.
+func warnWithFields(l Logger, keyvals []any, format string, args ...any) {
+	warnf(withFields(l, keyvals...), format, args...)
+}
+
+// maxDebugDumpBytes caps how much of a packet debugPacket hex-dumps, so
+// a large blksize transfer doesn't flood the log with megabytes of hex
+// per packet.
+const maxDebugDumpBytes = 32
+
+// debugPacket logs one packet's opcode, block number, length, and a
+// truncated hex dump via dbg.Debugf. It is a no-op when dbg is nil, so
+// call sites can invoke it unconditionally regardless of whether the
+// configured Logger opted into DebugLogger.
+func debugPacket(dbg DebugLogger, direction string, addr net.Addr, b []byte) {
+	if dbg == nil {
+		return
+	}
+	block := ""
+	if op := opcodeOf(b); (op == OpDATA || op == OpACK) && len(b) >= 4 {
+		block = fmt.Sprintf(" block=%d", binary.BigEndian.Uint16(b[2:4]))
+	}
+	dump := b
+	truncated := ""
+	if len(dump) > maxDebugDumpBytes {
+		dump = dump[:maxDebugDumpBytes]
+		truncated = "..."
+	}
+	dbg.Debugf("tftp: %s %s opcode=%d%s len=%d data=%s%s", direction, addr, opcodeOf(b), block, len(b), hex.EncodeToString(dump), truncated)
+}
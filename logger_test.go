@@ -0,0 +1,214 @@
+package tftp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var errBadContent = errors.New("bad content")
+
+type recordingLogger struct {
+	mu   sync.Mutex
+	logs []string
+}
+
+func (r *recordingLogger) Printf(format string, args ...any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logs = append(r.logs, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingLogger) contains(substr string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, l := range r.logs {
+		if strings.Contains(l, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// recordingDebugLogger additionally implements DebugLogger, so tests can
+// assert that wire-level packet traces only appear when a Client or
+// Server's Logger opts into the richer interface.
+type recordingDebugLogger struct {
+	recordingLogger
+}
+
+func (r *recordingDebugLogger) Debugf(format string, args ...any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logs = append(r.logs, fmt.Sprintf(format, args...))
+}
+
+func TestDebugLoggerReceivesPacketTraces(t *testing.T) {
+	addr, _ := startTestServer(t, map[string][]byte{"file.bin": []byte("hello world")})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+	logger := &recordingDebugLogger{}
+	c.Logger = logger
+
+	if _, err := c.GetBytes("file.bin", "octet"); err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+
+	if !logger.contains("opcode=") {
+		t.Error("expected packet traces logged via Debugf")
+	}
+}
+
+// recordingWarnLogger additionally implements WarnLogger, so tests can
+// assert that warning-level messages route through Warnf when the
+// configured Logger opts into it, instead of falling back to Printf.
+type recordingWarnLogger struct {
+	recordingLogger
+	warnLogs []string
+}
+
+func (r *recordingWarnLogger) Warnf(format string, args ...any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.warnLogs = append(r.warnLogs, fmt.Sprintf(format, args...))
+}
+
+func TestWarnfUsesWarnLoggerWhenAvailable(t *testing.T) {
+	logger := &recordingWarnLogger{}
+	warnf(logger, "disk %s full", "sda1")
+
+	if len(logger.logs) != 0 {
+		t.Errorf("logs = %v, want nothing logged via Printf", logger.logs)
+	}
+	if len(logger.warnLogs) != 1 || logger.warnLogs[0] != "disk sda1 full" {
+		t.Errorf("warnLogs = %v, want [\"disk sda1 full\"]", logger.warnLogs)
+	}
+}
+
+func TestWarnfFallsBackToPrintf(t *testing.T) {
+	logger := &recordingLogger{}
+	warnf(logger, "disk %s full", "sda1")
+
+	if !logger.contains("disk sda1 full") {
+		t.Error("expected warnf to fall back to Printf when Logger doesn't implement WarnLogger")
+	}
+}
+
+func TestWarnfNilLoggerIsNoOp(t *testing.T) {
+	warnf(nil, "should not panic")
+}
+
+// recordingFieldLogger additionally implements FieldLogger, returning a
+// child recordingLogger that prefixes its messages with the attached
+// fields, so tests can assert WithFields is actually used rather than
+// ignored.
+type recordingFieldLogger struct {
+	*recordingLogger
+	fields string
+}
+
+func (r *recordingFieldLogger) WithFields(keyvals ...any) Logger {
+	return &recordingFieldLogger{recordingLogger: r.recordingLogger, fields: fmt.Sprint(keyvals...)}
+}
+
+func (r *recordingFieldLogger) Printf(format string, args ...any) {
+	r.recordingLogger.Printf(r.fields+" "+format, args...)
+}
+
+func TestWithFieldsAttachesFields(t *testing.T) {
+	logger := &recordingFieldLogger{recordingLogger: &recordingLogger{}}
+	withFields(logger, "client", "10.0.0.1").Printf("hello")
+
+	if !logger.contains("10.0.0.1") {
+		t.Errorf("logs = %v, want the attached field present", logger.logs)
+	}
+}
+
+func TestWithFieldsFallsBackWhenUnsupported(t *testing.T) {
+	logger := &recordingLogger{}
+	if got := withFields(logger, "client", "10.0.0.1"); got != logger {
+		t.Errorf("withFields = %v, want the original Logger unchanged", got)
+	}
+}
+
+func TestWithFieldsNilLoggerReturnsNil(t *testing.T) {
+	if got := withFields(nil, "k", "v"); got != nil {
+		t.Errorf("withFields(nil, ...) = %v, want nil", got)
+	}
+}
+
+func TestPlainLoggerGetsNoPacketTraces(t *testing.T) {
+	addr, _ := startTestServer(t, map[string][]byte{"file.bin": []byte("hello world")})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+	logger := &recordingLogger{}
+	c.Logger = logger
+
+	if _, err := c.GetBytes("file.bin", "octet"); err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+
+	if logger.contains("opcode=") {
+		t.Error("expected no packet traces from a Logger that doesn't implement DebugLogger")
+	}
+}
+
+func TestServerLogsRejectedRequest(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer conn.Close()
+
+	h := &memHandler{files: map[string][]byte{"file.bin": []byte("data")}}
+	s := NewServer("", h)
+	s.conn = conn
+	logger := &recordingLogger{}
+	s.Logger = logger
+	s.Validators = []Validator{
+		func(sess *Session) *Rejection {
+			return &Rejection{Code: ErrCodeAccessViolation, Message: "denied for test"}
+		},
+	}
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	if _, err := c.GetBytes("file.bin", "octet"); err == nil {
+		t.Fatal("expected the validator rejection to fail the transfer")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for !logger.contains("denied for test") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !logger.contains("denied for test") {
+		t.Error("expected the rejection message to be logged via Server.Logger")
+	}
+}
+
+func TestClientLogsValidationFailure(t *testing.T) {
+	addr, _ := startTestServer(t, map[string][]byte{"file.bin": []byte("unexpected contents")})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+	logger := &recordingLogger{}
+	c.Logger = logger
+	c.Validators = []ContentValidator{
+		func(data []byte) error { return errBadContent },
+	}
+
+	if _, err := c.GetBytes("file.bin", "octet"); err == nil {
+		t.Fatal("expected content validation to fail")
+	}
+	if !logger.contains("file.bin") {
+		t.Error("expected the validation failure to be logged via Client.Logger")
+	}
+}
@@ -0,0 +1,99 @@
+package tftp
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// defaultLogSampleWindow and defaultLogSampleBurst are the sampling
+// parameters logSampler falls back to when Server.LogSampleWindow or
+// Server.LogSampleBurst is left zero.
+const (
+	defaultLogSampleWindow = 10 * time.Second
+	defaultLogSampleBurst  = 3
+)
+
+// logSampler suppresses repetitive warnf lines that share a key (e.g. a
+// client address paired with the reason it was rejected) so a scan
+// hammering port 69 can't blow up log volume the way one line per
+// datagram would. The first burst occurrences of a key within window
+// are logged in full; the rest are only counted, until a summary line
+// reports how many were suppressed, either lazily (the first occurrence
+// after the window elapses triggers it) or via flush, for a key that
+// goes quiet before logging anything else.
+type logSampler struct {
+	window time.Duration
+	burst  int
+
+	mu      sync.Mutex
+	entries map[string]*logSampleEntry
+}
+
+type logSampleEntry struct {
+	windowStart time.Time
+	count       int
+}
+
+// newLogSampler returns a logSampler with the given window and burst,
+// substituting defaultLogSampleWindow/defaultLogSampleBurst for either
+// that's left zero or negative.
+func newLogSampler(window time.Duration, burst int) *logSampler {
+	if window <= 0 {
+		window = defaultLogSampleWindow
+	}
+	if burst <= 0 {
+		burst = defaultLogSampleBurst
+	}
+	return &logSampler{window: window, burst: burst, entries: make(map[string]*logSampleEntry)}
+}
+
+// allow reports whether the caller should log this occurrence of key in
+// full. If key's previous window closed having gone over burst, allow
+// also returns a summary line the caller should log first, reporting
+// how many occurrences that window suppressed.
+func (ls *logSampler) allow(key string) (ok bool, summary string) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	now := time.Now()
+	e, seen := ls.entries[key]
+	if !seen || now.Sub(e.windowStart) >= ls.window {
+		if seen && e.count > ls.burst {
+			summary = ls.summaryLine(key, e)
+		}
+		e = &logSampleEntry{windowStart: now}
+		ls.entries[key] = e
+	}
+	e.count++
+	return e.count <= ls.burst, summary
+}
+
+// flush reports a summary line, via warnf, for every key whose window
+// has elapsed with more occurrences than burst allowed through, then
+// forgets those keys. It's meant to be called periodically so a key
+// that stops recurring still gets its summary instead of waiting
+// indefinitely on a next occurrence that never comes.
+func (ls *logSampler) flush(warnf func(string, ...any)) {
+	ls.mu.Lock()
+	now := time.Now()
+	var summaries []string
+	for key, e := range ls.entries {
+		if now.Sub(e.windowStart) < ls.window {
+			continue
+		}
+		if e.count > ls.burst {
+			summaries = append(summaries, ls.summaryLine(key, e))
+		}
+		delete(ls.entries, key)
+	}
+	ls.mu.Unlock()
+
+	for _, summary := range summaries {
+		warnf(summary)
+	}
+}
+
+func (ls *logSampler) summaryLine(key string, e *logSampleEntry) string {
+	return fmt.Sprintf("tftp: suppressed %d more %q log lines in the last %s", e.count-ls.burst, key, ls.window)
+}
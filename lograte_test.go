@@ -0,0 +1,134 @@
+package tftp
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLogSamplerAllowsBurstThenSuppresses(t *testing.T) {
+	ls := newLogSampler(time.Minute, 3)
+
+	var allowed int
+	for i := 0; i < 10; i++ {
+		ok, summary := ls.allow("k")
+		if summary != "" {
+			t.Errorf("allow(%d): unexpected summary %q before the window elapsed", i, summary)
+		}
+		if ok {
+			allowed++
+		}
+	}
+	if allowed != 3 {
+		t.Errorf("allowed %d of 10 occurrences, want exactly burst (3)", allowed)
+	}
+}
+
+func TestLogSamplerEmitsSummaryOnNextOccurrenceAfterWindow(t *testing.T) {
+	ls := newLogSampler(10*time.Millisecond, 1)
+
+	if ok, summary := ls.allow("k"); !ok || summary != "" {
+		t.Fatalf("first allow(k) = (%v, %q), want (true, \"\")", ok, summary)
+	}
+	for i := 0; i < 4; i++ {
+		ls.allow("k")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	ok, summary := ls.allow("k")
+	if !ok {
+		t.Error("allow(k) after window elapsed = false, want true (burst resets)")
+	}
+	if !strings.Contains(summary, "suppressed 4") {
+		t.Errorf("summary = %q, want it to report 4 suppressed occurrences", summary)
+	}
+}
+
+func TestLogSamplerFlushReportsQuietKeys(t *testing.T) {
+	ls := newLogSampler(10*time.Millisecond, 1)
+	for i := 0; i < 3; i++ {
+		ls.allow("k")
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	var mu sync.Mutex
+	var got []string
+	ls.flush(func(format string, args ...any) {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, format)
+	})
+
+	if len(got) != 1 || !strings.Contains(got[0], "suppressed 2") {
+		t.Errorf("flush warned %v, want one summary reporting 2 suppressed occurrences", got)
+	}
+
+	got = nil
+	ls.flush(func(format string, args ...any) {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, format)
+	})
+	if len(got) != 0 {
+		t.Errorf("flush warned %v after already reporting, want none", got)
+	}
+}
+
+func TestServerSamplesMalformedPacketWarnings(t *testing.T) {
+	var mu sync.Mutex
+	var lines []string
+	logger := &testLogger{fn: func(s string) {
+		mu.Lock()
+		defer mu.Unlock()
+		lines = append(lines, s)
+	}}
+
+	s := NewServer("", &memHandler{files: map[string][]byte{}})
+	s.Logger = logger
+	s.LogSampleWindow = time.Hour
+	s.LogSampleBurst = 2
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s.conn = conn
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	client, err := net.DialUDP("udp", nil, conn.LocalAddr().(*net.UDPAddr))
+	if err != nil {
+		t.Fatalf("DialUDP: %v", err)
+	}
+	defer client.Close()
+
+	for i := 0; i < 10; i++ {
+		if _, err := client.Write([]byte{0xff, 0xff}); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	mu.Lock()
+	n := len(lines)
+	mu.Unlock()
+	if n == 0 {
+		t.Fatal("no malformed-packet lines logged at all")
+	}
+	if n >= 10 {
+		t.Errorf("logged %d lines for 10 malformed datagrams, want them sampled down well below that", n)
+	}
+}
+
+// testLogger is a Logger that hands every Printf line to fn, for
+// asserting on sampled log volume without depending on *log.Logger's
+// own formatting.
+type testLogger struct {
+	fn func(string)
+}
+
+func (l *testLogger) Printf(format string, args ...any) {
+	l.fn(format)
+}
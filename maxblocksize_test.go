@@ -0,0 +1,37 @@
+package tftp
+
+import "testing"
+
+func TestParseOptionsClampsBlockSizeToMaxBlockSize(t *testing.T) {
+	defer func(n int) { MaxBlockSize = n }(MaxBlockSize)
+	MaxBlockSize = 1024
+
+	nego, ack := parseOptions(Options{OptBlockSize: "4096"})
+	if nego.BlockSize != DefaultBlockSize {
+		t.Errorf("BlockSize = %d, want %d (request above MaxBlockSize ignored)", nego.BlockSize, DefaultBlockSize)
+	}
+	if _, ok := ack[OptBlockSize]; ok {
+		t.Error("ack should not echo a blksize request that exceeds MaxBlockSize")
+	}
+
+	nego, ack = parseOptions(Options{OptBlockSize: "1024"})
+	if nego.BlockSize != 1024 {
+		t.Errorf("BlockSize = %d, want 1024 (at the MaxBlockSize limit)", nego.BlockSize)
+	}
+	if ack[OptBlockSize] != "1024" {
+		t.Errorf("ack[blksize] = %q, want %q", ack[OptBlockSize], "1024")
+	}
+}
+
+func TestParseOptionsMaxBlockSizeDisabledByZero(t *testing.T) {
+	defer func(n int) { MaxBlockSize = n }(MaxBlockSize)
+	MaxBlockSize = 0
+
+	nego, ack := parseOptions(Options{OptBlockSize: "65464"})
+	if nego.BlockSize != 65464 {
+		t.Errorf("BlockSize = %d, want 65464 (the protocol maximum, with MaxBlockSize disabled)", nego.BlockSize)
+	}
+	if ack[OptBlockSize] != "65464" {
+		t.Errorf("ack[blksize] = %q, want %q", ack[OptBlockSize], "65464")
+	}
+}
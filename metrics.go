@@ -0,0 +1,75 @@
+package tftp
+
+import (
+	"expvar"
+	"strconv"
+)
+
+// ServerMetrics holds cumulative counters for a Server, published via
+// expvar so an existing expvar scraper (anything hitting a process's
+// /debug/vars endpoint) picks up TFTP traffic without this package
+// pulling in a metrics client. All counters are cumulative across the
+// Server's lifetime except ActiveSessions, which is a gauge.
+type ServerMetrics struct {
+	Requests       *expvar.Int
+	ActiveSessions *expvar.Int
+	BytesSent      *expvar.Int
+	BytesReceived  *expvar.Int
+	ErrorsByCode   *expvar.Map
+}
+
+// NewServerMetrics creates a ServerMetrics and publishes each counter
+// under expvar using prefix to namespace the variable names, e.g.
+// prefix "tftp" publishes "tftp_requests_total", "tftp_active_sessions",
+// and so on. Assign the result to Server.Metrics to opt a Server into
+// publication; a Server with Metrics left nil (the default) doesn't
+// touch expvar at all. As with any other expvar.Publish, creating two
+// ServerMetrics with the same prefix panics, since expvar variables
+// can't be unregistered.
+func NewServerMetrics(prefix string) *ServerMetrics {
+	return &ServerMetrics{
+		Requests:       expvar.NewInt(prefix + "_requests_total"),
+		ActiveSessions: expvar.NewInt(prefix + "_active_sessions"),
+		BytesSent:      expvar.NewInt(prefix + "_bytes_sent_total"),
+		BytesReceived:  expvar.NewInt(prefix + "_bytes_received_total"),
+		ErrorsByCode:   expvar.NewMap(prefix + "_errors_total"),
+	}
+}
+
+// Each method below is a no-op on a nil *ServerMetrics, so Server can
+// call them unconditionally regardless of whether Metrics was set.
+
+func (m *ServerMetrics) addRequest() {
+	if m == nil {
+		return
+	}
+	m.Requests.Add(1)
+}
+
+func (m *ServerMetrics) addActiveSessions(delta int64) {
+	if m == nil {
+		return
+	}
+	m.ActiveSessions.Add(delta)
+}
+
+func (m *ServerMetrics) addBytesSent(n int64) {
+	if m == nil {
+		return
+	}
+	m.BytesSent.Add(n)
+}
+
+func (m *ServerMetrics) addBytesReceived(n int64) {
+	if m == nil {
+		return
+	}
+	m.BytesReceived.Add(n)
+}
+
+func (m *ServerMetrics) recordError(code ErrorCode) {
+	if m == nil {
+		return
+	}
+	m.ErrorsByCode.Add(strconv.Itoa(int(code)), 1)
+}
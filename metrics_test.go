@@ -0,0 +1,54 @@
+package tftp
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestServerMetricsCountRequestsAndBytes(t *testing.T) {
+	want := []byte("hello world")
+	h := &memHandler{files: map[string][]byte{"file.bin": want}}
+
+	s := NewServer("", h)
+	s.Metrics = NewServerMetrics("test_server_metrics_" + t.Name())
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s.conn = conn
+	go s.Serve(conn)
+	defer conn.Close()
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	if _, err := c.GetBytes("file.bin", "octet"); err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for s.Metrics.BytesSent.Value() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := s.Metrics.Requests.Value(); got != 1 {
+		t.Errorf("Requests = %d, want 1", got)
+	}
+	if got := s.Metrics.BytesSent.Value(); got != int64(len(want)) {
+		t.Errorf("BytesSent = %d, want %d", got, len(want))
+	}
+	if got := s.Metrics.ActiveSessions.Value(); got != 0 {
+		t.Errorf("ActiveSessions = %d, want 0 once the transfer is done", got)
+	}
+
+	if _, err := c.GetBytes("missing.bin", "octet"); err == nil {
+		t.Fatal("expected GetBytes for a missing file to fail")
+	}
+	deadline = time.Now().Add(time.Second)
+	for s.Metrics.ErrorsByCode.Get("1") == nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := s.Metrics.ErrorsByCode.Get("1"); got == nil {
+		t.Error("expected an errors_total entry for code 1 (not found)")
+	}
+}
@@ -0,0 +1,141 @@
+package tftp
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingConn wraps a net.PacketConn and counts the writes made through
+// it, the way a real PacketMiddleware might count bytes or packets for
+// metrics.
+type countingConn struct {
+	net.PacketConn
+	writes *atomic.Int32
+}
+
+func (c countingConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	c.writes.Add(1)
+	return c.PacketConn.WriteTo(b, addr)
+}
+
+func countingMiddleware(writes *atomic.Int32) PacketMiddleware {
+	return func(conn net.PacketConn) net.PacketConn {
+		return countingConn{PacketConn: conn, writes: writes}
+	}
+}
+
+func TestClientMiddlewareSeesEveryWrite(t *testing.T) {
+	want := bytes.Repeat([]byte("m"), DefaultBlockSize*2+10)
+	addr, _ := startTestServer(t, map[string][]byte{"file.bin": want})
+
+	var writes atomic.Int32
+	c := NewClient(addr)
+	c.Timeout = time.Second
+	c.Middleware = []PacketMiddleware{countingMiddleware(&writes)}
+
+	got, err := c.GetBytes("file.bin", "octet")
+	if err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("GetBytes returned %d bytes, want %d", len(got), len(want))
+	}
+	if writes.Load() == 0 {
+		t.Error("expected the middleware to observe at least one write")
+	}
+}
+
+// fakePacketConn is a net.PacketConn that does nothing, just enough to
+// sit at the bottom of an applyMiddleware chain in a test that never
+// touches the network.
+type fakePacketConn struct{}
+
+func (fakePacketConn) ReadFrom(b []byte) (int, net.Addr, error)     { return 0, nil, nil }
+func (fakePacketConn) WriteTo(b []byte, addr net.Addr) (int, error) { return 0, nil }
+func (fakePacketConn) Close() error                                 { return nil }
+func (fakePacketConn) LocalAddr() net.Addr                          { return nil }
+func (fakePacketConn) SetDeadline(t time.Time) error                { return nil }
+func (fakePacketConn) SetReadDeadline(t time.Time) error            { return nil }
+func (fakePacketConn) SetWriteDeadline(t time.Time) error           { return nil }
+
+// taggingConn appends name to writes on WriteTo (before delegating) and
+// to reads on ReadFrom (after delegating), so a chain of them records
+// the order each layer actually saw a write or read in.
+type taggingConn struct {
+	net.PacketConn
+	name   string
+	writes *[]string
+	reads  *[]string
+}
+
+func (c *taggingConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	*c.writes = append(*c.writes, c.name)
+	return c.PacketConn.WriteTo(b, addr)
+}
+
+func (c *taggingConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	n, addr, err := c.PacketConn.ReadFrom(b)
+	*c.reads = append(*c.reads, c.name)
+	return n, addr, err
+}
+
+func taggingMiddleware(name string, writes, reads *[]string) PacketMiddleware {
+	return func(conn net.PacketConn) net.PacketConn {
+		return &taggingConn{PacketConn: conn, name: name, writes: writes, reads: reads}
+	}
+}
+
+// TestApplyMiddlewareLastIsOutermost exercises applyMiddleware with two
+// middlewares and asserts the order documented on applyMiddleware: the
+// last element of mw ends up outermost, so it sees a write first and a
+// read last.
+func TestApplyMiddlewareLastIsOutermost(t *testing.T) {
+	var writes, reads []string
+	conn := applyMiddleware(fakePacketConn{}, []PacketMiddleware{
+		taggingMiddleware("a", &writes, &reads),
+		taggingMiddleware("b", &writes, &reads),
+	})
+
+	conn.WriteTo(nil, nil)
+	if want := []string{"b", "a"}; !reflect.DeepEqual(writes, want) {
+		t.Errorf("write order = %v, want %v (b outermost, sees the write first)", writes, want)
+	}
+
+	conn.ReadFrom(nil)
+	if want := []string{"a", "b"}; !reflect.DeepEqual(reads, want) {
+		t.Errorf("read order = %v, want %v (b outermost, sees the read last)", reads, want)
+	}
+}
+
+func TestServerMiddlewareSeesEveryWrite(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer conn.Close()
+
+	var writes atomic.Int32
+	h := &memHandler{files: map[string][]byte{}}
+	s := NewServer("", h)
+	s.conn = conn
+	s.Middleware = []PacketMiddleware{countingMiddleware(&writes)}
+	go s.Serve(conn)
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	data := bytes.Repeat([]byte("p"), DefaultBlockSize+5)
+	if _, err := c.Put(context.Background(), "up.bin", "octet", bytes.NewReader(data)); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if got, ok := h.get("up.bin"); !ok || !bytes.Equal(got, data) {
+		t.Fatalf("server stored %d bytes (ok=%v), want %d", len(got), ok, len(data))
+	}
+	if writes.Load() == 0 {
+		t.Error("expected the middleware to observe at least one write")
+	}
+}
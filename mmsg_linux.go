@@ -0,0 +1,110 @@
+//go:build linux && amd64
+
+package tftp
+
+import (
+	"net"
+	"syscall"
+	"unsafe"
+)
+
+// recvBatchSize bounds how many datagrams a single recvmmsg(2) call
+// pulls off the listening socket at once, so a burst of simultaneous
+// boot requests costs one syscall instead of dozens.
+const recvBatchSize = 32
+
+// mmsghdr mirrors struct mmsghdr from linux/socket.h, which the syscall
+// package doesn't expose: a msghdr plus the byte count the kernel filled
+// in for that message.
+type mmsghdr struct {
+	hdr syscall.Msghdr
+	len uint32
+}
+
+// recvBatch reads up to len(bufs) pending datagrams off conn using a
+// single recvmmsg(2) syscall instead of one recvfrom(2) per datagram,
+// which is what lets Serve's accept loop keep up when thousands of
+// clients request a boot file at once. It blocks until at least one
+// datagram has arrived, then returns immediately with whatever else was
+// already queued rather than waiting to fill every buffer. srcs[i] and
+// lens[i] are only valid for i < the returned count.
+func recvBatch(conn *net.UDPConn, bufs [][]byte, srcs []*net.UDPAddr, lens []int) (int, error) {
+	rc, err := conn.SyscallConn()
+	if err != nil {
+		return recvBatchFallback(conn, bufs, srcs, lens)
+	}
+
+	hdrs := make([]mmsghdr, len(bufs))
+	iovs := make([]syscall.Iovec, len(bufs))
+	names := make([]syscall.RawSockaddrInet6, len(bufs))
+	for i := range bufs {
+		iovs[i].Base = &bufs[i][0]
+		iovs[i].SetLen(len(bufs[i]))
+		hdrs[i].hdr.Iov = &iovs[i]
+		hdrs[i].hdr.Iovlen = 1
+		hdrs[i].hdr.Name = (*byte)(unsafe.Pointer(&names[i]))
+		hdrs[i].hdr.Namelen = uint32(unsafe.Sizeof(names[i]))
+	}
+
+	var n int
+	var callErr error
+	readErr := rc.Read(func(fd uintptr) bool {
+		r1, _, errno := syscall.Syscall6(syscall.SYS_RECVMMSG, fd,
+			uintptr(unsafe.Pointer(&hdrs[0])), uintptr(len(hdrs)), 0, 0, 0)
+		if errno == syscall.EAGAIN {
+			return false
+		}
+		if errno != 0 {
+			callErr = errno
+			return true
+		}
+		n = int(r1)
+		return true
+	})
+	if readErr != nil {
+		return recvBatchFallback(conn, bufs, srcs, lens)
+	}
+	if callErr != nil {
+		return 0, callErr
+	}
+
+	for i := 0; i < n; i++ {
+		srcs[i] = sockaddrInet6ToUDPAddr(&names[i])
+		lens[i] = int(hdrs[i].len)
+	}
+	return n, nil
+}
+
+// recvBatchFallback reads a single datagram the ordinary way, used when
+// the recvmmsg(2) fast path can't be taken, e.g. conn isn't backed by a
+// real file descriptor.
+func recvBatchFallback(conn *net.UDPConn, bufs [][]byte, srcs []*net.UDPAddr, lens []int) (int, error) {
+	n, addr, err := conn.ReadFromUDP(bufs[0])
+	if err != nil {
+		return 0, err
+	}
+	srcs[0] = addr
+	lens[0] = n
+	return 1, nil
+}
+
+// sockaddrInet6ToUDPAddr decodes a kernel-filled sockaddr_in6-sized
+// buffer, which recvmmsg always writes into regardless of the real
+// address family, since an IPv4 peer's sockaddr_in fits within it.
+func sockaddrInet6ToUDPAddr(raw *syscall.RawSockaddrInet6) *net.UDPAddr {
+	if raw.Family == syscall.AF_INET {
+		in4 := (*syscall.RawSockaddrInet4)(unsafe.Pointer(raw))
+		ip := make(net.IP, 4)
+		copy(ip, in4.Addr[:])
+		return &net.UDPAddr{IP: ip, Port: int(ntohs(in4.Port))}
+	}
+	ip := make(net.IP, 16)
+	copy(ip, raw.Addr[:])
+	return &net.UDPAddr{IP: ip, Port: int(ntohs(raw.Port))}
+}
+
+// ntohs converts a port straight out of a kernel sockaddr, always
+// big-endian on the wire, to the host byte order net.UDPAddr expects.
+func ntohs(v uint16) uint16 {
+	return v<<8 | v>>8
+}
@@ -0,0 +1,23 @@
+//go:build !(linux && amd64)
+
+package tftp
+
+import "net"
+
+// recvBatchSize is 1 on platforms without a recvmmsg(2) fast path, so
+// Serve's accept loop behaves exactly as it did before batching was
+// introduced.
+const recvBatchSize = 1
+
+// recvBatch reads a single datagram into bufs[0]. recvmmsg(2) is
+// Linux/amd64-specific; other platforms fall back to one syscall per
+// datagram.
+func recvBatch(conn *net.UDPConn, bufs [][]byte, srcs []*net.UDPAddr, lens []int) (int, error) {
+	n, addr, err := conn.ReadFromUDP(bufs[0])
+	if err != nil {
+		return 0, err
+	}
+	srcs[0] = addr
+	lens[0] = n
+	return 1, nil
+}
@@ -0,0 +1,103 @@
+package tftp
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestRecvBatchReadsMultiplePendingDatagrams(t *testing.T) {
+	receiver, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer receiver.Close()
+
+	senders := make([]*net.UDPConn, 3)
+	want := [][]byte{[]byte("one"), []byte("two"), []byte("three")}
+	for i, payload := range want {
+		sender, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		if err != nil {
+			t.Fatalf("ListenUDP: %v", err)
+		}
+		defer sender.Close()
+		senders[i] = sender
+		if _, err := sender.WriteToUDP(payload, receiver.LocalAddr().(*net.UDPAddr)); err != nil {
+			t.Fatalf("WriteToUDP: %v", err)
+		}
+	}
+
+	// Give the kernel a moment to queue all three datagrams before the
+	// single recvBatch call drains them.
+	time.Sleep(10 * time.Millisecond)
+
+	bufs := make([][]byte, recvBatchSize)
+	for i := range bufs {
+		bufs[i] = make([]byte, 512)
+	}
+	srcs := make([]*net.UDPAddr, recvBatchSize)
+	lens := make([]int, recvBatchSize)
+
+	receiver.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, err := recvBatch(receiver, bufs, srcs, lens)
+	if err != nil {
+		t.Fatalf("recvBatch: %v", err)
+	}
+	if n == 0 {
+		t.Fatal("recvBatch returned 0 datagrams")
+	}
+
+	got := make(map[string]bool)
+	for i := 0; i < n; i++ {
+		got[string(bufs[i][:lens[i]])] = true
+		if srcs[i] == nil || srcs[i].Port == 0 {
+			t.Errorf("srcs[%d] = %v, want a resolved sender address", i, srcs[i])
+		}
+	}
+
+	// The kernel may not have every datagram queued by the time the
+	// single recvBatch call runs, so drain the rest individually and
+	// confirm the union matches what was sent, rather than requiring all
+	// three in the first call.
+	for len(got) < len(want) {
+		receiver.SetReadDeadline(time.Now().Add(time.Second))
+		m, err := recvBatch(receiver, bufs, srcs, lens)
+		if err != nil {
+			t.Fatalf("recvBatch: %v", err)
+		}
+		for i := 0; i < m; i++ {
+			got[string(bufs[i][:lens[i]])] = true
+		}
+	}
+
+	for _, payload := range want {
+		if !got[string(payload)] {
+			t.Errorf("missing payload %q among received datagrams", payload)
+		}
+	}
+}
+
+func TestServerServesOverRecvBatch(t *testing.T) {
+	want := bytes.Repeat([]byte("m"), DefaultBlockSize*2)
+	h := &memHandler{files: map[string][]byte{"file.bin": want}}
+	s := NewServer("", h)
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s.conn = conn
+	go s.Serve(conn)
+	defer conn.Close()
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	got, err := c.GetBytes("file.bin", "octet")
+	if err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("downloaded content did not match")
+	}
+}
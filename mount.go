@@ -0,0 +1,69 @@
+package tftp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Mount pairs a filename prefix with the Handler that should serve
+// requests under it, one entry in a MountHandler's table.
+type Mount struct {
+	// Prefix is matched against the start of a requested filename. The
+	// empty string matches everything, so it's the natural place for a
+	// catch-all fallback mount.
+	Prefix string
+	// Handler serves requests whose filename starts with Prefix. The
+	// prefix itself is stripped before Handler ever sees the filename,
+	// so each backend can be written as if it owned the whole namespace
+	// under its own mount point.
+	Handler Handler
+}
+
+// MountHandler routes each request to the Handler of the first Mount
+// in its table whose Prefix matches, stripping that prefix before
+// delegating — the TFTP analogue of mounting several filesystems under
+// one tree, for fronting heterogeneous content sources (an embed.FS, an
+// S3 bucket, a local directory) behind a single Server.Handler. Mounts
+// are tried in order, so a longer, more specific Prefix should come
+// before a shorter or empty catch-all one, the same ordering
+// Server.FilenameRules requires of its own rules.
+type MountHandler struct {
+	Mounts []Mount
+}
+
+// NewMountHandler returns a MountHandler routing through mounts in
+// order.
+func NewMountHandler(mounts ...Mount) *MountHandler {
+	return &MountHandler{Mounts: mounts}
+}
+
+// match returns the Handler and prefix-stripped filename for the first
+// Mount whose Prefix matches filename, or a nil Handler if none do.
+func (m *MountHandler) match(filename string) (Handler, string) {
+	for _, mount := range m.Mounts {
+		if strings.HasPrefix(filename, mount.Prefix) {
+			return mount.Handler, filename[len(mount.Prefix):]
+		}
+	}
+	return nil, ""
+}
+
+// ReadFile implements Handler, routing filename to the matching
+// mount's ReadFile.
+func (m *MountHandler) ReadFile(filename string) ([]byte, error) {
+	h, rest := m.match(filename)
+	if h == nil {
+		return nil, fmt.Errorf("tftp: no mount matches %q", filename)
+	}
+	return h.ReadFile(rest)
+}
+
+// WriteFile implements Handler, routing filename to the matching
+// mount's WriteFile.
+func (m *MountHandler) WriteFile(filename string, data []byte) error {
+	h, rest := m.match(filename)
+	if h == nil {
+		return fmt.Errorf("tftp: no mount matches %q", filename)
+	}
+	return h.WriteFile(rest, data)
+}
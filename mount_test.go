@@ -0,0 +1,81 @@
+package tftp
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestMountHandlerRoutesByPrefix(t *testing.T) {
+	efi := &memHandler{files: map[string][]byte{"grub.cfg": []byte("efi grub")}}
+	images := &memHandler{files: map[string][]byte{"router.img": []byte("image bytes")}}
+	local := &memHandler{files: map[string][]byte{"notes.txt": []byte("local notes")}}
+
+	m := NewMountHandler(
+		Mount{Prefix: "/efi/", Handler: efi},
+		Mount{Prefix: "/images/", Handler: images},
+		Mount{Prefix: "", Handler: local},
+	)
+
+	cases := []struct {
+		filename string
+		want     string
+	}{
+		{"/efi/grub.cfg", "efi grub"},
+		{"/images/router.img", "image bytes"},
+		{"notes.txt", "local notes"},
+	}
+	for _, c := range cases {
+		got, err := m.ReadFile(c.filename)
+		if err != nil {
+			t.Fatalf("ReadFile(%q): %v", c.filename, err)
+		}
+		if string(got) != c.want {
+			t.Errorf("ReadFile(%q) = %q, want %q", c.filename, got, c.want)
+		}
+	}
+}
+
+func TestMountHandlerNoMatchErrors(t *testing.T) {
+	m := NewMountHandler(Mount{Prefix: "/efi/", Handler: &memHandler{}})
+	if _, err := m.ReadFile("/images/router.img"); err == nil {
+		t.Fatal("ReadFile: want an error when no mount matches")
+	}
+}
+
+func TestMountHandlerWriteFileRoutesAndStripsPrefix(t *testing.T) {
+	images := &memHandler{files: map[string][]byte{}}
+	m := NewMountHandler(Mount{Prefix: "/images/", Handler: images})
+
+	if err := m.WriteFile("/images/new.img", []byte("uploaded")); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	got, ok := images.get("new.img")
+	if !ok || string(got) != "uploaded" {
+		t.Fatalf("get(%q) = %q, %v, want %q, true", "new.img", got, ok, "uploaded")
+	}
+}
+
+func TestServerServesThroughMountHandler(t *testing.T) {
+	efi := &memHandler{files: map[string][]byte{"grub.cfg": []byte("efi grub")}}
+	m := NewMountHandler(Mount{Prefix: "/efi/", Handler: efi})
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s := NewServer("", m)
+	s.conn = conn
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	got, err := c.GetBytes("/efi/grub.cfg", "octet")
+	if err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+	if string(got) != "efi grub" {
+		t.Errorf("GetBytes = %q, want %q", got, "efi grub")
+	}
+}
@@ -0,0 +1,105 @@
+package tftp
+
+import (
+	"fmt"
+	"net"
+)
+
+// ipv4HeaderSize and ipv6HeaderSize are IP header sizes assuming no
+// options, the worst case this package can assume since it has no
+// portable way to know whether a given path actually carries any.
+const (
+	ipv4HeaderSize = 20
+	ipv6HeaderSize = 40
+	udpHeaderSize  = 8
+)
+
+// SafeBlockSize returns the largest OptBlockSize value whose resulting
+// DATA packets (see maxPacketSize) fit within mtu without IP
+// fragmentation, for a server reachable at addr. Many embedded TFTP
+// stacks silently drop a fragmented datagram instead of reassembling
+// it, so staying under the path's MTU matters more for interop than
+// squeezing out the last few bytes of throughput.
+//
+// This isn't RFC 1191 path MTU discovery: real path MTU discovery sends
+// probe datagrams with the IP "don't fragment" bit set and reacts to
+// the ICMP "fragmentation needed" replies that come back, which needs
+// raw socket access this package's pure net.UDPConn, zero-dependency
+// design has no portable way to get at (see ProbeInterfaceMTU for the
+// substitute this package uses instead). SafeBlockSize just converts an
+// MTU figure the caller already has — most commonly the local egress
+// interface's MTU — into a blksize, clamped to the range parseOptions
+// accepts.
+func SafeBlockSize(mtu int, addr net.Addr) int {
+	headerSize := ipv4HeaderSize
+	if isIPv6Addr(addr) {
+		headerSize = ipv6HeaderSize
+	}
+	size := mtu - headerSize - udpHeaderSize - tftpDataHeaderSize
+	if size < minBlockSize {
+		return minBlockSize
+	}
+	if size > maxBlockSize {
+		return maxBlockSize
+	}
+	return size
+}
+
+// tftpDataHeaderSize is the 2-byte opcode plus 2-byte block number a
+// DATA packet prepends to every block's payload; see maxPacketSize.
+const tftpDataHeaderSize = 4
+
+// isIPv6Addr reports whether addr's host is an IPv6 address. A nil or
+// unparseable addr is treated as IPv6, the larger of the two header
+// sizes, so a caller that can't determine the server's address family
+// gets the more conservative (smaller) blksize rather than risking
+// fragmentation.
+func isIPv6Addr(addr net.Addr) bool {
+	if addr == nil {
+		return true
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		host = addr.String()
+	}
+	ip := net.ParseIP(host)
+	return ip == nil || ip.To4() == nil
+}
+
+// ProbeInterfaceMTU reports the MTU of the local network interface a
+// packet to remoteAddr would egress from, by asking the OS to resolve
+// the route (net.Dial on a UDP socket does this without sending any
+// packets) and matching the resulting local address against
+// net.Interfaces(). This is the local, first-hop half of "path MTU": it
+// catches the common case of a constrained uplink (a narrowband radio
+// link, a VPN tunnel with a reduced MTU) but can't see a smaller MTU
+// further out on the path the way true path MTU discovery (see
+// SafeBlockSize's doc comment) would.
+func ProbeInterfaceMTU(remoteAddr string) (int, error) {
+	conn, err := net.Dial("udp", remoteAddr)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	local, ok := conn.LocalAddr().(*net.UDPAddr)
+	if !ok {
+		return 0, fmt.Errorf("tftp: unexpected local address type %T", conn.LocalAddr())
+	}
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return 0, err
+	}
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			ipNet, ok := a.(*net.IPNet)
+			if ok && ipNet.IP.Equal(local.IP) {
+				return iface.MTU, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("tftp: no local interface found with address %s", local.IP)
+}
@@ -0,0 +1,75 @@
+package tftp
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSafeBlockSizeAccountsForAddressFamily(t *testing.T) {
+	v4 := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 1), Port: 69}
+	v6 := &net.UDPAddr{IP: net.ParseIP("fe80::1"), Port: 69}
+
+	gotV4 := SafeBlockSize(1500, v4)
+	wantV4 := 1500 - ipv4HeaderSize - udpHeaderSize - tftpDataHeaderSize
+	if gotV4 != wantV4 {
+		t.Errorf("SafeBlockSize(1500, IPv4) = %d, want %d", gotV4, wantV4)
+	}
+
+	gotV6 := SafeBlockSize(1500, v6)
+	wantV6 := 1500 - ipv6HeaderSize - udpHeaderSize - tftpDataHeaderSize
+	if gotV6 != wantV6 {
+		t.Errorf("SafeBlockSize(1500, IPv6) = %d, want %d", gotV6, wantV6)
+	}
+	if gotV6 >= gotV4 {
+		t.Errorf("IPv6's larger header should yield a smaller blksize than IPv4's: got %d >= %d", gotV6, gotV4)
+	}
+}
+
+func TestSafeBlockSizeClampsToTheNegotiableRange(t *testing.T) {
+	if got := SafeBlockSize(10, nil); got != minBlockSize {
+		t.Errorf("SafeBlockSize(10, nil) = %d, want the floor %d", got, minBlockSize)
+	}
+	if got := SafeBlockSize(1<<20, nil); got != maxBlockSize {
+		t.Errorf("SafeBlockSize(1<<20, nil) = %d, want the ceiling %d", got, maxBlockSize)
+	}
+}
+
+func TestSafeBlockSizeTreatsUnknownAddressAsIPv6(t *testing.T) {
+	gotNil := SafeBlockSize(1500, nil)
+	gotV6 := SafeBlockSize(1500, &net.UDPAddr{IP: net.ParseIP("fe80::1"), Port: 69})
+	if gotNil != gotV6 {
+		t.Errorf("SafeBlockSize(1500, nil) = %d, want the conservative IPv6 result %d", gotNil, gotV6)
+	}
+}
+
+func TestProbeInterfaceMTUFindsTheLoopbackInterface(t *testing.T) {
+	mtu, err := ProbeInterfaceMTU("127.0.0.1:12345")
+	if err != nil {
+		t.Fatalf("ProbeInterfaceMTU: %v", err)
+	}
+	if mtu <= 0 {
+		t.Errorf("ProbeInterfaceMTU returned MTU = %d, want a positive value", mtu)
+	}
+}
+
+func TestClientGetUsesPathMTUOverBlockSize(t *testing.T) {
+	want := bytes.Repeat([]byte("m"), 100)
+	addr, _ := startTestServer(t, map[string][]byte{"file.bin": want})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+	c.BlockSize = 8
+	c.PathMTU = 1500
+
+	rc, nego, err := c.get(context.Background(), "file.bin", "octet", c.requestOptions("file.bin", 0))
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	defer rc.Close()
+	if nego.BlockSize == 8 {
+		t.Errorf("NegotiatedOptions.BlockSize = %d, want PathMTU's derived size rather than the unused BlockSize", nego.BlockSize)
+	}
+}
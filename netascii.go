@@ -0,0 +1,60 @@
+package tftp
+
+import "io"
+
+// netasciiDecoder translates an RFC 1350 netascii byte stream (lines
+// terminated by CRLF, with a bare CR only ever followed by LF or NUL)
+// into plain text with bare LF line endings, writing translated bytes
+// to the wrapped Writer as they arrive. State survives across Write
+// calls so a CR split across two DATA blocks still decodes correctly.
+type netasciiDecoder struct {
+	w      io.Writer
+	pendCR bool
+}
+
+func newNetasciiDecoder(w io.Writer) *netasciiDecoder {
+	return &netasciiDecoder{w: w}
+}
+
+func (d *netasciiDecoder) Write(p []byte) (int, error) {
+	out := make([]byte, 0, len(p))
+	for _, b := range p {
+		if d.pendCR {
+			d.pendCR = false
+			switch b {
+			case '\n':
+				out = append(out, '\n')
+				continue
+			case 0:
+				out = append(out, '\r')
+				continue
+			default:
+				// Not valid netascii (a bare CR must be followed by LF
+				// or NUL), but rather than drop data, emit the CR as
+				// literal and keep processing b normally below.
+				out = append(out, '\r')
+			}
+		}
+		if b == '\r' {
+			d.pendCR = true
+			continue
+		}
+		out = append(out, b)
+	}
+	if _, err := d.w.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush emits a trailing bare CR left pending at the end of the stream,
+// which would otherwise be silently dropped if the transfer ends before
+// its following LF or NUL arrives.
+func (d *netasciiDecoder) Flush() error {
+	if !d.pendCR {
+		return nil
+	}
+	d.pendCR = false
+	_, err := d.w.Write([]byte{'\r'})
+	return err
+}
@@ -0,0 +1,60 @@
+package tftp
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNetasciiDecoderTranslatesCRLF(t *testing.T) {
+	var buf bytes.Buffer
+	d := newNetasciiDecoder(&buf)
+
+	if _, err := d.Write([]byte("line one\r\nline two\r\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if got, want := buf.String(), "line one\nline two\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestNetasciiDecoderCRNulIsLiteralCR(t *testing.T) {
+	var buf bytes.Buffer
+	d := newNetasciiDecoder(&buf)
+
+	if _, err := d.Write([]byte("a\r\x00b")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if got, want := buf.String(), "a\rb"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestNetasciiDecoderSplitAcrossWrites(t *testing.T) {
+	var buf bytes.Buffer
+	d := newNetasciiDecoder(&buf)
+
+	if _, err := d.Write([]byte("line one\r")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if _, err := d.Write([]byte("\nline two")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if got, want := buf.String(), "line one\nline two"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestNetasciiDecoderFlushesTrailingCR(t *testing.T) {
+	var buf bytes.Buffer
+	d := newNetasciiDecoder(&buf)
+
+	if _, err := d.Write([]byte("abc\r")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := d.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if got, want := buf.String(), "abc\r"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,32 @@
+package tftp
+
+// OpCode identifies the type of a TFTP packet, as defined in RFC 1350.
+type OpCode uint16
+
+const (
+	OpRRQ   OpCode = 1
+	OpWRQ   OpCode = 2
+	OpDATA  OpCode = 3
+	OpACK   OpCode = 4
+	OpERROR OpCode = 5
+	OpOACK  OpCode = 6
+)
+
+func (op OpCode) String() string {
+	switch op {
+	case OpRRQ:
+		return "RRQ"
+	case OpWRQ:
+		return "WRQ"
+	case OpDATA:
+		return "DATA"
+	case OpACK:
+		return "ACK"
+	case OpERROR:
+		return "ERROR"
+	case OpOACK:
+		return "OACK"
+	default:
+		return "UNKNOWN"
+	}
+}
@@ -0,0 +1,169 @@
+package tftp
+
+import "strconv"
+
+// NegotiatedOptions holds the parsed, validated values of the options
+// extension (RFC 2347/2348/2349/7440) for a single transfer.
+type NegotiatedOptions struct {
+	BlockSize      int
+	TimeoutSeconds int
+	TransferSize   int64
+	WindowSize     int
+
+	// Offset is the byte offset, aligned down to a block boundary, at
+	// which this download's DATA stream actually begins. It is nonzero
+	// only when OptOffset was negotiated; see OptOffset.
+	Offset int64
+
+	// Compressed reports whether OptCompress was negotiated: the DATA
+	// stream carries gzip-compressed content rather than the file's raw
+	// bytes. See OptCompress.
+	Compressed bool
+
+	// Encrypted reports whether OptEncrypt was negotiated: the DATA
+	// stream carries AES-256-GCM-encrypted content rather than the
+	// file's raw (or, if Compressed is also set, compressed) bytes. See
+	// OptEncrypt.
+	Encrypted bool
+}
+
+// OptCompress is a private, vendor-prefixed option (RFC 2347 doesn't
+// standardize one) that asks the DATA stream to carry the file's
+// content gzip-compressed instead of raw, for text-heavy content (boot
+// configs, firmware manifests) over slow serial-backed links. The only
+// value this package recognizes is "gzip"; a server that doesn't
+// understand the option, or that can't honor it for the request it's
+// serving (see openBlockSource), simply omits it from the OACK per the
+// usual unknown-option rule, and a client must check
+// NegotiatedOptions.Compressed rather than assume it was honored.
+const OptCompress = "tftp-go-xcompress"
+
+// optCompressGzip is the only value OptCompress currently accepts.
+const optCompressGzip = "gzip"
+
+// OptEncrypt is a private, vendor-prefixed option (RFC 2347 doesn't
+// standardize one) that asks the DATA stream to carry the file's
+// content encrypted instead of cleartext, for a closed deployment where
+// both ends run this package and some served images contain secrets
+// that plain TFTP would otherwise put on the wire unprotected. The
+// request that prompted this named XChaCha20-Poly1305; this package has
+// no third-party dependencies (see go.mod), and that construction isn't
+// in the standard library, so this uses AES-256-GCM from crypto/aes and
+// crypto/cipher instead — a stdlib-only AEAD with the same
+// confidentiality/integrity properties for this use case. The only
+// value this package recognizes is "aes-256-gcm"; a server that doesn't
+// understand the option, or that has no EncryptionKey configured, omits
+// it from the OACK per the usual unknown-option rule, and a client must
+// check NegotiatedOptions.Encrypted rather than assume it was honored.
+const OptEncrypt = "tftp-go-xencrypt"
+
+// optEncryptAES256GCM is the only value OptEncrypt currently accepts.
+const optEncryptAES256GCM = "aes-256-gcm"
+
+// OptOffset is a private, vendor-prefixed option (RFC 2347 doesn't
+// standardize one) recognized only by this package's Server: it asks a
+// download to resume at a byte offset instead of starting over from
+// block 1, for continuing an interrupted transfer of a large file over
+// a flaky link. A server that doesn't understand it simply ignores it,
+// per the usual unknown-option rule, and serves the file from the
+// start, so a client must check NegotiatedOptions.Offset rather than
+// assume the resume was honored.
+const OptOffset = "tftp-go-offset"
+
+// DefaultWindowSize is the window size used when no windowsize option is
+// negotiated, per RFC 7440.
+const DefaultWindowSize = 1
+
+// StrictRequestParsing rejects RRQ/WRQ packets that carry trailing data
+// after the last recognized field instead of capturing it in Trailing.
+// Leave this false (the default) when serving devices whose firmware is
+// known to append extra NUL-separated junk after the mode field.
+var StrictRequestParsing = false
+
+// MaxFilenameLength, MaxModeLength, and MaxOptionCount/MaxOptionSize
+// bound how large the respective fields of an RRQ/WRQ are allowed to be
+// during unmarshaling, so a hostile or malfunctioning peer on an
+// exposed network can't make the server hold onto an arbitrarily large
+// filename or mode string, or a request stuffed with an unbounded
+// number of options. A request exceeding any of these is rejected with
+// ErrCodeIllegalOp instead of being parsed. Set the corresponding limit
+// to 0 to disable it.
+var (
+	MaxFilenameLength = 512
+	MaxModeLength     = 32
+	MaxOptionCount    = 16
+	MaxOptionSize     = 256
+)
+
+// MaxBlockSize additionally bounds the blksize option (RFC 2348) beyond
+// the protocol's own 65464-byte ceiling, so an operator can hold
+// negotiated block sizes to whatever their link's MTU/fragmentation
+// story can actually sustain. A requested value above it is treated the
+// same as one outside the protocol's own range: the option is dropped
+// from the OACK and DefaultBlockSize is used instead. Zero (the
+// default) means no additional limit beyond the protocol maximum.
+var MaxBlockSize = 0
+
+const (
+	minBlockSize  = 8
+	maxBlockSize  = 65464
+	minWindowSize = 1
+	maxWindowSize = 65535
+)
+
+// parseOptions validates the subset of opts this package understands and
+// returns the values that should be echoed back in an OACK. Unknown
+// options are ignored, per RFC 2347.
+func parseOptions(opts Options) (NegotiatedOptions, Options) {
+	nego := NegotiatedOptions{
+		BlockSize:  DefaultBlockSize,
+		WindowSize: DefaultWindowSize,
+	}
+	ack := Options{}
+	if v, ok := opts[OptBlockSize]; ok {
+		limit := maxBlockSize
+		if MaxBlockSize > 0 && MaxBlockSize < limit {
+			limit = MaxBlockSize
+		}
+		if n, err := strconv.Atoi(v); err == nil && n >= minBlockSize && n <= limit {
+			nego.BlockSize = n
+			ack[OptBlockSize] = v
+		}
+	}
+	if v, ok := opts[OptTimeout]; ok {
+		if n, err := strconv.Atoi(v); err == nil && n >= 1 && n <= 255 {
+			nego.TimeoutSeconds = n
+			ack[OptTimeout] = v
+		}
+	}
+	if v, ok := opts[OptTransferSize]; ok {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n >= 0 {
+			nego.TransferSize = n
+			ack[OptTransferSize] = v
+		}
+	}
+	if v, ok := opts[OptWindowSize]; ok {
+		if n, err := strconv.Atoi(v); err == nil && n >= minWindowSize && n <= maxWindowSize {
+			nego.WindowSize = n
+			ack[OptWindowSize] = v
+		}
+	}
+	if v, ok := opts[OptOffset]; ok {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n >= 0 {
+			nego.Offset = n
+			ack[OptOffset] = v
+		}
+	}
+	if v, ok := opts[OptCompress]; ok && v == optCompressGzip {
+		nego.Compressed = true
+		ack[OptCompress] = v
+	}
+	if v, ok := opts[OptEncrypt]; ok && v == optEncryptAES256GCM {
+		nego.Encrypted = true
+		ack[OptEncrypt] = v
+	}
+	if len(ack) == 0 {
+		return nego, nil
+	}
+	return nego, ack
+}
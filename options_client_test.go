@@ -0,0 +1,85 @@
+package tftp
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestClientGetNegotiatesBlockSize(t *testing.T) {
+	want := bytes.Repeat([]byte("d"), 3000)
+	addr, _ := startTestServer(t, map[string][]byte{"file.bin": want})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+	c.BlockSize = 1024
+
+	var lastReceived int64
+	c.ProgressFunc = func(received, total int64) {
+		lastReceived = received
+		if total != int64(len(want)) {
+			t.Errorf("total = %d, want %d", total, len(want))
+		}
+	}
+
+	got, err := c.GetBytes("file.bin", "octet")
+	if err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("GetBytes returned %d bytes, want %d", len(got), len(want))
+	}
+	if lastReceived != int64(len(want)) {
+		t.Errorf("final progress = %d, want %d", lastReceived, len(want))
+	}
+}
+
+func TestClientPutNegotiatesOptions(t *testing.T) {
+	addr, h := startTestServer(t, map[string][]byte{})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+	c.BlockSize = 1024
+	want := bytes.Repeat([]byte("e"), 3000)
+
+	stats, err := c.Put(context.Background(), "upload.bin", "octet", bytes.NewReader(want))
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if stats.BlockSize != 1024 {
+		t.Errorf("Stats.BlockSize = %d, want 1024 (negotiated)", stats.BlockSize)
+	}
+
+	var got []byte
+	var ok bool
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if got, ok = h.get("upload.bin"); ok {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !ok {
+		t.Fatal("server never recorded the uploaded file")
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("uploaded %d bytes, want %d", len(got), len(want))
+	}
+}
+
+func TestClientGetFallsBackWithoutOptions(t *testing.T) {
+	want := bytes.Repeat([]byte("f"), DefaultBlockSize+5)
+	addr, _ := startTestServer(t, map[string][]byte{"file.bin": want})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+
+	got, err := c.GetBytes("file.bin", "octet")
+	if err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("GetBytes returned %d bytes, want %d", len(got), len(want))
+	}
+}
@@ -0,0 +1,470 @@
+// Package tftp implements a TFTP (RFC 1350) client and server, including
+// the option extension negotiation mechanism of RFC 2347 and the blksize,
+// timeout, tsize (RFC 2348/2349) and windowsize (RFC 7440) options.
+package tftp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultBlockSize is the block size used when no blksize option is
+// negotiated, as specified by RFC 1350.
+const DefaultBlockSize = 512
+
+// ErrWrongOpcode is returned (wrapped) by a Packet's UnmarshalBinary or
+// Decode when the wire data's opcode doesn't match that Packet's own
+// type. Distinguishing this from ErrMalformedPacket lets a caller that
+// tries decoding into several types in turn (ParsePacket does this via
+// opcodeOf instead) tell "this wasn't an ACK" apart from "this was an
+// ACK, but a corrupt one" — the former means try another type, the
+// latter means give up and report the packet as bad.
+var ErrWrongOpcode = errors.New("tftp: wrong opcode for packet type")
+
+// ErrMalformedPacket is returned (wrapped) by a Packet's UnmarshalBinary
+// or Decode when the wire data has the right opcode but is too short or
+// otherwise structurally invalid to decode.
+var ErrMalformedPacket = errors.New("tftp: malformed packet")
+
+// wrongOpcode reports that b's opcode doesn't match want, for use by a
+// Decode/UnmarshalBinary that has already confirmed len(b) >= 2.
+func wrongOpcode(b []byte, want OpCode) error {
+	return fmt.Errorf("%w: got %d, want %d", ErrWrongOpcode, opcodeOf(b), want)
+}
+
+// errMalformed reports reason as the cause of an ErrMalformedPacket.
+func errMalformed(reason string) error {
+	return fmt.Errorf("%w: %s", ErrMalformedPacket, reason)
+}
+
+// Packet is implemented by every TFTP packet type. It mirrors the
+// standard library's encoding.BinaryMarshaler/BinaryUnmarshaler so
+// packets can be handled uniformly by callers that don't care about the
+// concrete type.
+type Packet interface {
+	MarshalBinary() ([]byte, error)
+	UnmarshalBinary([]byte) error
+	Op() OpCode
+	String() string
+}
+
+// AppendEncoder is implemented by every Packet and lets hot paths encode
+// into a caller-owned, reusable buffer instead of allocating a fresh
+// []byte per packet (as MarshalBinary does). AppendBinary appends the
+// wire encoding to dst and returns the extended slice, in the style of
+// strconv.AppendInt.
+type AppendEncoder interface {
+	AppendBinary(dst []byte) ([]byte, error)
+}
+
+// Decoder is implemented by every Packet and lets hot paths decode
+// without the per-field allocations UnmarshalBinary makes for safety
+// (e.g. DATA.Decode aliases Payload into b instead of copying it). b
+// must not be reused by the caller until the decoded packet is done
+// with it.
+type Decoder interface {
+	Decode(b []byte) error
+}
+
+// Options carries the option/value pairs negotiated per RFC 2347. Keys
+// are matched case-insensitively on the wire but are stored lower-cased.
+type Options map[string]string
+
+const (
+	OptBlockSize  = "blksize"
+	OptTimeout    = "timeout"
+	OptTransferSize = "tsize"
+	OptWindowSize = "windowsize"
+)
+
+// RRQ is a read request.
+type RRQ struct {
+	Filename string
+	Mode     string
+	Options  Options
+
+	// Trailing holds any NUL-separated data found after the mode field
+	// that didn't form a complete key/value option pair. Some firmware
+	// appends extra junk here; by default it's captured rather than
+	// rejected (see StrictRequestParsing).
+	Trailing []byte
+}
+
+func (p *RRQ) Op() OpCode { return OpRRQ }
+
+func (p *RRQ) String() string {
+	return "RRQ " + p.Filename + " " + p.Mode
+}
+
+func (p *RRQ) AppendBinary(dst []byte) ([]byte, error) {
+	return appendRequest(dst, OpRRQ, p.Filename, p.Mode, p.Options), nil
+}
+
+func (p *RRQ) MarshalBinary() ([]byte, error) {
+	return p.AppendBinary(nil)
+}
+
+func (p *RRQ) UnmarshalBinary(b []byte) error {
+	filename, mode, opts, trailing, err := unmarshalRequest(b, OpRRQ)
+	if err != nil {
+		return err
+	}
+	p.Filename, p.Mode, p.Options, p.Trailing = filename, mode, opts, trailing
+	return nil
+}
+
+// Decode is equivalent to UnmarshalBinary: request filenames and modes
+// are always copied out of b via a string conversion, so there is no
+// zero-copy form worth offering.
+func (p *RRQ) Decode(b []byte) error { return p.UnmarshalBinary(b) }
+
+// WRQ is a write request.
+type WRQ struct {
+	Filename string
+	Mode     string
+	Options  Options
+	Trailing []byte
+}
+
+func (p *WRQ) Op() OpCode { return OpWRQ }
+
+func (p *WRQ) String() string {
+	return "WRQ " + p.Filename + " " + p.Mode
+}
+
+func (p *WRQ) AppendBinary(dst []byte) ([]byte, error) {
+	return appendRequest(dst, OpWRQ, p.Filename, p.Mode, p.Options), nil
+}
+
+func (p *WRQ) MarshalBinary() ([]byte, error) {
+	return p.AppendBinary(nil)
+}
+
+func (p *WRQ) UnmarshalBinary(b []byte) error {
+	filename, mode, opts, trailing, err := unmarshalRequest(b, OpWRQ)
+	if err != nil {
+		return err
+	}
+	p.Filename, p.Mode, p.Options, p.Trailing = filename, mode, opts, trailing
+	return nil
+}
+
+func (p *WRQ) Decode(b []byte) error { return p.UnmarshalBinary(b) }
+
+// DATA carries one block of transfer payload. Block numbers wrap at
+// 65535 per RFC 1350.
+type DATA struct {
+	Block   uint16
+	Payload []byte
+}
+
+func (p *DATA) Op() OpCode { return OpDATA }
+
+func (p *DATA) String() string {
+	return fmt.Sprintf("DATA block=%d len=%d", p.Block, len(p.Payload))
+}
+
+func (p *DATA) AppendBinary(dst []byte) ([]byte, error) {
+	var hdr [4]byte
+	binary.BigEndian.PutUint16(hdr[0:2], uint16(OpDATA))
+	binary.BigEndian.PutUint16(hdr[2:4], p.Block)
+	dst = append(dst, hdr[:]...)
+	dst = append(dst, p.Payload...)
+	return dst, nil
+}
+
+func (p *DATA) MarshalBinary() ([]byte, error) {
+	return p.AppendBinary(nil)
+}
+
+func (p *DATA) UnmarshalBinary(b []byte) error {
+	if err := p.Decode(b); err != nil {
+		return err
+	}
+	p.Payload = append([]byte(nil), p.Payload...)
+	return nil
+}
+
+// Decode aliases Payload directly into b instead of copying it, for use
+// on hot paths (e.g. the server's send/receive loops) that own b for the
+// lifetime of the DATA packet. Callers that need to retain the payload
+// past b's next reuse must use UnmarshalBinary instead.
+func (p *DATA) Decode(b []byte) error {
+	if len(b) < 2 {
+		return errMalformed("too short to read opcode")
+	}
+	if op := opcodeOf(b); op != OpDATA {
+		return wrongOpcode(b, OpDATA)
+	}
+	if len(b) < 4 {
+		return errMalformed("too short for block number")
+	}
+	p.Block = binary.BigEndian.Uint16(b[2:4])
+	p.Payload = b[4:]
+	return nil
+}
+
+// ACK acknowledges receipt of the DATA block with the given number.
+type ACK struct {
+	Block uint16
+}
+
+func (p *ACK) Op() OpCode { return OpACK }
+
+func (p *ACK) String() string {
+	return fmt.Sprintf("ACK block=%d", p.Block)
+}
+
+func (p *ACK) AppendBinary(dst []byte) ([]byte, error) {
+	var hdr [4]byte
+	binary.BigEndian.PutUint16(hdr[0:2], uint16(OpACK))
+	binary.BigEndian.PutUint16(hdr[2:4], p.Block)
+	return append(dst, hdr[:]...), nil
+}
+
+func (p *ACK) MarshalBinary() ([]byte, error) {
+	return p.AppendBinary(nil)
+}
+
+func (p *ACK) UnmarshalBinary(b []byte) error {
+	if len(b) < 2 {
+		return errMalformed("too short to read opcode")
+	}
+	if op := opcodeOf(b); op != OpACK {
+		return wrongOpcode(b, OpACK)
+	}
+	if len(b) < 4 {
+		return errMalformed("too short for block number")
+	}
+	p.Block = binary.BigEndian.Uint16(b[2:4])
+	return nil
+}
+
+func (p *ACK) Decode(b []byte) error { return p.UnmarshalBinary(b) }
+
+// ERROR reports a failure and terminates the transfer.
+type ERROR struct {
+	Code    ErrorCode
+	Message string
+}
+
+func (p *ERROR) Op() OpCode { return OpERROR }
+
+// String matches Error's formatting rather than duplicating it, so
+// logging a Packet and returning it as an error never disagree.
+func (p *ERROR) String() string { return p.Error() }
+
+func (p *ERROR) AppendBinary(dst []byte) ([]byte, error) {
+	var hdr [4]byte
+	binary.BigEndian.PutUint16(hdr[0:2], uint16(OpERROR))
+	binary.BigEndian.PutUint16(hdr[2:4], uint16(p.Code))
+	dst = append(dst, hdr[:]...)
+	dst = append(dst, p.Message...)
+	dst = append(dst, 0)
+	return dst, nil
+}
+
+func (p *ERROR) MarshalBinary() ([]byte, error) {
+	return p.AppendBinary(nil)
+}
+
+func (p *ERROR) UnmarshalBinary(b []byte) error {
+	if len(b) < 2 {
+		return errMalformed("too short to read opcode")
+	}
+	if op := opcodeOf(b); op != OpERROR {
+		return wrongOpcode(b, OpERROR)
+	}
+	if len(b) < 4 {
+		return errMalformed("too short for error code")
+	}
+	p.Code = ErrorCode(binary.BigEndian.Uint16(b[2:4]))
+	p.Message = strings.TrimSuffix(string(b[4:]), "\x00")
+	return nil
+}
+
+func (p *ERROR) Decode(b []byte) error { return p.UnmarshalBinary(b) }
+
+// OACK acknowledges the subset of requested options the peer accepted,
+// per RFC 2347.
+type OACK struct {
+	Options Options
+}
+
+func (p *OACK) Op() OpCode { return OpOACK }
+
+func (p *OACK) String() string {
+	return fmt.Sprintf("OACK %v", map[string]string(p.Options))
+}
+
+func (p *OACK) AppendBinary(dst []byte) ([]byte, error) {
+	var hdr [2]byte
+	binary.BigEndian.PutUint16(hdr[:], uint16(OpOACK))
+	dst = append(dst, hdr[:]...)
+	dst = appendOptions(dst, p.Options)
+	return dst, nil
+}
+
+func (p *OACK) MarshalBinary() ([]byte, error) {
+	return p.AppendBinary(nil)
+}
+
+func (p *OACK) UnmarshalBinary(b []byte) error {
+	if len(b) < 2 {
+		return errMalformed("too short to read opcode")
+	}
+	if op := opcodeOf(b); op != OpOACK {
+		return wrongOpcode(b, OpOACK)
+	}
+	p.Options = readOptions(b[2:])
+	return nil
+}
+
+func (p *OACK) Decode(b []byte) error { return p.UnmarshalBinary(b) }
+
+func appendRequest(dst []byte, op OpCode, filename, mode string, opts Options) []byte {
+	var hdr [2]byte
+	binary.BigEndian.PutUint16(hdr[:], uint16(op))
+	dst = append(dst, hdr[:]...)
+	dst = append(dst, filename...)
+	dst = append(dst, 0)
+	dst = append(dst, mode...)
+	dst = append(dst, 0)
+	dst = appendOptions(dst, opts)
+	return dst
+}
+
+func unmarshalRequest(b []byte, want OpCode) (filename, mode string, opts Options, trailing []byte, err error) {
+	if len(b) < 2 {
+		return "", "", nil, nil, errMalformed("too short to read opcode")
+	}
+	if op := opcodeOf(b); op != want {
+		return "", "", nil, nil, wrongOpcode(b, want)
+	}
+	if len(b) < 4 {
+		return "", "", nil, nil, errMalformed("too short for filename/mode fields")
+	}
+	fields := bytes.Split(b[2:], []byte{0})
+	if len(fields) < 2 {
+		return "", "", nil, nil, errMalformed("missing filename/mode NUL terminators")
+	}
+	if MaxFilenameLength > 0 && len(fields[0]) > MaxFilenameLength {
+		return "", "", nil, nil, NewError(ErrCodeIllegalOp, "filename too long")
+	}
+	if MaxModeLength > 0 && len(fields[1]) > MaxModeLength {
+		return "", "", nil, nil, NewError(ErrCodeIllegalOp, "mode too long")
+	}
+	filename = string(fields[0])
+	mode = string(fields[1])
+	// fields is filename, mode, k1, v1, k2, v2, ..., "" (trailing NUL);
+	// anything beyond the mandatory trailing empty field is an option.
+	rest := fields[2:]
+	for i := 0; i+1 < len(rest); i += 2 {
+		if len(rest[i]) == 0 {
+			continue
+		}
+		if MaxOptionCount > 0 && len(opts) >= MaxOptionCount {
+			return "", "", nil, nil, NewError(ErrCodeIllegalOp, "too many options")
+		}
+		if MaxOptionSize > 0 && len(rest[i])+len(rest[i+1]) > MaxOptionSize {
+			return "", "", nil, nil, NewError(ErrCodeIllegalOp, "option too large")
+		}
+		if opts == nil {
+			opts = Options{}
+		}
+		opts[strings.ToLower(string(rest[i]))] = string(rest[i+1])
+	}
+	// A well-formed request always ends on a NUL, leaving one empty
+	// trailing field after the split. An odd-length remainder with a
+	// non-empty last field means something was appended after the mode
+	// (or last option value) without a closing NUL pair — firmware junk
+	// rather than a protocol violation worth failing the whole request
+	// over, unless StrictRequestParsing says otherwise.
+	if len(rest)%2 == 1 {
+		if last := rest[len(rest)-1]; len(last) > 0 {
+			if StrictRequestParsing {
+				return "", "", nil, nil, NewError(ErrCodeIllegalOp, "trailing data after request fields")
+			}
+			trailing = append([]byte(nil), last...)
+		}
+	}
+	return filename, mode, opts, trailing, nil
+}
+
+func appendOptions(dst []byte, opts Options) []byte {
+	for k, v := range opts {
+		dst = append(dst, k...)
+		dst = append(dst, 0)
+		dst = append(dst, v...)
+		dst = append(dst, 0)
+	}
+	return dst
+}
+
+func readOptions(b []byte) Options {
+	if len(b) == 0 {
+		return nil
+	}
+	opts := Options{}
+	fields := bytes.Split(bytes.TrimSuffix(b, []byte{0}), []byte{0})
+	for i := 0; i+1 < len(fields); i += 2 {
+		opts[strings.ToLower(string(fields[i]))] = string(fields[i+1])
+	}
+	return opts
+}
+
+// opcodeOf reads the opcode from a wire-format packet without allocating
+// or decoding the rest of it, so hot paths can dispatch to the right
+// Decoder without going through ParsePacket.
+func opcodeOf(b []byte) OpCode {
+	if len(b) < 2 {
+		return 0
+	}
+	return OpCode(binary.BigEndian.Uint16(b[0:2]))
+}
+
+// newPacketForOpcode returns a zero-value Packet of the concrete type
+// op identifies, so a caller reads the 2-byte opcode exactly once and
+// decodes directly into the right type instead of attempting
+// UnmarshalBinary against each type in turn until one accepts the
+// bytes — the latter is both slower (up to 6 failed attempts per
+// packet) and reports a worse error, since the last type tried "wins"
+// regardless of which type the sender actually meant.
+func newPacketForOpcode(op OpCode) (Packet, error) {
+	switch op {
+	case OpRRQ:
+		return &RRQ{}, nil
+	case OpWRQ:
+		return &WRQ{}, nil
+	case OpDATA:
+		return &DATA{}, nil
+	case OpACK:
+		return &ACK{}, nil
+	case OpERROR:
+		return &ERROR{}, nil
+	case OpOACK:
+		return &OACK{}, nil
+	default:
+		return nil, NewError(ErrCodeIllegalOp, "unknown opcode")
+	}
+}
+
+// ParsePacket inspects the opcode in b and decodes it into the
+// corresponding Packet implementation via newPacketForOpcode, so
+// dispatch is a single opcode read followed by exactly one decode.
+func ParsePacket(b []byte) (Packet, error) {
+	if len(b) < 2 {
+		return nil, errMalformed("too short to read opcode")
+	}
+	p, err := newPacketForOpcode(opcodeOf(b))
+	if err != nil {
+		return nil, err
+	}
+	if err := p.UnmarshalBinary(b); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
@@ -0,0 +1,228 @@
+package tftp
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPacketRoundTrip(t *testing.T) {
+	cases := []Packet{
+		&RRQ{Filename: "boot.img", Mode: "octet", Options: Options{OptBlockSize: "1024", OptWindowSize: "4"}},
+		&WRQ{Filename: "upload.bin", Mode: "octet"},
+		&DATA{Block: 7, Payload: []byte("hello")},
+		&ACK{Block: 7},
+		&ERROR{Code: ErrCodeNotFound, Message: "no such file"},
+		&OACK{Options: Options{OptBlockSize: "1024"}},
+	}
+
+	for _, want := range cases {
+		b, err := want.MarshalBinary()
+		if err != nil {
+			t.Fatalf("MarshalBinary(%#v): %v", want, err)
+		}
+		got, err := ParsePacket(b)
+		if err != nil {
+			t.Fatalf("ParsePacket: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("round trip mismatch: got %#v, want %#v", got, want)
+		}
+	}
+}
+
+func TestParsePacketTooShort(t *testing.T) {
+	if _, err := ParsePacket([]byte{0}); err == nil {
+		t.Fatal("expected error for short packet")
+	}
+}
+
+func TestParsePacketUnknownOpcode(t *testing.T) {
+	if _, err := ParsePacket([]byte{0, 99}); err == nil {
+		t.Fatal("expected error for unknown opcode")
+	}
+}
+
+// Real firmware has been observed appending extra, non-NUL-terminated
+// bytes after the mode field (e.g. vendor-specific junk). Lenient
+// parsing should expose it via Trailing instead of failing the request.
+func TestUnmarshalRequestTrailingGarbage(t *testing.T) {
+	raw := append(append([]byte{0, 1}, []byte("boot.img\x00octet\x00")...), []byte("DEADBEEF")...)
+
+	var rrq RRQ
+	if err := rrq.UnmarshalBinary(raw); err != nil {
+		t.Fatalf("UnmarshalBinary: %v", err)
+	}
+	if string(rrq.Trailing) != "DEADBEEF" {
+		t.Errorf("Trailing = %q, want %q", rrq.Trailing, "DEADBEEF")
+	}
+
+	StrictRequestParsing = true
+	defer func() { StrictRequestParsing = false }()
+	var strict RRQ
+	if err := strict.UnmarshalBinary(raw); err == nil {
+		t.Fatal("expected StrictRequestParsing to reject trailing garbage")
+	}
+}
+
+func TestUnmarshalRequestNoTrailingGarbage(t *testing.T) {
+	raw := append([]byte{0, 1}, []byte("boot.img\x00octet\x00")...)
+
+	var rrq RRQ
+	if err := rrq.UnmarshalBinary(raw); err != nil {
+		t.Fatalf("UnmarshalBinary: %v", err)
+	}
+	if rrq.Trailing != nil {
+		t.Errorf("Trailing = %q, want nil", rrq.Trailing)
+	}
+}
+
+func TestUnmarshalRequestRejectsOversizedFilename(t *testing.T) {
+	defer func(n int) { MaxFilenameLength = n }(MaxFilenameLength)
+	MaxFilenameLength = 8
+
+	raw := append([]byte{0, 1}, []byte("boot-image-too-long.img\x00octet\x00")...)
+	var rrq RRQ
+	err := rrq.UnmarshalBinary(raw)
+	if err == nil {
+		t.Fatal("expected oversized filename to be rejected")
+	}
+	if terr, ok := err.(*ERROR); !ok || terr.Code != ErrCodeIllegalOp {
+		t.Errorf("err = %v, want *ERROR with ErrCodeIllegalOp", err)
+	}
+}
+
+func TestUnmarshalRequestRejectsOversizedMode(t *testing.T) {
+	defer func(n int) { MaxModeLength = n }(MaxModeLength)
+	MaxModeLength = 4
+
+	raw := append([]byte{0, 1}, []byte("boot.img\x00octet\x00")...)
+	var rrq RRQ
+	err := rrq.UnmarshalBinary(raw)
+	if err == nil {
+		t.Fatal("expected oversized mode to be rejected")
+	}
+	if terr, ok := err.(*ERROR); !ok || terr.Code != ErrCodeIllegalOp {
+		t.Errorf("err = %v, want *ERROR with ErrCodeIllegalOp", err)
+	}
+}
+
+func TestUnmarshalRequestRejectsTooManyOptions(t *testing.T) {
+	defer func(n int) { MaxOptionCount = n }(MaxOptionCount)
+	MaxOptionCount = 1
+
+	raw := append([]byte{0, 1}, []byte("boot.img\x00octet\x00blksize\x001024\x00windowsize\x004\x00")...)
+	var rrq RRQ
+	err := rrq.UnmarshalBinary(raw)
+	if err == nil {
+		t.Fatal("expected a request with too many options to be rejected")
+	}
+	if terr, ok := err.(*ERROR); !ok || terr.Code != ErrCodeIllegalOp {
+		t.Errorf("err = %v, want *ERROR with ErrCodeIllegalOp", err)
+	}
+}
+
+func TestUnmarshalRequestRejectsOversizedOption(t *testing.T) {
+	defer func(n int) { MaxOptionSize = n }(MaxOptionSize)
+	MaxOptionSize = 8
+
+	raw := append([]byte{0, 1}, []byte("boot.img\x00octet\x00blksize\x0065464\x00")...)
+	var rrq RRQ
+	err := rrq.UnmarshalBinary(raw)
+	if err == nil {
+		t.Fatal("expected an oversized option to be rejected")
+	}
+	if terr, ok := err.(*ERROR); !ok || terr.Code != ErrCodeIllegalOp {
+		t.Errorf("err = %v, want *ERROR with ErrCodeIllegalOp", err)
+	}
+}
+
+func TestUnmarshalRequestLimitsDisabledByZero(t *testing.T) {
+	defer func(n int) { MaxFilenameLength = n }(MaxFilenameLength)
+	MaxFilenameLength = 0
+
+	raw := append([]byte{0, 1}, []byte("a-very-long-filename-that-would-otherwise-be-rejected.img\x00octet\x00")...)
+	var rrq RRQ
+	if err := rrq.UnmarshalBinary(raw); err != nil {
+		t.Fatalf("UnmarshalBinary: %v, want the limit disabled", err)
+	}
+}
+
+func TestUnmarshalBinaryReturnsErrWrongOpcode(t *testing.T) {
+	ack, err := (&ACK{Block: 3}).MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary: %v", err)
+	}
+
+	cases := []Decoder{&RRQ{}, &WRQ{}, &DATA{}, &ERROR{}, &OACK{}}
+	for _, p := range cases {
+		err := p.Decode(ack)
+		if !errors.Is(err, ErrWrongOpcode) {
+			t.Errorf("%T.Decode(ACK bytes) = %v, want ErrWrongOpcode", p, err)
+		}
+		if errors.Is(err, ErrMalformedPacket) {
+			t.Errorf("%T.Decode(ACK bytes) = %v, want not ErrMalformedPacket", p, err)
+		}
+	}
+}
+
+func TestUnmarshalBinaryReturnsErrMalformedPacketForTruncatedData(t *testing.T) {
+	cases := []struct {
+		name string
+		p    Decoder
+		raw  []byte
+	}{
+		{"ACK", &ACK{}, []byte{0, 4}},
+		{"DATA", &DATA{}, []byte{0, 3}},
+		{"ERROR", &ERROR{}, []byte{0, 5}},
+		{"RRQ", &RRQ{}, []byte{0, 1}},
+	}
+	for _, c := range cases {
+		err := c.p.Decode(c.raw)
+		if !errors.Is(err, ErrMalformedPacket) {
+			t.Errorf("%s.Decode(%v) = %v, want ErrMalformedPacket", c.name, c.raw, err)
+		}
+	}
+}
+
+func TestParsePacketRejectsTruncatedOpcode(t *testing.T) {
+	if _, err := ParsePacket([]byte{0}); !errors.Is(err, ErrMalformedPacket) {
+		t.Errorf("ParsePacket([]byte{0}) = %v, want ErrMalformedPacket", err)
+	}
+}
+
+func TestNewPacketForOpcodeDispatchesEveryKnownType(t *testing.T) {
+	cases := map[OpCode]Packet{
+		OpRRQ:   &RRQ{},
+		OpWRQ:   &WRQ{},
+		OpDATA:  &DATA{},
+		OpACK:   &ACK{},
+		OpERROR: &ERROR{},
+		OpOACK:  &OACK{},
+	}
+	for op, want := range cases {
+		got, err := newPacketForOpcode(op)
+		if err != nil {
+			t.Fatalf("newPacketForOpcode(%v): %v", op, err)
+		}
+		if reflect.TypeOf(got) != reflect.TypeOf(want) {
+			t.Errorf("newPacketForOpcode(%v) = %T, want %T", op, got, want)
+		}
+	}
+	if _, err := newPacketForOpcode(OpCode(99)); err == nil {
+		t.Fatal("newPacketForOpcode(99): want an error for an unknown opcode")
+	}
+}
+
+func BenchmarkParsePacket(b *testing.B) {
+	wire, err := (&ACK{Block: 42}).MarshalBinary()
+	if err != nil {
+		b.Fatalf("MarshalBinary: %v", err)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParsePacket(wire); err != nil {
+			b.Fatalf("ParsePacket: %v", err)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package tftp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+type closingBuffer struct {
+	*bytes.Reader
+	mu     *sync.Mutex
+	closed *int
+}
+
+func (c closingBuffer) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	*c.closed++
+	return nil
+}
+
+func TestServeRRQUsesPayloadProvider(t *testing.T) {
+	want := bytes.Repeat([]byte("p"), DefaultBlockSize*2+3)
+	var mu sync.Mutex
+	closed := 0
+	var gotFilename string
+	var gotAddr net.Addr
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s := &Server{Timeout: time.Second, Retries: DefaultRetries}
+	s.PayloadProvider = func(ctx context.Context, filename string, clientAddr net.Addr) (io.ReadCloser, int64, error) {
+		mu.Lock()
+		gotFilename, gotAddr = filename, clientAddr
+		mu.Unlock()
+		return closingBuffer{Reader: bytes.NewReader(want), mu: &mu, closed: &closed}, int64(len(want)), nil
+	}
+	s.conn = conn
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	got, err := c.GetBytes("generated.bin", "octet")
+	if err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("got %d bytes, want %d matching bytes", len(got), len(want))
+	}
+
+	mu.Lock()
+	if gotFilename != "generated.bin" {
+		t.Errorf("PayloadProvider filename = %q, want %q", gotFilename, "generated.bin")
+	}
+	if gotAddr == nil {
+		t.Error("PayloadProvider clientAddr = nil, want the client's address")
+	}
+	mu.Unlock()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		n := closed
+		mu.Unlock()
+		if n == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("closed = %d, want 1", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServeRRQPayloadProviderErrorCode(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s := &Server{Timeout: time.Second, Retries: DefaultRetries}
+	s.PayloadProvider = func(ctx context.Context, filename string, clientAddr net.Addr) (io.ReadCloser, int64, error) {
+		return nil, 0, NewError(ErrCodeDiskFull, "cache is cold")
+	}
+	s.conn = conn
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	_, err = c.GetBytes("anything", "octet")
+	var tftpErr *ERROR
+	if !errors.As(err, &tftpErr) {
+		t.Fatalf("err = %v, want *ERROR", err)
+	}
+	if tftpErr.Code != ErrCodeDiskFull {
+		t.Errorf("Code = %v, want %v", tftpErr.Code, ErrCodeDiskFull)
+	}
+}
@@ -0,0 +1,196 @@
+package tftp
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// PacketCapture records every TFTP datagram a Client or Server sends or
+// receives to w in pcap format (the classic libpcap file format, not
+// pcapng), so a transfer can be opened directly in Wireshark to
+// diagnose wire-level issues with picky firmware TFTP clients. Each
+// record is a synthesized IPv4 packet carrying a UDP segment with a
+// correctly computed header checksum, using LINKTYPE_RAW framing (no
+// Ethernet header) since the link layer plays no part in the TFTP
+// conversation being inspected.
+//
+// A PacketCapture is safe for concurrent use, but writes to w are not
+// flushed or closed on its behalf; the caller owns w's lifecycle.
+type PacketCapture struct {
+	mu     sync.Mutex
+	w      io.Writer
+	nextID uint16
+	err    error
+}
+
+// pcapGlobalHeader is the 24-byte header that begins every classic
+// pcap file, declaring microsecond timestamp resolution and a raw-IP
+// link-layer type.
+var pcapGlobalHeader = []byte{
+	0xd4, 0xc3, 0xb2, 0xa1, // magic number, little-endian, microsecond resolution
+	0x02, 0x00, 0x04, 0x00, // version major 2, minor 4
+	0x00, 0x00, 0x00, 0x00, // GMT to local correction
+	0x00, 0x00, 0x00, 0x00, // accuracy of timestamps
+	0xff, 0xff, 0x00, 0x00, // snaplen: 65535
+	0x65, 0x00, 0x00, 0x00, // LINKTYPE_RAW (101)
+}
+
+// NewPacketCapture writes a pcap file header to w and returns a
+// PacketCapture ready to record datagrams.
+func NewPacketCapture(w io.Writer) (*PacketCapture, error) {
+	if _, err := w.Write(pcapGlobalHeader); err != nil {
+		return nil, err
+	}
+	return &PacketCapture{w: w}, nil
+}
+
+// Err returns the first error encountered while writing to w, if any.
+// A PacketCapture stops writing further records once it has failed
+// once, so a full disk degrades a capture rather than a transfer.
+func (pc *PacketCapture) Err() error {
+	if pc == nil {
+		return nil
+	}
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	return pc.err
+}
+
+// sent records a datagram transmitted from local to remote. It is a
+// no-op on a nil PacketCapture, so call sites can pass a Client or
+// Server's possibly-nil Capture field unconditionally. local and remote
+// are typed as net.Addr so a call site sitting behind a PacketMiddleware
+// doesn't need to assert back to *net.UDPAddr itself; see record.
+func (pc *PacketCapture) sent(local, remote net.Addr, payload []byte) {
+	pc.record(local, remote, payload)
+}
+
+// received records a datagram read from remote, addressed to local.
+func (pc *PacketCapture) received(local, remote net.Addr, payload []byte) {
+	pc.record(remote, local, payload)
+}
+
+func (pc *PacketCapture) record(src, dst net.Addr, payload []byte) {
+	if pc == nil {
+		return
+	}
+	srcAddr, ok := src.(*net.UDPAddr)
+	if !ok {
+		return
+	}
+	dstAddr, ok := dst.(*net.UDPAddr)
+	if !ok {
+		// A PacketMiddleware that hands back an Addr of its own rather
+		// than passing the underlying *net.UDPAddr through unchanged;
+		// this format only knows how to frame UDP/IPv4, so skip rather
+		// than emit a malformed record.
+		return
+	}
+	srcIP, dstIP := normalizeIPv4(srcAddr.IP), normalizeIPv4(dstAddr.IP)
+	if srcIP == nil || dstIP == nil {
+		// IPv6 isn't framed here; skip rather than emit a malformed
+		// packet that would confuse Wireshark more than an omission.
+		return
+	}
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if pc.err != nil {
+		return
+	}
+
+	id := pc.nextID
+	pc.nextID++
+	pkt := buildIPv4UDP(srcIP, srcAddr.Port, dstIP, dstAddr.Port, payload, id)
+
+	now := time.Now()
+	rec := make([]byte, 16, 16+len(pkt))
+	binary.LittleEndian.PutUint32(rec[0:4], uint32(now.Unix()))
+	binary.LittleEndian.PutUint32(rec[4:8], uint32(now.Nanosecond()/1000))
+	binary.LittleEndian.PutUint32(rec[8:12], uint32(len(pkt)))
+	binary.LittleEndian.PutUint32(rec[12:16], uint32(len(pkt)))
+	rec = append(rec, pkt...)
+
+	if _, err := pc.w.Write(rec); err != nil {
+		pc.err = err
+	}
+}
+
+// normalizeIPv4 returns ip's 4-byte form for framing, substituting
+// 0.0.0.0 for an unspecified address (IPv4 or IPv6, including a
+// UDPConn's nil IP before it's ever been dialed or bound to a concrete
+// interface) since a socket listening on the wildcard address sends and
+// receives over whatever interface routing picks at write time, not
+// over any address literally representable as "::". A concrete,
+// non-unspecified IPv6 address returns nil, since this capture format
+// only frames IPv4.
+func normalizeIPv4(ip net.IP) net.IP {
+	if v4 := ip.To4(); v4 != nil {
+		return v4
+	}
+	if ip == nil || ip.IsUnspecified() {
+		return net.IPv4zero.To4()
+	}
+	return nil
+}
+
+// buildIPv4UDP assembles a bare IPv4 packet (no link-layer header)
+// carrying payload as a UDP segment, with both the IP and UDP checksums
+// computed over the finished header.
+func buildIPv4UDP(srcIP net.IP, srcPort int, dstIP net.IP, dstPort int, payload []byte, id uint16) []byte {
+	udpLen := 8 + len(payload)
+	totalLen := 20 + udpLen
+	pkt := make([]byte, totalLen)
+
+	pkt[0] = 0x45 // version 4, 20-byte header (no options)
+	pkt[1] = 0    // DSCP/ECN
+	binary.BigEndian.PutUint16(pkt[2:4], uint16(totalLen))
+	binary.BigEndian.PutUint16(pkt[4:6], id)
+	binary.BigEndian.PutUint16(pkt[6:8], 0) // flags/fragment offset
+	pkt[8] = 64                             // TTL
+	pkt[9] = 17                             // protocol: UDP
+	copy(pkt[12:16], srcIP)
+	copy(pkt[16:20], dstIP)
+	binary.BigEndian.PutUint16(pkt[10:12], internetChecksum(pkt[:20]))
+
+	udp := pkt[20:]
+	binary.BigEndian.PutUint16(udp[0:2], uint16(srcPort))
+	binary.BigEndian.PutUint16(udp[2:4], uint16(dstPort))
+	binary.BigEndian.PutUint16(udp[4:6], uint16(udpLen))
+	copy(udp[8:], payload)
+
+	pseudo := make([]byte, 12+udpLen)
+	copy(pseudo[0:4], srcIP)
+	copy(pseudo[4:8], dstIP)
+	pseudo[9] = 17
+	binary.BigEndian.PutUint16(pseudo[10:12], uint16(udpLen))
+	copy(pseudo[12:], udp)
+	cksum := internetChecksum(pseudo)
+	if cksum == 0 {
+		// RFC 768: a computed checksum of zero is transmitted as all
+		// ones, since zero on the wire means "no checksum computed".
+		cksum = 0xffff
+	}
+	binary.BigEndian.PutUint16(udp[6:8], cksum)
+
+	return pkt
+}
+
+// internetChecksum computes the ones'-complement checksum used by both
+// IPv4 headers and UDP-over-IPv4 (RFC 1071).
+func internetChecksum(data []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(data); i += 2 {
+		sum += uint32(data[i])<<8 | uint32(data[i+1])
+	}
+	if len(data)%2 == 1 {
+		sum += uint32(data[len(data)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = (sum & 0xffff) + (sum >> 16)
+	}
+	return ^uint16(sum)
+}
@@ -0,0 +1,97 @@
+package tftp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+)
+
+func TestBuildIPv4UDPChecksumsValidate(t *testing.T) {
+	src := net.IPv4(192, 168, 1, 10).To4()
+	dst := net.IPv4(192, 168, 1, 20).To4()
+	payload := []byte("hello tftp")
+
+	pkt := buildIPv4UDP(src, 12345, dst, 69, payload, 1)
+
+	if got := internetChecksum(pkt[:20]); got != 0 {
+		t.Errorf("IP header checksum = %#x, want 0 (self-validating)", got)
+	}
+
+	udpLen := len(pkt) - 20
+	pseudo := make([]byte, 12+udpLen)
+	copy(pseudo[0:4], src)
+	copy(pseudo[4:8], dst)
+	pseudo[9] = 17
+	binary.BigEndian.PutUint16(pseudo[10:12], uint16(udpLen))
+	copy(pseudo[12:], pkt[20:])
+	if got := internetChecksum(pseudo); got != 0 {
+		t.Errorf("UDP checksum = %#x, want 0 (self-validating)", got)
+	}
+
+	gotSrcPort := binary.BigEndian.Uint16(pkt[20:22])
+	gotDstPort := binary.BigEndian.Uint16(pkt[22:24])
+	if gotSrcPort != 12345 || gotDstPort != 69 {
+		t.Errorf("ports = %d/%d, want 12345/69", gotSrcPort, gotDstPort)
+	}
+	if !bytes.Equal(pkt[28:], payload) {
+		t.Errorf("payload = %q, want %q", pkt[28:], payload)
+	}
+}
+
+func TestPacketCaptureRecordsTransfer(t *testing.T) {
+	want := bytes.Repeat([]byte("x"), DefaultBlockSize*2+10)
+	addr, _ := startTestServer(t, map[string][]byte{"file.bin": want})
+
+	var buf bytes.Buffer
+	capture, err := NewPacketCapture(&buf)
+	if err != nil {
+		t.Fatalf("NewPacketCapture: %v", err)
+	}
+
+	c := NewClient(addr)
+	c.Capture = capture
+	got, err := c.GetBytes("file.bin", "octet")
+	if err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("GetBytes returned %d bytes, want %d", len(got), len(want))
+	}
+
+	if capture.Err() != nil {
+		t.Fatalf("capture.Err() = %v", capture.Err())
+	}
+
+	data := buf.Bytes()
+	if len(data) < 24 {
+		t.Fatalf("capture is only %d bytes, missing the global header", len(data))
+	}
+	if !bytes.Equal(data[:4], []byte{0xd4, 0xc3, 0xb2, 0xa1}) {
+		t.Errorf("global header magic = %x, want pcap magic", data[:4])
+	}
+	if binary.LittleEndian.Uint32(data[20:24]) != 101 {
+		t.Errorf("linktype = %d, want 101 (LINKTYPE_RAW)", binary.LittleEndian.Uint32(data[20:24]))
+	}
+
+	records := 0
+	for rest := data[24:]; len(rest) > 0; {
+		if len(rest) < 16 {
+			t.Fatalf("truncated record header with %d bytes left", len(rest))
+		}
+		inclLen := binary.LittleEndian.Uint32(rest[8:12])
+		if len(rest) < int(16+inclLen) {
+			t.Fatalf("truncated record body: want %d bytes, have %d", inclLen, len(rest)-16)
+		}
+		pkt := rest[16 : 16+inclLen]
+		if pkt[9] != 17 {
+			t.Errorf("record %d: IP protocol = %d, want 17 (UDP)", records, pkt[9])
+		}
+		records++
+		rest = rest[16+inclLen:]
+	}
+	// At minimum: the RRQ and one DATA/ACK pair.
+	if records < 3 {
+		t.Errorf("captured %d records, want at least 3", records)
+	}
+}
@@ -0,0 +1,111 @@
+package tftp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestActiveSessionsForIPCountsOnlyMatchingIP(t *testing.T) {
+	s := &Server{}
+	addrA1 := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1111}
+	addrA2 := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 2222}
+	addrB := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 2), Port: 3333}
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer conn.Close()
+
+	as1 := s.registerSession(conn, Session{Addr: addrA1, Filename: "a1.bin", Op: OpRRQ})
+	defer s.unregisterSession(as1.id)
+	as2 := s.registerSession(conn, Session{Addr: addrA2, Filename: "a2.bin", Op: OpRRQ})
+	defer s.unregisterSession(as2.id)
+	as3 := s.registerSession(conn, Session{Addr: addrB, Filename: "b.bin", Op: OpRRQ})
+	defer s.unregisterSession(as3.id)
+
+	if n := s.activeSessionsForIP(net.IPv4(127, 0, 0, 1)); n != 2 {
+		t.Errorf("activeSessionsForIP(127.0.0.1) = %d, want 2", n)
+	}
+	if n := s.activeSessionsForIP(net.IPv4(127, 0, 0, 2)); n != 1 {
+		t.Errorf("activeSessionsForIP(127.0.0.2) = %d, want 1", n)
+	}
+	if n := s.activeSessionsForIP(net.IPv4(127, 0, 0, 3)); n != 0 {
+		t.Errorf("activeSessionsForIP(127.0.0.3) = %d, want 0", n)
+	}
+}
+
+func TestDispatchRejectsPastMaxSessionsPerIP(t *testing.T) {
+	var calls int32
+	block := make(chan struct{})
+
+	s := &Server{Timeout: time.Second, Retries: DefaultRetries, MaxSessionsPerIP: 2}
+	s.PayloadProvider = func(ctx context.Context, filename string, clientAddr net.Addr) (io.ReadCloser, int64, error) {
+		atomic.AddInt32(&calls, 1)
+		<-block
+		return io.NopCloser(bytes.NewReader([]byte("data"))), 4, nil
+	}
+
+	serverConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	go s.Serve(serverConn)
+	defer s.Close()
+	serverAddr := serverConn.LocalAddr().(*net.UDPAddr)
+
+	var clients []*net.UDPConn
+	for i := 0; i < 3; i++ {
+		c, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		if err != nil {
+			t.Fatalf("ListenUDP: %v", err)
+		}
+		defer c.Close()
+		clients = append(clients, c)
+	}
+
+	sendRRQ := func(c *net.UDPConn, filename string) {
+		wire, err := (&RRQ{Filename: filename, Mode: "octet"}).MarshalBinary()
+		if err != nil {
+			t.Fatalf("MarshalBinary: %v", err)
+		}
+		if _, err := c.WriteToUDP(wire, serverAddr); err != nil {
+			t.Fatalf("WriteToUDP: %v", err)
+		}
+	}
+
+	sendRRQ(clients[0], "first.bin")
+	sendRRQ(clients[1], "second.bin")
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&calls) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Fatalf("PayloadProvider calls = %d after two RRQs from the same IP, want 2", got)
+	}
+
+	sendRRQ(clients[2], "third.bin")
+
+	clients[2].SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 1024)
+	n, _, err := clients[2].ReadFromUDP(buf)
+	if err != nil {
+		t.Fatalf("ReadFromUDP: %v", err)
+	}
+	var errPkt ERROR
+	if err := errPkt.UnmarshalBinary(buf[:n]); err != nil {
+		t.Fatalf("third RRQ's reply didn't decode as an ERROR: %v", err)
+	}
+
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Fatalf("PayloadProvider calls = %d after a third RRQ past the per-IP limit, want still 2", got)
+	}
+
+	close(block)
+}
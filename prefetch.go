@@ -0,0 +1,81 @@
+package tftp
+
+import "sync"
+
+// prefetchBlockSource wraps a blockSource backed by a slow per-block
+// fetch (an HTTP range request, an S3 GetObject call, ...) so the next
+// ahead blocks are read in the background while sendData waits on the
+// current window's ACK, hiding the backend's own latency behind the
+// protocol's round trips instead of stacking on top of them. It assumes
+// src.Block is safe to call concurrently and, per blockSource's
+// contract, deterministic — exactly what ReaderAtBlockIterator already
+// guarantees over an io.ReaderAt.
+type prefetchBlockSource struct {
+	src   blockSource
+	ahead int
+
+	mu      sync.Mutex
+	pending map[int]*blockFetch
+}
+
+// blockFetch is the in-flight or completed result of fetching one block.
+// data/err are only safe to read after done is closed, which happens
+// exactly once, after the fetching goroutine finishes writing them.
+type blockFetch struct {
+	done chan struct{}
+	data []byte
+	err  error
+}
+
+// newPrefetchBlockSource returns a blockSource over src that eagerly
+// fetches up to ahead blocks beyond whichever one Block was last asked
+// for. ahead is clamped to at least 1, so the wrapper is never worse
+// than src itself.
+func newPrefetchBlockSource(src blockSource, ahead int) *prefetchBlockSource {
+	if ahead < 1 {
+		ahead = 1
+	}
+	return &prefetchBlockSource{src: src, ahead: ahead, pending: make(map[int]*blockFetch)}
+}
+
+func (p *prefetchBlockSource) Len() int {
+	return p.src.Len()
+}
+
+// Block returns the payload for the i'th block, kicking off background
+// fetches for the next p.ahead blocks first so they're already in
+// flight (or done) by the time sendData gets around to asking for them.
+// A block whose fetch is already pending (because an earlier call
+// prefetched it) is not fetched twice; once its result has been
+// returned, it's evicted so memory use stays bounded to roughly ahead
+// blocks rather than the whole transfer — a block sendData re-requests
+// after that (a retransmit) is simply fetched again from src.
+func (p *prefetchBlockSource) Block(i int) ([]byte, error) {
+	f := p.fetch(i)
+	for j := i + 1; j <= i+p.ahead && j < p.src.Len(); j++ {
+		p.fetch(j)
+	}
+	<-f.done
+
+	p.mu.Lock()
+	delete(p.pending, i)
+	p.mu.Unlock()
+	return f.data, f.err
+}
+
+// fetch returns the blockFetch for block i, starting one in the
+// background if none is already pending or completed.
+func (p *prefetchBlockSource) fetch(i int) *blockFetch {
+	p.mu.Lock()
+	f, ok := p.pending[i]
+	if !ok {
+		f = &blockFetch{done: make(chan struct{})}
+		p.pending[i] = f
+		go func() {
+			f.data, f.err = p.src.Block(i)
+			close(f.done)
+		}()
+	}
+	p.mu.Unlock()
+	return f
+}
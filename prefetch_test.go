@@ -0,0 +1,76 @@
+package tftp
+
+import (
+	"bytes"
+	"sync/atomic"
+	"testing"
+)
+
+// countingBlockSource wraps a blockSource and counts how many times
+// Block is called for each index, so tests can assert prefetching
+// doesn't re-fetch a block it's already fetched.
+type countingBlockSource struct {
+	src    blockSource
+	counts []atomic.Int32
+}
+
+func (c *countingBlockSource) Len() int { return c.src.Len() }
+
+func (c *countingBlockSource) Block(i int) ([]byte, error) {
+	c.counts[i].Add(1)
+	return c.src.Block(i)
+}
+
+func TestPrefetchBlockSourceMatchesUnderlyingSource(t *testing.T) {
+	data := []byte("abcdefghijklmnop")
+	want := NewBlockIterator(data, 4)
+	src := &countingBlockSource{src: NewBlockIterator(data, 4), counts: make([]atomic.Int32, want.Len())}
+	p := newPrefetchBlockSource(src, 2)
+
+	if got, wantLen := p.Len(), want.Len(); got != wantLen {
+		t.Fatalf("Len() = %d, want %d", got, wantLen)
+	}
+	for i := 0; i < want.Len(); i++ {
+		got := block(t, p, i)
+		wantBlock := block(t, want, i)
+		if !bytes.Equal(got, wantBlock) {
+			t.Errorf("Block(%d) = %q, want %q", i, got, wantBlock)
+		}
+	}
+}
+
+func TestPrefetchBlockSourceDoesNotRefetchAPendingBlock(t *testing.T) {
+	data := []byte("abcdefghijklmnop")
+	src := &countingBlockSource{src: NewBlockIterator(data, 4), counts: make([]atomic.Int32, NewBlockIterator(data, 4).Len())}
+	p := newPrefetchBlockSource(src, 3)
+
+	// Block(0) prefetches 1, 2, and 3 in the background; asking for 1
+	// right after should join that in-flight fetch rather than starting
+	// a second one.
+	block(t, p, 0)
+	block(t, p, 1)
+
+	if n := src.counts[1].Load(); n != 1 {
+		t.Errorf("Block(1) was fetched from src %d times, want exactly 1", n)
+	}
+}
+
+func TestPrefetchBlockSourceRetransmitRefetches(t *testing.T) {
+	data := []byte("abcdefgh")
+	src := &countingBlockSource{src: NewBlockIterator(data, 4), counts: make([]atomic.Int32, NewBlockIterator(data, 4).Len())}
+	p := newPrefetchBlockSource(src, 1)
+
+	first := block(t, p, 0)
+	second := block(t, p, 0)
+	if !bytes.Equal(first, second) {
+		t.Fatalf("Block(0) returned %q then %q, want identical results for a retransmit", first, second)
+	}
+}
+
+func TestPrefetchBlockSourceClampsAheadToAtLeastOne(t *testing.T) {
+	data := []byte("abcd")
+	p := newPrefetchBlockSource(NewBlockIterator(data, 4), 0)
+	if p.ahead != 1 {
+		t.Errorf("ahead = %d, want 1 when constructed with 0", p.ahead)
+	}
+}
@@ -0,0 +1,225 @@
+package tftp
+
+import (
+	"context"
+	"io"
+	"net"
+	"time"
+)
+
+// Put uploads r to the server as filename in the given mode, implementing
+// the WRQ side of the protocol: send WRQ, wait for ACK 0, then send DATA
+// blocks with the same retransmission behavior as a download. The
+// returned Stats cover only the DATA/ACK phase (Duration starts once
+// the server's initial response arrives), so callers graphing
+// throughput don't see the handshake's round trip counted as part of
+// the transfer proper.
+//
+// r is read as a stream rather than buffered up front, so piping stdin
+// or an arbitrarily large, unseekable source through Put doesn't require
+// holding it all in memory; tsize is requested as 0 (unknown), the same
+// convention Get uses for a download of unknown length. The transfer
+// ends as soon as r yields a short or empty read, per RFC 1350's
+// trailing-block EOF signal — including a final empty DATA packet when
+// len(r)'s contents happen to be an exact multiple of the block size.
+// The exceptions are Compress and EncryptionKey: gzip framing and
+// AES-256-GCM sealing both need the whole payload in hand before the
+// first block can be sent, so r is buffered when either is set.
+func (c *Client) Put(ctx context.Context, filename, mode string, r io.Reader) (Stats, error) {
+	local, err := c.localBindAddr()
+	if err != nil {
+		return Stats{}, err
+	}
+	conn, err := net.ListenUDP("udp", local)
+	if err != nil {
+		return Stats{}, err
+	}
+	defer conn.Close()
+	enableICMPErrors(conn)
+	pc := applyMiddleware(conn, c.Middleware)
+
+	go func() {
+		<-ctx.Done()
+		pc.Close()
+	}()
+
+	needsBuffer := c.Compress || len(c.EncryptionKey) > 0
+	var tsize int64
+	var buffered []byte
+	if needsBuffer {
+		buffered, err = io.ReadAll(r)
+		if err != nil {
+			return Stats{}, err
+		}
+		tsize = int64(len(buffered))
+	}
+
+	rttStart := time.Now()
+	req := &WRQ{Filename: filename, Mode: mode, Options: c.requestOptions(filename, tsize)}
+	from, nego, err := c.wrqHandshake(ctx, pc, req)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	start := time.Now()
+	timeout := c.Timeout
+	var rto *rtoEstimator
+	if c.AdaptiveTimeout {
+		timeout = seedTimeoutFromRTT(time.Since(rttStart))
+		rto = newRTOEstimator(timeout)
+	}
+
+	var blocks blockSource
+	if needsBuffer {
+		// The server only echoed OptCompress/OptEncrypt in its OACK just
+		// now, after the WRQ (carrying the unmodified tsize) had already
+		// gone out, so both transforms are applied here rather than
+		// before writePacket. If the server declined either, that step
+		// is skipped and payload is sent as-is (or with only the other
+		// transform applied).
+		payload := buffered
+		if nego.Compressed {
+			payload, err = gzipCompress(payload)
+			if err != nil {
+				return Stats{}, err
+			}
+		}
+		if nego.Encrypted {
+			payload, err = aesGCMEncrypt(c.EncryptionKey, payload)
+			if err != nil {
+				return Stats{}, err
+			}
+		}
+		blocks = NewBlockIterator(payload, nego.BlockSize)
+	} else {
+		blocks = newStreamingBlockSource(r, nego.BlockSize)
+	}
+
+	var xferStats transferStats
+	err = sendData(pc, from, blocks, nego, timeout, c.Retries, 1, &xferStats, c.Capture, debugLoggerOf(c.Logger), nil, rto, c.RetryPolicy, nil)
+	stats := Stats{
+		Bytes:           xferStats.bytes,
+		Duration:        time.Since(start),
+		Blocks:          xferStats.blocks,
+		Retransmissions: xferStats.retransmissions,
+		DuplicateACKs:   xferStats.duplicateACKs,
+		Timeouts:        xferStats.timeouts,
+		BlockSize:       nego.BlockSize,
+	}
+	if err != nil {
+		if cerr := ctx.Err(); cerr != nil {
+			return stats, cerr
+		}
+		return stats, err
+	}
+	return stats, nil
+}
+
+// wrqHandshake sends req to each of c.serverAddrs() in turn, returning
+// the first server's response. It mirrors rrqHandshake's failover
+// behavior for the WRQ side: an address that never answers within
+// c.Retries attempts, or that actively refuses the connection
+// (errConnRefused, an ICMP port-unreachable), is skipped in favor of
+// the next one in Addrs, while any other error (including an explicit
+// ERROR reply, or the last address failing) is returned immediately.
+func (c *Client) wrqHandshake(ctx context.Context, conn packetConn, req *WRQ) (*net.UDPAddr, NegotiatedOptions, error) {
+	addrs := c.serverAddrs()
+	var lastErr error
+	for i, addr := range addrs {
+		remote, err := net.ResolveUDPAddr("udp", addr)
+		if err != nil {
+			return nil, NegotiatedOptions{}, err
+		}
+		if err := writePacket(conn, req, remote, c.Capture, debugLoggerOf(c.Logger)); err != nil {
+			return nil, NegotiatedOptions{}, err
+		}
+		from, nego, err := c.awaitInitialResponse(ctx, conn, remote, req)
+		if err == nil {
+			return from, nego, nil
+		}
+		if !isFailoverError(err) || i == len(addrs)-1 {
+			return nil, NegotiatedOptions{}, err
+		}
+		c.warnf("tftp: %s: no response from %s, failing over to %s", req.Filename, addr, addrs[i+1])
+		lastErr = err
+	}
+	return nil, NegotiatedOptions{}, lastErr
+}
+
+// awaitInitialResponse waits for the server's first substantive reply to
+// a WRQ, which is either an OACK (the server accepted one or more of the
+// requested options) or a plain ACK of block 0 (a plain RFC 1350 server,
+// or one that ignored every requested option). Per RFC 2347, an OACK for
+// a WRQ stands in for ACK 0 itself, so it isn't acknowledged separately;
+// the caller proceeds straight to sending DATA block 1. Each timed-out
+// attempt retransmits req (the WRQ may simply have been lost) and waits
+// however long c.RetryPolicy says to next, so repeated losses don't all
+// retry in lockstep. It returns ctx.Err() as soon as a read fails after
+// ctx is canceled, rather than working through the remaining retries
+// first.
+func (c *Client) awaitInitialResponse(ctx context.Context, conn packetConn, remote *net.UDPAddr, req *WRQ) (*net.UDPAddr, NegotiatedOptions, error) {
+	fallback := NegotiatedOptions{BlockSize: DefaultBlockSize, WindowSize: DefaultWindowSize}
+	// The negotiated block size isn't known yet at this point in the
+	// handshake, so the buffer is sized to the largest possible UDP
+	// datagram rather than to blksize, mirroring readInitialResponse.
+	buf := make([]byte, 65507)
+	local, _ := conn.LocalAddr().(*net.UDPAddr)
+	attempt := 0
+	for {
+		if attempt > c.Retries {
+			if err := ctx.Err(); err != nil {
+				return nil, NegotiatedOptions{}, err
+			}
+			return nil, NegotiatedOptions{}, errTransferAborted
+		}
+		delay, ok := retryDelay(c.RetryPolicy, c.Timeout, attempt)
+		if !ok {
+			return nil, NegotiatedOptions{}, errTransferAborted
+		}
+		conn.SetReadDeadline(time.Now().Add(delay))
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if cerr := ctx.Err(); cerr != nil {
+				return nil, NegotiatedOptions{}, cerr
+			}
+			if uc, ok := conn.(*net.UDPConn); ok && connRefused(uc) {
+				c.warnf("tftp: %s: %s refused the connection (ICMP port unreachable)", req.Filename, remote)
+				return nil, NegotiatedOptions{}, errConnRefused
+			}
+			attempt++
+			if werr := writePacket(conn, req, remote, c.Capture, debugLoggerOf(c.Logger)); werr != nil {
+				return nil, NegotiatedOptions{}, werr
+			}
+			continue
+		}
+		c.Capture.received(local, addr, buf[:n])
+		debugPacket(debugLoggerOf(c.Logger), "received", addr, buf[:n])
+		// sendData still addresses the session by *net.UDPAddr, so a
+		// PacketMiddleware that hands back some other Addr type for the
+		// server's reply can't be serviced past this handshake.
+		from, ok := addr.(*net.UDPAddr)
+		if !ok {
+			attempt++
+			continue
+		}
+		p, err := ParsePacket(buf[:n])
+		if err != nil {
+			attempt++
+			continue
+		}
+		switch pkt := p.(type) {
+		case *ACK:
+			if pkt.Block == 0 {
+				return from, fallback, nil
+			}
+			attempt++
+		case *OACK:
+			nego, _ := parseOptions(pkt.Options)
+			return from, nego, nil
+		case *ERROR:
+			return nil, NegotiatedOptions{}, pkt
+		default:
+			attempt++
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package tftp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestClientPut(t *testing.T) {
+	addr, h := startTestServer(t, map[string][]byte{})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+	want := bytes.Repeat([]byte("c"), DefaultBlockSize*2+5)
+
+	stats, err := c.Put(context.Background(), "upload.bin", "octet", bytes.NewReader(want))
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if stats.Bytes != int64(len(want)) {
+		t.Errorf("Stats.Bytes = %d, want %d", stats.Bytes, len(want))
+	}
+	if stats.Blocks == 0 {
+		t.Error("Stats.Blocks = 0, want at least one DATA block")
+	}
+
+	// The server ACKs the final DATA block (unblocking Put) just before
+	// it calls Handler.WriteFile, so give it a brief moment to land.
+	var got []byte
+	var ok bool
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if got, ok = h.get("upload.bin"); ok {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !ok {
+		t.Fatal("server never recorded the uploaded file")
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("uploaded %d bytes, want %d", len(got), len(want))
+	}
+}
+
+// TestClientPutStreamsUnknownLengthReader uploads from an io.Pipe,
+// which unlike bytes.Reader exposes no length or Seek, the same shape
+// as stdin or any other piped source. The pipe writer trickles data in
+// over several writes to make sure Put doesn't require it all up front.
+func TestClientPutStreamsUnknownLengthReader(t *testing.T) {
+	addr, h := startTestServer(t, map[string][]byte{})
+
+	pr, pw := io.Pipe()
+	want := bytes.Repeat([]byte("s"), DefaultBlockSize*2+5)
+	go func() {
+		for _, chunk := range [][]byte{want[:100], want[100:DefaultBlockSize], want[DefaultBlockSize:]} {
+			pw.Write(chunk)
+		}
+		pw.Close()
+	}()
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+	stats, err := c.Put(context.Background(), "stream.bin", "octet", pr)
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if stats.Bytes != int64(len(want)) {
+		t.Errorf("Stats.Bytes = %d, want %d", stats.Bytes, len(want))
+	}
+
+	var got []byte
+	var ok bool
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if got, ok = h.get("stream.bin"); ok {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !ok {
+		t.Fatal("server never recorded the uploaded file")
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("uploaded %d bytes, want %d", len(got), len(want))
+	}
+}
+
+// TestClientPutStreamsExactBlockSizeMultiple checks the trailing-block
+// EOF convention still holds when the input length happens to be an
+// exact multiple of the negotiated block size: a final empty DATA
+// packet must still go out, or the server has no way to tell the
+// transfer apart from one that simply stalled after the last full
+// block.
+func TestClientPutStreamsExactBlockSizeMultiple(t *testing.T) {
+	addr, h := startTestServer(t, map[string][]byte{})
+
+	want := bytes.Repeat([]byte("e"), DefaultBlockSize*2)
+	c := NewClient(addr)
+	c.Timeout = time.Second
+	stats, err := c.Put(context.Background(), "exact.bin", "octet", io.NopCloser(bytes.NewReader(want)))
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if stats.Blocks != 3 {
+		t.Errorf("Stats.Blocks = %d, want 3 (two full blocks plus a trailing empty one)", stats.Blocks)
+	}
+
+	var got []byte
+	var ok bool
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if got, ok = h.get("exact.bin"); ok {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !ok {
+		t.Fatal("server never recorded the uploaded file")
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("uploaded %d bytes, want %d", len(got), len(want))
+	}
+}
+
+func TestClientPutContextCancellation(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer conn.Close()
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = 50 * time.Millisecond
+	c.Retries = 100
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err = c.Put(ctx, "upload.bin", "octet", bytes.NewReader([]byte("hello")))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Put err = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Put took %v to notice cancellation, want well under the %d-retry timeout budget", elapsed, c.Retries)
+	}
+}
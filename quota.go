@@ -0,0 +1,42 @@
+package tftp
+
+// DefaultSoftQuotaThreshold is the fraction of MaxConcurrentTransfers at
+// which OnQuotaWarning fires when Server.SoftQuotaThreshold is left at
+// its zero value.
+const DefaultSoftQuotaThreshold = 0.8
+
+// QuotaWarning describes a resource crossing a configured soft
+// threshold, emitted before the corresponding hard limit starts
+// rejecting requests so operators get an early signal.
+type QuotaWarning struct {
+	Resource string
+	Used     int
+	Limit    int
+}
+
+// checkQuota compares the current transfer count against
+// MaxConcurrentTransfers and fires OnQuotaWarning the moment usage
+// crosses SoftQuotaThreshold, resetting once usage falls back below it
+// so a warning is emitted again on the next approach rather than only
+// once per process lifetime.
+func (s *Server) checkQuota() {
+	if s.MaxConcurrentTransfers <= 0 || s.OnQuotaWarning == nil {
+		return
+	}
+	threshold := s.SoftQuotaThreshold
+	if threshold <= 0 {
+		threshold = DefaultSoftQuotaThreshold
+	}
+	used := int(s.active.Load())
+	if float64(used) >= float64(s.MaxConcurrentTransfers)*threshold {
+		if s.warned.CompareAndSwap(false, true) {
+			s.OnQuotaWarning(QuotaWarning{
+				Resource: "concurrent_transfers",
+				Used:     used,
+				Limit:    s.MaxConcurrentTransfers,
+			})
+		}
+	} else {
+		s.warned.Store(false)
+	}
+}
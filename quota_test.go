@@ -0,0 +1,73 @@
+package tftp
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestServerOnQuotaWarningFiresAtThreshold(t *testing.T) {
+	want := bytes.Repeat([]byte("q"), DefaultBlockSize*4)
+	h := &memHandler{files: map[string][]byte{"file.bin": want}}
+
+	warnings := make(chan QuotaWarning, 4)
+	s := NewServer("", h)
+	s.MaxConcurrentTransfers = 1
+	s.SoftQuotaThreshold = 1
+	s.OnQuotaWarning = func(w QuotaWarning) { warnings <- w }
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s.conn = conn
+	go s.Serve(conn)
+	defer conn.Close()
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	if _, err := c.GetBytes("file.bin", "octet"); err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+
+	select {
+	case w := <-warnings:
+		if w.Resource != "concurrent_transfers" {
+			t.Errorf("Resource = %q, want concurrent_transfers", w.Resource)
+		}
+		if w.Limit != 1 {
+			t.Errorf("Limit = %d, want 1", w.Limit)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for quota warning")
+	}
+}
+
+func TestServerOnQuotaWarningNotCalledBelowThreshold(t *testing.T) {
+	h := &memHandler{files: map[string][]byte{"file.bin": []byte("hi")}}
+
+	called := false
+	s := NewServer("", h)
+	s.MaxConcurrentTransfers = 10
+	s.SoftQuotaThreshold = 0.8
+	s.OnQuotaWarning = func(QuotaWarning) { called = true }
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s.conn = conn
+	go s.Serve(conn)
+	defer conn.Close()
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	if _, err := c.GetBytes("file.bin", "octet"); err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+
+	if called {
+		t.Error("OnQuotaWarning fired below the configured threshold")
+	}
+}
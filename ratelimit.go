@@ -0,0 +1,55 @@
+package tftp
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token-bucket limiter scoped to a single transfer, so
+// Server.RateLimit can cap one session's outbound throughput without a
+// shared limiter causing unrelated transfers to interfere with each
+// other's burst allowance.
+type rateLimiter struct {
+	mu          sync.Mutex
+	bytesPerSec int64
+	tokens      float64
+	last        time.Time
+}
+
+// newRateLimiter returns a rateLimiter capped at bytesPerSec, or nil if
+// bytesPerSec is zero or negative, so "no limit" needs no special-casing
+// at call sites.
+func newRateLimiter(bytesPerSec int64) *rateLimiter {
+	if bytesPerSec <= 0 {
+		return nil
+	}
+	return &rateLimiter{bytesPerSec: bytesPerSec, tokens: float64(bytesPerSec), last: time.Now()}
+}
+
+// wait blocks until n bytes' worth of budget is available, refilling
+// the bucket based on elapsed wall-clock time since the last call. It is
+// a no-op on a nil rateLimiter, so sendData can call it unconditionally
+// whether or not a limit is configured.
+func (rl *rateLimiter) wait(n int) {
+	if rl == nil {
+		return
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	for {
+		now := time.Now()
+		rl.tokens += now.Sub(rl.last).Seconds() * float64(rl.bytesPerSec)
+		rl.last = now
+		if rl.tokens > float64(rl.bytesPerSec) {
+			rl.tokens = float64(rl.bytesPerSec)
+		}
+		if rl.tokens >= float64(n) {
+			rl.tokens -= float64(n)
+			return
+		}
+		sleep := time.Duration((float64(n) - rl.tokens) / float64(rl.bytesPerSec) * float64(time.Second))
+		rl.mu.Unlock()
+		time.Sleep(sleep)
+		rl.mu.Lock()
+	}
+}
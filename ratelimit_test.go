@@ -0,0 +1,111 @@
+package tftp
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterWaitPacesToConfiguredRate(t *testing.T) {
+	rl := newRateLimiter(1024)
+
+	// The initial burst is free: the bucket starts full.
+	start := time.Now()
+	rl.wait(1024)
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Fatalf("first wait took %v, want near-instant (bucket starts full)", elapsed)
+	}
+
+	// A second request for the same amount has to wait for the bucket to
+	// refill, which takes about a second at 1024 bytes/sec.
+	start = time.Now()
+	rl.wait(1024)
+	if elapsed := time.Since(start); elapsed < 500*time.Millisecond {
+		t.Errorf("second wait took %v, want roughly 1s", elapsed)
+	}
+}
+
+func TestNewRateLimiterNilWhenUnlimited(t *testing.T) {
+	if rl := newRateLimiter(0); rl != nil {
+		t.Errorf("newRateLimiter(0) = %v, want nil", rl)
+	}
+	var rl *rateLimiter
+	rl.wait(1 << 20) // must not panic or block
+}
+
+func TestServerGlobalRateLimitSharedAcrossSessions(t *testing.T) {
+	size := DefaultBlockSize * 4
+	h := &memHandler{files: map[string][]byte{
+		"a.bin": bytes.Repeat([]byte("a"), size),
+		"b.bin": bytes.Repeat([]byte("b"), size),
+	}}
+
+	s := NewServer("", h)
+	s.GlobalRateLimit = int64(size) // shared by both downloads below
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s.conn = conn
+	go s.Serve(conn)
+	defer conn.Close()
+
+	start := time.Now()
+	errs := make(chan error, 2)
+	for _, name := range []string{"a.bin", "b.bin"} {
+		name := name
+		go func() {
+			c := NewClient(conn.LocalAddr().String())
+			c.Timeout = time.Second
+			_, err := c.GetBytes(name, "octet")
+			errs <- err
+		}()
+	}
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("GetBytes: %v", err)
+		}
+	}
+	// Together the two transfers move twice GlobalRateLimit's worth of
+	// bytes. If the limit were applied independently per session, each
+	// would fit within its own burst allowance and finish near-instantly;
+	// sharing one bucket means the second transfer to touch it drains
+	// what the first already spent, so the pair takes roughly 1s instead.
+	if elapsed := time.Since(start); elapsed < 700*time.Millisecond {
+		t.Errorf("elapsed = %v, want at least ~1s with a shared global limit", elapsed)
+	}
+}
+
+func TestServerRateLimitSlowsDownload(t *testing.T) {
+	want := bytes.Repeat([]byte("r"), DefaultBlockSize*4)
+	h := &memHandler{files: map[string][]byte{"file.bin": want}}
+
+	s := NewServer("", h)
+	s.RateLimit = int64(DefaultBlockSize) * 2 // half the file's bytes/sec
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s.conn = conn
+	go s.Serve(conn)
+	defer conn.Close()
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+
+	start := time.Now()
+	got, err := c.GetBytes("file.bin", "octet")
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatal("downloaded content did not match")
+	}
+	if elapsed < time.Second {
+		t.Errorf("elapsed = %v, want at least ~1s at half the throttled rate", elapsed)
+	}
+}
@@ -0,0 +1,117 @@
+package tftp
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// readerAtHandler is a Handler that also implements ReaderAtHandler, so
+// an RRQ is served by streaming blocks straight off of the backing
+// bytes.Reader instead of going through ReadFile. Each OpenReaderAt
+// call returns a closingReaderAt so the test can assert the server
+// closes it once the transfer finishes.
+type readerAtHandler struct {
+	mu     sync.Mutex
+	files  map[string][]byte
+	opened int
+	closed int
+}
+
+type closingReaderAt struct {
+	*bytes.Reader
+	h *readerAtHandler
+}
+
+func (c *closingReaderAt) Close() error {
+	c.h.mu.Lock()
+	c.h.closed++
+	c.h.mu.Unlock()
+	return nil
+}
+
+func (h *readerAtHandler) ReadFile(name string) ([]byte, error) {
+	return nil, errors.New("readerAtHandler only serves via OpenReaderAt")
+}
+
+func (h *readerAtHandler) WriteFile(name string, data []byte) error {
+	return errors.New("not supported")
+}
+
+func (h *readerAtHandler) OpenReaderAt(name string) (io.ReaderAt, int64, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	data, ok := h.files[name]
+	if !ok {
+		return nil, 0, errors.New("not found")
+	}
+	h.opened++
+	return &closingReaderAt{Reader: bytes.NewReader(data), h: h}, int64(len(data)), nil
+}
+
+func TestServeRRQStreamsFromReaderAtHandler(t *testing.T) {
+	want := bytes.Repeat([]byte("r"), DefaultBlockSize*3+7)
+	h := &readerAtHandler{files: map[string][]byte{"big.bin": want}}
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s := NewServer("", h)
+	s.conn = conn
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	got, err := c.GetBytes("big.bin", "octet")
+	if err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("got %d bytes, want %d bytes matching", len(got), len(want))
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		h.mu.Lock()
+		opened, closed := h.opened, h.closed
+		h.mu.Unlock()
+		if opened == 1 && closed == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("opened = %d, closed = %d, want 1 and 1", opened, closed)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServeRRQWithPrefetchBlocksMatchesUnprefetched(t *testing.T) {
+	want := bytes.Repeat([]byte("p"), DefaultBlockSize*5+3)
+	h := &readerAtHandler{files: map[string][]byte{"big.bin": want}}
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s := NewServer("", h)
+	s.PrefetchBlocks = 4
+	s.conn = conn
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	got, err := c.GetBytes("big.bin", "octet")
+	if err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("got %d bytes, want %d bytes matching", len(got), len(want))
+	}
+}
@@ -0,0 +1,59 @@
+package tftp
+
+import "io"
+
+// ReaderAtBlockIterator is a blockSource that reads each block directly
+// from an io.ReaderAt at its byte offset, on demand, instead of holding
+// the whole transfer in memory — the only practical way to serve a
+// multi-GB file. Reading by offset rather than sequentially also means
+// retransmitting a block (the same index, re-read after a timeout or a
+// duplicate ACK) just re-reads the same bytes; there's no cursor to
+// rewind.
+type ReaderAtBlockIterator struct {
+	r         io.ReaderAt
+	size      int64
+	blockSize int
+	n         int
+}
+
+// NewReaderAtBlockIterator returns a ReaderAtBlockIterator over the
+// first size bytes readable from r, split into blockSize-sized blocks
+// with the same trailing-short-block EOF convention as BlockIterator.
+func NewReaderAtBlockIterator(r io.ReaderAt, size int64, blockSize int) *ReaderAtBlockIterator {
+	n := int((size + int64(blockSize) - 1) / int64(blockSize))
+	if size%int64(blockSize) == 0 {
+		n++
+	}
+	return &ReaderAtBlockIterator{r: r, size: size, blockSize: blockSize, n: n}
+}
+
+// Len returns the total number of blocks, including the trailing EOF
+// block.
+func (bi *ReaderAtBlockIterator) Len() int {
+	return bi.n
+}
+
+// Block reads and returns the payload for the i'th block (0-indexed).
+// It panics if i is out of range, the same contract slice indexing has.
+func (bi *ReaderAtBlockIterator) Block(i int) ([]byte, error) {
+	start := int64(i) * int64(bi.blockSize)
+	if start > bi.size || i < 0 {
+		panic("tftp: ReaderAtBlockIterator index out of range")
+	}
+	end := start + int64(bi.blockSize)
+	if end > bi.size {
+		end = bi.size
+	}
+	buf := make([]byte, end-start)
+	if len(buf) == 0 {
+		return buf, nil
+	}
+	// ReadAt is permitted to return io.EOF alongside a full read when
+	// the read ends exactly at the end of the underlying data; only
+	// treat it as an error if fewer bytes came back than requested.
+	n, err := bi.r.ReadAt(buf, start)
+	if n < len(buf) {
+		return nil, err
+	}
+	return buf, nil
+}
@@ -0,0 +1,35 @@
+package tftp
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReaderAtBlockIteratorMatchesBlockIterator(t *testing.T) {
+	data := []byte("abcdefghij")
+	r := bytes.NewReader(data)
+	bi := NewReaderAtBlockIterator(r, int64(len(data)), 4)
+	want := NewBlockIterator(data, 4)
+
+	if got, wantLen := bi.Len(), want.Len(); got != wantLen {
+		t.Fatalf("Len() = %d, want %d", got, wantLen)
+	}
+	for i := 0; i < want.Len(); i++ {
+		got := block(t, bi, i)
+		wantBlock := block(t, want, i)
+		if !bytes.Equal(got, wantBlock) {
+			t.Errorf("Block(%d) = %q, want %q", i, got, wantBlock)
+		}
+	}
+}
+
+func TestReaderAtBlockIteratorBlockIsDeterministic(t *testing.T) {
+	data := []byte("abcdefgh")
+	bi := NewReaderAtBlockIterator(bytes.NewReader(data), int64(len(data)), 4)
+
+	first := block(t, bi, 0)
+	second := block(t, bi, 0)
+	if !bytes.Equal(first, second) {
+		t.Fatalf("Block(0) returned %q then %q, want identical results for a retransmit", first, second)
+	}
+}
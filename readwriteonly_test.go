@@ -0,0 +1,58 @@
+package tftp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestServerWriteOnlyRejectsRRQ(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	h := &memHandler{files: map[string][]byte{"file.bin": []byte("data")}}
+	s := NewServer("", h)
+	s.WriteOnly = true
+	s.conn = conn
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	_, err = c.GetBytes("file.bin", "octet")
+	var tftpErr *ERROR
+	if !errors.As(err, &tftpErr) {
+		t.Fatalf("err = %v, want *ERROR", err)
+	}
+	if tftpErr.Code != ErrCodeAccessViolation {
+		t.Errorf("Code = %v, want %v", tftpErr.Code, ErrCodeAccessViolation)
+	}
+}
+
+func TestServerReadOnlyRejectsWRQ(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	h := &memHandler{files: map[string][]byte{}}
+	s := NewServer("", h)
+	s.ReadOnly = true
+	s.conn = conn
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	_, err = c.Put(context.Background(), "upload.bin", "octet", bytes.NewReader([]byte("data")))
+	var tftpErr *ERROR
+	if !errors.As(err, &tftpErr) {
+		t.Fatalf("err = %v, want *ERROR", err)
+	}
+	if tftpErr.Code != ErrCodeAccessViolation {
+		t.Errorf("Code = %v, want %v", tftpErr.Code, ErrCodeAccessViolation)
+	}
+}
@@ -0,0 +1,104 @@
+package tftp
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestReceiveDataFromAtWritesReorderedBlocksCorrectly(t *testing.T) {
+	client, server := newFakePeer(t)
+	clientAddr := client.LocalAddr().(*net.UDPAddr)
+
+	blockSize := 8
+	nego := NegotiatedOptions{BlockSize: blockSize, WindowSize: 4}
+	first := &DATA{Block: 1, Payload: []byte("AAAAAAAA")}
+
+	wat := &sliceWriterAt{buf: make([]byte, 3*blockSize+1)}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- receiveDataFromAt(client, server.LocalAddr().(*net.UDPAddr), nego, time.Second, 3, first, wat, nil, -1, nil, nil, nil, nil)
+	}()
+
+	ackBuf := make([]byte, 4)
+	mustReadACK(t, server, clientAddr, ackBuf) // ACK for block 1
+
+	// Deliver block 3 before block 2 — reordered, but still within the
+	// negotiated window.
+	third := &DATA{Block: 3, Payload: []byte("CCCCCCCC")}
+	thirdBytes, _ := third.MarshalBinary()
+	server.WriteToUDP(thirdBytes, clientAddr)
+
+	second := &DATA{Block: 2, Payload: []byte("BBBBBBBB")}
+	secondBytes, _ := second.MarshalBinary()
+	server.WriteToUDP(secondBytes, clientAddr)
+	mustReadACK(t, server, clientAddr, ackBuf) // ACK for block 2
+	mustReadACK(t, server, clientAddr, ackBuf) // ACK for block 3, flushed immediately after
+
+	// A short final block ends the transfer.
+	last := &DATA{Block: 4, Payload: []byte("D")}
+	lastBytes, _ := last.MarshalBinary()
+	server.WriteToUDP(lastBytes, clientAddr)
+	mustReadACK(t, server, clientAddr, ackBuf)
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("receiveDataFromAt: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for receiveDataFromAt")
+	}
+
+	want := "AAAAAAAA" + "BBBBBBBB" + "CCCCCCCC" + "D"
+	if got := string(wat.buf[:len(want)]); got != want {
+		t.Fatalf("written bytes = %q, want %q", got, want)
+	}
+}
+
+func TestReceiveDataFromAtErrorsOnGapWiderThanWindow(t *testing.T) {
+	client, server := newFakePeer(t)
+	clientAddr := client.LocalAddr().(*net.UDPAddr)
+
+	blockSize := 8
+	nego := NegotiatedOptions{BlockSize: blockSize, WindowSize: 2}
+	first := &DATA{Block: 1, Payload: []byte("AAAAAAAA")}
+
+	wat := &sliceWriterAt{buf: make([]byte, 4*blockSize)}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- receiveDataFromAt(client, server.LocalAddr().(*net.UDPAddr), nego, time.Second, 3, first, wat, nil, -1, nil, nil, nil, nil)
+	}()
+
+	ackBuf := make([]byte, 4)
+	mustReadACK(t, server, clientAddr, ackBuf) // ACK for block 1
+
+	// Block 4 is two blocks past the edge of a window of size 2
+	// (block 2 would be the last one still inside it), so this is a
+	// genuine gap, not mere reordering.
+	gap := &DATA{Block: 4, Payload: []byte("gap")}
+	gapBytes, _ := gap.MarshalBinary()
+	server.WriteToUDP(gapBytes, clientAddr)
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("expected an error for a gap wider than the window")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for receiveDataFromAt to report the gap")
+	}
+}
+
+// sliceWriterAt is a minimal io.WriterAt backed by an in-memory slice,
+// standing in for the *os.File GetFile writes into.
+type sliceWriterAt struct {
+	buf []byte
+}
+
+func (w *sliceWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	n := copy(w.buf[off:], p)
+	return n, nil
+}
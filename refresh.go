@@ -0,0 +1,168 @@
+package tftp
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// RefreshFunc produces the Handler a RefreshingHandler should serve
+// from. It's called once at construction and then again on every
+// refresh tick, and is deliberately unopinionated about where content
+// comes from — an HTTP fetch, an rsynced directory, anything shaped
+// like a Handler — since RefreshingHandler's only job is swapping the
+// result in on a schedule.
+type RefreshFunc func() (Handler, error)
+
+// RefreshingHandler wraps a Handler that's rebuilt from a RefreshFunc
+// on a fixed interval and swapped in atomically, so an edge boot server
+// stays current with its upstream source without an external cron job
+// bouncing the process. A failed refresh leaves the previously active
+// Handler serving unchanged; OnRefreshError, if set, is still notified
+// so an embedder can alert even though service continues
+// uninterrupted.
+type RefreshingHandler struct {
+	refresh RefreshFunc
+
+	onRefreshError atomic.Pointer[func(error)]
+
+	current atomic.Pointer[Handler]
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// SetOnRefreshError registers fn to be called with the error from any
+// refresh after the first (the initial one is returned directly by
+// NewRefreshingHandler instead). It's an atomic.Pointer-backed setter
+// rather than a plain exported field because the background refresh
+// loop starts inside NewRefreshingHandler itself and could otherwise
+// read the callback concurrently with a caller still assigning it; safe
+// to call at any time, including while the loop is running.
+func (rh *RefreshingHandler) SetOnRefreshError(fn func(error)) {
+	rh.onRefreshError.Store(&fn)
+}
+
+// NewRefreshingHandler calls refresh once to populate the initial
+// content, then starts a background goroutine that calls it again
+// every interval until Close is called. It returns an error if the
+// initial call fails, since a RefreshingHandler with nothing to serve
+// yet isn't one an embedder can hand to Server.Handler.
+func NewRefreshingHandler(refresh RefreshFunc, interval time.Duration) (*RefreshingHandler, error) {
+	h, err := refresh()
+	if err != nil {
+		return nil, fmt.Errorf("tftp: initial refresh: %w", err)
+	}
+	rh := &RefreshingHandler{
+		refresh: refresh,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	rh.current.Store(&h)
+	go rh.loop(interval)
+	return rh, nil
+}
+
+func (rh *RefreshingHandler) loop(interval time.Duration) {
+	defer close(rh.done)
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-rh.stop:
+			return
+		case <-t.C:
+			h, err := rh.refresh()
+			if err != nil {
+				if fn := rh.onRefreshError.Load(); fn != nil {
+					(*fn)(err)
+				}
+				continue
+			}
+			rh.current.Store(&h)
+		}
+	}
+}
+
+// ReadFile implements Handler, delegating to whichever Handler is
+// currently active.
+func (rh *RefreshingHandler) ReadFile(filename string) ([]byte, error) {
+	return (*rh.current.Load()).ReadFile(filename)
+}
+
+// WriteFile implements Handler, delegating to whichever Handler is
+// currently active.
+func (rh *RefreshingHandler) WriteFile(filename string, data []byte) error {
+	return (*rh.current.Load()).WriteFile(filename, data)
+}
+
+// Close stops the background refresh loop, blocking until it has
+// exited. It does not close or otherwise touch whatever Handler is
+// currently active.
+func (rh *RefreshingHandler) Close() error {
+	close(rh.stop)
+	<-rh.done
+	return nil
+}
+
+// DirRefreshFunc returns a RefreshFunc for the directory-sync case: an
+// external process (rsync, a CI deploy step) updates dir's contents out
+// of band, and RefreshingHandler's job is just to notice it on its own
+// schedule rather than needing a SIGHUP. Each call returns a fresh
+// *FileHandler rooted at dir, so a refresh picks up whatever dir
+// contains at that moment.
+func DirRefreshFunc(dir string) RefreshFunc {
+	return func() (Handler, error) {
+		if _, err := os.Stat(dir); err != nil {
+			return nil, err
+		}
+		return &FileHandler{Dir: dir}, nil
+	}
+}
+
+// HTTPRefreshFunc returns a RefreshFunc for the single-artifact case: a
+// source that publishes one boot artifact (a kernel, an installer
+// image) at a stable URL rather than a whole tree. filename is the name
+// clients must request to receive whatever url last returned.
+func HTTPRefreshFunc(client *http.Client, url, filename string) RefreshFunc {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return func() (Handler, error) {
+		resp, err := client.Get(url)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("tftp: fetching %s: unexpected status %s", url, resp.Status)
+		}
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return &mapHandler{files: map[string][]byte{filename: data}}, nil
+	}
+}
+
+// mapHandler is a minimal read-only, in-memory Handler backing
+// HTTPRefreshFunc's snapshots. It isn't exported: embedders wanting a
+// general-purpose in-memory Handler for their own tests already have
+// tftptest.MemHandler.
+type mapHandler struct {
+	files map[string][]byte
+}
+
+func (h *mapHandler) ReadFile(filename string) ([]byte, error) {
+	data, ok := h.files[filename]
+	if !ok {
+		return nil, fmt.Errorf("tftp: %s: %w", filename, os.ErrNotExist)
+	}
+	return data, nil
+}
+
+func (h *mapHandler) WriteFile(filename string, data []byte) error {
+	return fmt.Errorf("tftp: HTTPRefreshFunc's handler is read-only")
+}
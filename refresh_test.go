@@ -0,0 +1,183 @@
+package tftp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRefreshingHandlerSwapsContentOnInterval(t *testing.T) {
+	var gen atomic.Int64
+	refresh := func() (Handler, error) {
+		n := gen.Add(1)
+		return &memHandler{files: map[string][]byte{
+			"file.bin": []byte(fmt.Sprintf("version %d", n)),
+		}}, nil
+	}
+
+	rh, err := NewRefreshingHandler(refresh, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewRefreshingHandler: %v", err)
+	}
+	defer rh.Close()
+
+	got, err := rh.ReadFile("file.bin")
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(got) != "version 1" {
+		t.Fatalf("ReadFile = %q, want %q", got, "version 1")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		got, err = rh.ReadFile("file.bin")
+		if err != nil {
+			t.Fatalf("ReadFile: %v", err)
+		}
+		if string(got) != "version 1" {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if string(got) == "version 1" {
+		t.Fatal("content never refreshed past the initial version")
+	}
+}
+
+func TestRefreshingHandlerKeepsPreviousContentOnFailedRefresh(t *testing.T) {
+	calls := 0
+	refresh := func() (Handler, error) {
+		calls++
+		if calls == 1 {
+			return &memHandler{files: map[string][]byte{"file.bin": []byte("good")}}, nil
+		}
+		return nil, errors.New("upstream unavailable")
+	}
+
+	errs := make(chan error, 1)
+	rh, err := NewRefreshingHandler(refresh, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewRefreshingHandler: %v", err)
+	}
+	rh.SetOnRefreshError(func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	})
+	defer rh.Close()
+
+	select {
+	case <-errs:
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("OnRefreshError was never called")
+	}
+
+	got, err := rh.ReadFile("file.bin")
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(got) != "good" {
+		t.Fatalf("ReadFile = %q, want the last successful content %q", got, "good")
+	}
+}
+
+func TestNewRefreshingHandlerFailsOnInitialRefreshError(t *testing.T) {
+	refresh := func() (Handler, error) { return nil, errors.New("boom") }
+	if _, err := NewRefreshingHandler(refresh, time.Second); err == nil {
+		t.Fatal("NewRefreshingHandler: want an error when the initial refresh fails")
+	}
+}
+
+func TestDirRefreshFuncServesCurrentDirContents(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "file.bin"), []byte("v1"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	refresh := DirRefreshFunc(dir)
+	rh, err := NewRefreshingHandler(refresh, time.Hour)
+	if err != nil {
+		t.Fatalf("NewRefreshingHandler: %v", err)
+	}
+	defer rh.Close()
+
+	got, err := rh.ReadFile("file.bin")
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(got) != "v1" {
+		t.Fatalf("ReadFile = %q, want %q", got, "v1")
+	}
+}
+
+func TestHTTPRefreshFuncFetchesAndServesBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("boot image bytes"))
+	}))
+	defer srv.Close()
+
+	refresh := HTTPRefreshFunc(nil, srv.URL, "boot.img")
+	h, err := refresh()
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	got, err := h.ReadFile("boot.img")
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if !bytes.Equal(got, []byte("boot image bytes")) {
+		t.Fatalf("ReadFile = %q, want %q", got, "boot image bytes")
+	}
+}
+
+func TestHTTPRefreshFuncRejectsNonOKStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	refresh := HTTPRefreshFunc(nil, srv.URL, "boot.img")
+	if _, err := refresh(); err == nil {
+		t.Fatal("refresh: want an error for a non-200 response")
+	}
+}
+
+func TestServerServesThroughRefreshingHandler(t *testing.T) {
+	refresh := func() (Handler, error) {
+		return &memHandler{files: map[string][]byte{"file.bin": []byte("served content")}}, nil
+	}
+	rh, err := NewRefreshingHandler(refresh, time.Hour)
+	if err != nil {
+		t.Fatalf("NewRefreshingHandler: %v", err)
+	}
+	defer rh.Close()
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s := NewServer("", rh)
+	s.conn = conn
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	got, err := c.GetBytes("file.bin", "octet")
+	if err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+	if string(got) != "served content" {
+		t.Fatalf("GetBytes = %q, want %q", got, "served content")
+	}
+}
@@ -0,0 +1,93 @@
+package tftp
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+)
+
+func newRelayServer(t *testing.T, h Handler, upstream string) string {
+	t.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s := NewServer("", h)
+	s.Upstream = upstream
+	s.conn = conn
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+	return conn.LocalAddr().String()
+}
+
+func TestServeRRQRelaysToUpstreamOnLocalMiss(t *testing.T) {
+	want := bytes.Repeat([]byte("u"), DefaultBlockSize*2+3)
+	upstreamAddr, _ := startTestServer(t, map[string][]byte{"shared.img": want})
+
+	branchAddr := newRelayServer(t, &memHandler{files: map[string][]byte{}}, upstreamAddr)
+
+	c := NewClient(branchAddr)
+	c.Timeout = time.Second
+	got, err := c.GetBytes("shared.img", "octet")
+	if err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("got %d bytes, want %d bytes matching upstream", len(got), len(want))
+	}
+}
+
+func TestServeRRQCachesFromUpstreamWhenEnabled(t *testing.T) {
+	want := bytes.Repeat([]byte("c"), DefaultBlockSize+5)
+	upstreamAddr, upstreamHandler := startTestServer(t, map[string][]byte{"shared.img": want})
+
+	branchHandler := &memHandler{files: map[string][]byte{}}
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s := NewServer("", branchHandler)
+	s.Upstream = upstreamAddr
+	s.CacheUpstream = true
+	s.conn = conn
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	if _, err := c.GetBytes("shared.img", "octet"); err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	var cached []byte
+	var ok bool
+	for time.Now().Before(deadline) {
+		if cached, ok = branchHandler.get("shared.img"); ok {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !ok {
+		t.Fatal("branch server never cached shared.img locally")
+	}
+	if !bytes.Equal(cached, want) {
+		t.Fatalf("cached %d bytes, want %d matching upstream", len(cached), len(want))
+	}
+
+	// The second request should now be served locally rather than
+	// relayed again, so it must still succeed even after the upstream
+	// server is gone.
+	upstreamHandler.mu.Lock()
+	delete(upstreamHandler.files, "shared.img")
+	upstreamHandler.mu.Unlock()
+
+	got, err := c.GetBytes("shared.img", "octet")
+	if err != nil {
+		t.Fatalf("second GetBytes: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("second GetBytes = %d bytes, want %d matching the cached copy", len(got), len(want))
+	}
+}
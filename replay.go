@@ -0,0 +1,50 @@
+package tftp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// LoadRecording reads the JSON-lines format a SessionRecorder writes,
+// returning the recorded datagrams in capture order.
+func LoadRecording(r io.Reader) ([]RecordedPacket, error) {
+	var out []RecordedPacket
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for sc.Scan() {
+		line := sc.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var p RecordedPacket
+		if err := json.Unmarshal(line, &p); err != nil {
+			return nil, fmt.Errorf("tftp: load recording: %w", err)
+		}
+		out = append(out, p)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("tftp: load recording: %w", err)
+	}
+	return out, nil
+}
+
+// ReplayPackets decodes every recorded datagram's payload back into a
+// Packet, in capture order, so a bug report like "device X aborts at
+// block 213" can be stepped through against the same ParsePacket the
+// original live session used, without needing to reproduce the original
+// network conversation. An entry whose payload doesn't decode returns
+// an error identifying its position, rather than silently skipping a
+// malformed or truncated capture.
+func ReplayPackets(entries []RecordedPacket) ([]Packet, error) {
+	packets := make([]Packet, 0, len(entries))
+	for i, e := range entries {
+		p, err := ParsePacket(e.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("tftp: replay: entry %d (%s %s): %w", i, e.Direction, e.Addr, err)
+		}
+		packets = append(packets, p)
+	}
+	return packets, nil
+}
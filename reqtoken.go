@@ -0,0 +1,78 @@
+package tftp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tokenFilenamePrefix marks a filename as using the signed, expiring
+// request token convention: "token.<hex signature>.<unix expiry>/<path>".
+// Unlike Server.AuthKey, which signs a request over the wire via
+// OptAuthTimestamp/OptAuthSignature, this convention carries everything
+// needed to verify it inside the filename itself, so a signed,
+// time-limited link to a sensitive image can be handed out (e.g.
+// embedded in a provisioning config) without the issuer needing to run
+// a TFTP client to add auth options — any RFC 1350 client can fetch it
+// as-is.
+const tokenFilenamePrefix = "token."
+
+// SignedFilename returns the filename a client must request to fetch
+// path under the token. convention, signed with key and valid until
+// expiry. Server.TokenKey, set to the same key, verifies it.
+func SignedFilename(key []byte, path string, expiry time.Time) string {
+	exp := expiry.Unix()
+	sig := signTokenFilename(key, path, exp)
+	return fmt.Sprintf("%s%s.%d/%s", tokenFilenamePrefix, sig, exp, path)
+}
+
+// signTokenFilename computes the hex-encoded HMAC-SHA256 over path and
+// exp keyed by key, the construction both SignedFilename (to sign) and
+// verifyTokenFilename (to verify) use.
+func signTokenFilename(key []byte, path string, exp int64) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(path))
+	mac.Write([]byte(":"))
+	mac.Write([]byte(strconv.FormatInt(exp, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifyTokenFilename reports whether filename uses the token.
+// convention with a signature valid for key and not yet past its
+// embedded expiry, returning the real path it authorizes once
+// unwrapped. ok is false both when filename doesn't use the convention
+// at all and when it does but fails verification — the caller can't
+// tell those apart from the return value alone, by design, so a probe
+// for "is this the token convention" can't be distinguished from "is
+// this a valid token" by timing or response shape.
+func verifyTokenFilename(key []byte, filename string, now time.Time) (path string, ok bool) {
+	rest, ok := strings.CutPrefix(filename, tokenFilenamePrefix)
+	if !ok {
+		return "", false
+	}
+	parts := strings.SplitN(rest, "/", 2)
+	if len(parts) != 2 {
+		return "", false
+	}
+	header, path := parts[0], parts[1]
+	sig, expStr, ok := strings.Cut(header, ".")
+	if !ok {
+		return "", false
+	}
+	exp, err := strconv.ParseInt(expStr, 10, 64)
+	if err != nil {
+		return "", false
+	}
+	if now.After(time.Unix(exp, 0)) {
+		return "", false
+	}
+	want := signTokenFilename(key, path, exp)
+	if !hmac.Equal([]byte(want), []byte(sig)) {
+		return "", false
+	}
+	return path, true
+}
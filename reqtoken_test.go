@@ -0,0 +1,136 @@
+package tftp
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSignedFilenameRoundTrips(t *testing.T) {
+	key := []byte("secret")
+	name := SignedFilename(key, "images/router.img", time.Now().Add(time.Hour))
+
+	path, ok := verifyTokenFilename(key, name, time.Now())
+	if !ok {
+		t.Fatalf("verifyTokenFilename(%q) ok = false, want true", name)
+	}
+	if path != "images/router.img" {
+		t.Errorf("verifyTokenFilename(%q) path = %q, want %q", name, path, "images/router.img")
+	}
+}
+
+func TestVerifyTokenFilenameRejectsExpired(t *testing.T) {
+	key := []byte("secret")
+	name := SignedFilename(key, "images/router.img", time.Now().Add(-time.Minute))
+
+	if _, ok := verifyTokenFilename(key, name, time.Now()); ok {
+		t.Fatal("verifyTokenFilename: want false for an expired token")
+	}
+}
+
+func TestVerifyTokenFilenameRejectsWrongKey(t *testing.T) {
+	name := SignedFilename([]byte("secret"), "images/router.img", time.Now().Add(time.Hour))
+
+	if _, ok := verifyTokenFilename([]byte("different"), name, time.Now()); ok {
+		t.Fatal("verifyTokenFilename: want false for a signature from the wrong key")
+	}
+}
+
+func TestVerifyTokenFilenameRejectsTamperedPath(t *testing.T) {
+	key := []byte("secret")
+	name := SignedFilename(key, "images/router.img", time.Now().Add(time.Hour))
+
+	tampered := name[:len(name)-len("router.img")] + "other.img"
+	if _, ok := verifyTokenFilename(key, tampered, time.Now()); ok {
+		t.Fatal("verifyTokenFilename: want false once the wrapped path is tampered with")
+	}
+}
+
+func TestVerifyTokenFilenameIgnoresPlainFilenames(t *testing.T) {
+	if _, ok := verifyTokenFilename([]byte("secret"), "plain.img", time.Now()); ok {
+		t.Fatal("verifyTokenFilename: want false for a filename that never used the token convention")
+	}
+}
+
+func TestServerServesValidSignedFilename(t *testing.T) {
+	key := []byte("secret")
+	want := []byte("router firmware bytes")
+	h := &memHandler{files: map[string][]byte{"images/router.img": want}}
+
+	s := NewServer("", h)
+	s.TokenKey = key
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s.conn = conn
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	name := SignedFilename(key, "images/router.img", time.Now().Add(time.Minute))
+	got, err := c.GetBytes(name, "octet")
+	if err != nil {
+		t.Fatalf("GetBytes(%q): %v", name, err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("GetBytes = %q, want %q", got, want)
+	}
+}
+
+func TestServerRejectsExpiredOrForgedSignedFilename(t *testing.T) {
+	key := []byte("secret")
+	h := &memHandler{files: map[string][]byte{"images/router.img": []byte("x")}}
+
+	s := NewServer("", h)
+	s.TokenKey = key
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s.conn = conn
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+
+	cases := []string{
+		SignedFilename(key, "images/router.img", time.Now().Add(-time.Minute)),
+		SignedFilename([]byte("wrong-key"), "images/router.img", time.Now().Add(time.Minute)),
+		"token.deadbeef.9999999999/images/router.img",
+	}
+	for _, name := range cases {
+		if _, err := c.GetBytes(name, "octet"); err == nil {
+			t.Errorf("GetBytes(%q): want an error", name)
+		}
+	}
+}
+
+func TestServerServesUnsignedFilenamesNormallyWhenTokenKeySet(t *testing.T) {
+	h := &memHandler{files: map[string][]byte{"public.txt": []byte("public content")}}
+
+	s := NewServer("", h)
+	s.TokenKey = []byte("secret")
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s.conn = conn
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	got, err := c.GetBytes("public.txt", "octet")
+	if err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+	if string(got) != "public content" {
+		t.Errorf("GetBytes = %q, want %q", got, "public content")
+	}
+}
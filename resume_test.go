@@ -0,0 +1,63 @@
+package tftp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestClientGetResumeContinuesFromOffset(t *testing.T) {
+	want := bytes.Repeat([]byte("g"), DefaultBlockSize*3+200)
+	addr, _ := startTestServer(t, map[string][]byte{"image.bin": want})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+	c.BlockSize = 512
+
+	offset := int64(1536) // a clean multiple of BlockSize
+	rc, actualOffset, err := c.GetResume(context.Background(), "image.bin", "octet", offset)
+	if err != nil {
+		t.Fatalf("GetResume: %v", err)
+	}
+	defer rc.Close()
+
+	if actualOffset != offset {
+		t.Fatalf("actualOffset = %d, want %d", actualOffset, offset)
+	}
+
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if !bytes.Equal(got, want[offset:]) {
+		t.Fatalf("resumed download returned %d bytes, want %d", len(got), len(want)-int(offset))
+	}
+}
+
+func TestClientGetResumeFallsBackPastEOF(t *testing.T) {
+	want := bytes.Repeat([]byte("h"), DefaultBlockSize)
+	addr, _ := startTestServer(t, map[string][]byte{"image.bin": want})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+
+	rc, actualOffset, err := c.GetResume(context.Background(), "image.bin", "octet", int64(len(want)*2))
+	if err != nil {
+		t.Fatalf("GetResume: %v", err)
+	}
+	defer rc.Close()
+
+	if actualOffset != 0 {
+		t.Fatalf("actualOffset = %d, want 0 (offset past EOF should be ignored)", actualOffset)
+	}
+
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("fallback download returned %d bytes, want the full %d-byte file", len(got), len(want))
+	}
+}
@@ -0,0 +1,53 @@
+package tftp
+
+import (
+	"bytes"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestClientGetRetransmitsOnTimeout drops the first RRQ a "flaky" server
+// sees and only answers from the second attempt onward, verifying the
+// client resends its request instead of just re-reading.
+func TestClientGetRetransmitsOnTimeout(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer conn.Close()
+
+	want := []byte("hello, world")
+	var seen atomic.Int32
+
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			_, addr, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			if seen.Add(1) == 1 {
+				// Drop the first request entirely to force a retransmit.
+				continue
+			}
+			writePacket(conn, &DATA{Block: 1, Payload: want}, addr, nil, nil)
+		}
+	}()
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = 50 * time.Millisecond
+	c.Retries = 5
+
+	got, err := c.GetBytes("file.bin", "octet")
+	if err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("GetBytes = %q, want %q", got, want)
+	}
+	if seen.Load() < 2 {
+		t.Fatalf("server saw %d requests, want at least 2 (client should have retransmitted)", seen.Load())
+	}
+}
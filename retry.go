@@ -0,0 +1,89 @@
+package tftp
+
+import (
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy decides how long a retransmission loop waits before its
+// next attempt. NextDelay is called with the 0-indexed attempt number
+// (0 for the very first wait) and returns the delay to use next and
+// whether the loop should keep retrying at all; returning false lets a
+// policy cut a transfer short on its own terms (e.g. after a fixed
+// number of attempts regardless of how many Client.Retries/the server's
+// retries parameter still allows), instead of every caller having to
+// encode that limit itself.
+type RetryPolicy interface {
+	NextDelay(attempt int) (delay time.Duration, ok bool)
+}
+
+// ConstantRetryPolicy waits the same Delay before every attempt,
+// forever. Fine for a stable link where backoff buys nothing.
+type ConstantRetryPolicy struct {
+	Delay time.Duration
+}
+
+// NextDelay implements RetryPolicy.
+func (p ConstantRetryPolicy) NextDelay(attempt int) (time.Duration, bool) {
+	return p.Delay, true
+}
+
+// ExponentialRetryPolicy doubles Base per attempt, capped at Max (or at
+// maxBackoff if Max is zero). This is the shape backoffDelay used
+// before RetryPolicy existed: it tolerates a single lost packet quickly
+// while backing off hard against a link that's down for longer.
+type ExponentialRetryPolicy struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// NextDelay implements RetryPolicy.
+func (p ExponentialRetryPolicy) NextDelay(attempt int) (time.Duration, bool) {
+	max := p.Max
+	if max <= 0 {
+		max = maxBackoff
+	}
+	d := p.Base
+	for i := 0; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d, true
+}
+
+// JitteredRetryPolicy wraps Policy and adds up to 25% random jitter to
+// whatever delay it returns, so many clients retrying against the same
+// server after a shared outage don't all retransmit in lockstep.
+type JitteredRetryPolicy struct {
+	Policy RetryPolicy
+}
+
+// NextDelay implements RetryPolicy.
+func (p JitteredRetryPolicy) NextDelay(attempt int) (time.Duration, bool) {
+	d, ok := p.Policy.NextDelay(attempt)
+	if !ok || d <= 0 {
+		return d, ok
+	}
+	return d + time.Duration(rand.Int63n(int64(d)/4+1)), true
+}
+
+// defaultRetryPolicy reproduces backoffDelay's historical behavior —
+// base doubling per attempt up to maxBackoff, jittered by up to 25% —
+// and is what Client and Server fall back to when RetryPolicy is unset,
+// so leaving it unset keeps existing deployments behaving exactly as
+// before.
+func defaultRetryPolicy(base time.Duration) RetryPolicy {
+	return JitteredRetryPolicy{Policy: ExponentialRetryPolicy{Base: base, Max: maxBackoff}}
+}
+
+// retryDelay resolves policy (falling back to defaultRetryPolicy(base)
+// when nil) and returns the deadline duration for attempt, along with
+// whether the caller should retry at all.
+func retryDelay(policy RetryPolicy, base time.Duration, attempt int) (time.Duration, bool) {
+	if policy == nil {
+		policy = defaultRetryPolicy(base)
+	}
+	return policy.NextDelay(attempt)
+}
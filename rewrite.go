@@ -0,0 +1,52 @@
+package tftp
+
+import (
+	"regexp"
+	"strings"
+)
+
+// FilenameRule rewrites a requested filename before it reaches the
+// backend (Handler, PayloadProvider, and every Validator), so legacy
+// clients hard-coded to a fixed path layout — e.g. PXE firmware that
+// always requests "/tftpboot/pxelinux.0" — can be mapped onto wherever
+// the server actually keeps that content. Exactly one of Exact, Prefix,
+// or Regexp should be set per rule; Server.FilenameRules are tried in
+// order and the first match wins, leaving the filename untouched if
+// none match.
+type FilenameRule struct {
+	// Exact rewrites filename to Replacement when it matches exactly.
+	Exact string
+	// Prefix rewrites filename when it starts with Prefix, splicing
+	// Replacement in for just the matched prefix and leaving the rest
+	// of the filename untouched.
+	Prefix string
+	// Regexp rewrites filename when it matches, expanding Replacement
+	// as a regexp.ReplaceAllString template (so "$1", "${name}", etc.
+	// refer to Regexp's capture groups).
+	Regexp *regexp.Regexp
+	// Replacement is the resulting filename (Exact, Prefix) or
+	// replacement template (Regexp).
+	Replacement string
+}
+
+// rewriteFilename applies the first rule in s.FilenameRules that
+// matches filename, or returns filename unchanged if none do.
+func (s *Server) rewriteFilename(filename string) string {
+	for _, rule := range s.filenameRules() {
+		switch {
+		case rule.Regexp != nil:
+			if rule.Regexp.MatchString(filename) {
+				return rule.Regexp.ReplaceAllString(filename, rule.Replacement)
+			}
+		case rule.Prefix != "":
+			if strings.HasPrefix(filename, rule.Prefix) {
+				return rule.Replacement + filename[len(rule.Prefix):]
+			}
+		case rule.Exact != "":
+			if filename == rule.Exact {
+				return rule.Replacement
+			}
+		}
+	}
+	return filename
+}
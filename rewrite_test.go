@@ -0,0 +1,63 @@
+package tftp
+
+import (
+	"net"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestRewriteFilenameExactPrefixRegexp(t *testing.T) {
+	s := &Server{FilenameRules: []FilenameRule{
+		{Exact: "/tftpboot/pxelinux.0", Replacement: "boot/pxelinux.0"},
+		{Prefix: "/tftpboot/", Replacement: "boot/"},
+		{Regexp: regexp.MustCompile(`^legacy-(.+)\.img$`), Replacement: "images/$1.img"},
+	}}
+
+	cases := []struct{ in, want string }{
+		{"/tftpboot/pxelinux.0", "boot/pxelinux.0"},
+		{"/tftpboot/grub.cfg", "boot/grub.cfg"},
+		{"legacy-router42.img", "images/router42.img"},
+		{"unrelated.bin", "unrelated.bin"},
+	}
+	for _, c := range cases {
+		if got := s.rewriteFilename(c.in); got != c.want {
+			t.Errorf("rewriteFilename(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestRewriteFilenameFirstRuleWins(t *testing.T) {
+	s := &Server{FilenameRules: []FilenameRule{
+		{Prefix: "a", Replacement: "first-"},
+		{Prefix: "a", Replacement: "second-"},
+	}}
+	if got, want := s.rewriteFilename("abc"), "first-bc"; got != want {
+		t.Errorf("rewriteFilename(%q) = %q, want %q", "abc", got, want)
+	}
+}
+
+func TestServeRRQAppliesFilenameRewrite(t *testing.T) {
+	want := []byte("legacy firmware payload")
+	h := &memHandler{files: map[string][]byte{"boot/pxelinux.0": want}}
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s := NewServer("", h)
+	s.FilenameRules = []FilenameRule{{Exact: "/tftpboot/pxelinux.0", Replacement: "boot/pxelinux.0"}}
+	s.conn = conn
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	got, err := c.GetBytes("/tftpboot/pxelinux.0", "octet")
+	if err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+	if string(got) != string(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
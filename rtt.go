@@ -0,0 +1,81 @@
+package tftp
+
+import "time"
+
+// rttTimeoutMultiplier and the min/max bounds keep a seeded timeout from
+// being so tight that ordinary jitter causes spurious retransmits, or so
+// loose that a fast LAN transfer waits seconds to notice real loss.
+const (
+	rttTimeoutMultiplier = 3
+	minSeededTimeout     = 200 * time.Millisecond
+	maxSeededTimeout     = 10 * time.Second
+)
+
+// seedTimeoutFromRTT derives a per-session retransmission timeout from
+// the measured round-trip time of the first request/response exchange,
+// rather than using a single static default for every client regardless
+// of whether it's on a LAN or across a WAN link.
+func seedTimeoutFromRTT(rtt time.Duration) time.Duration {
+	return clampSeededTimeout(rtt * rttTimeoutMultiplier)
+}
+
+func clampSeededTimeout(t time.Duration) time.Duration {
+	if t < minSeededTimeout {
+		return minSeededTimeout
+	}
+	if t > maxSeededTimeout {
+		return maxSeededTimeout
+	}
+	return t
+}
+
+// rtoEstimator tracks a smoothed round-trip time and its variance across
+// a transfer's blocks, the same way TCP derives its retransmission
+// timeout (RFC 6298): srtt and rttvar are exponentially weighted moving
+// averages, and the timeout itself is srtt plus four times rttvar. A
+// static seed (e.g. from seedTimeoutFromRTT) stands in as the timeout
+// until the first real sample replaces it, so a transfer's very first
+// wait isn't unbounded.
+type rtoEstimator struct {
+	srtt, rttvar, rto time.Duration
+	seeded            bool
+}
+
+// newRTOEstimator returns an estimator whose timeout is seed until
+// sample is called for the first time.
+func newRTOEstimator(seed time.Duration) *rtoEstimator {
+	return &rtoEstimator{rto: clampSeededTimeout(seed)}
+}
+
+// sample records a measured round trip that was not preceded by a
+// retransmission. Karn's algorithm excludes retransmitted blocks from
+// sampling entirely: once a block has been resent, an ACK for it can't
+// be attributed to the original transmission or the retransmission, and
+// using it would skew srtt toward whichever one actually happened.
+func (e *rtoEstimator) sample(rtt time.Duration) {
+	if !e.seeded {
+		e.srtt = rtt
+		e.rttvar = rtt / 2
+		e.seeded = true
+	} else {
+		diff := e.srtt - rtt
+		if diff < 0 {
+			diff = -diff
+		}
+		e.rttvar = (e.rttvar*3 + diff) / 4
+		e.srtt = (e.srtt*7 + rtt) / 8
+	}
+	e.rto = clampSeededTimeout(e.srtt + 4*e.rttvar)
+}
+
+// backoff doubles the current timeout after a retransmission, mirroring
+// TCP's exponential retransmission backoff, without folding the lost
+// round trip into srtt/rttvar the way sample would.
+func (e *rtoEstimator) backoff() {
+	e.rto = clampSeededTimeout(e.rto * 2)
+}
+
+// timeout returns the estimator's current retransmission timeout.
+func (e *rtoEstimator) timeout() time.Duration {
+	return e.rto
+}
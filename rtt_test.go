@@ -0,0 +1,59 @@
+package tftp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSeedTimeoutFromRTT(t *testing.T) {
+	cases := []struct {
+		rtt  time.Duration
+		want time.Duration
+	}{
+		{time.Millisecond, 200 * time.Millisecond},       // below the floor
+		{100 * time.Millisecond, 300 * time.Millisecond}, // 3x multiplier
+		{10 * time.Second, 10 * time.Second},             // above the ceiling
+	}
+	for _, tc := range cases {
+		if got := seedTimeoutFromRTT(tc.rtt); got != tc.want {
+			t.Errorf("seedTimeoutFromRTT(%s) = %s, want %s", tc.rtt, got, tc.want)
+		}
+	}
+}
+
+func TestRTOEstimatorSeedsThenTracksSamples(t *testing.T) {
+	e := newRTOEstimator(500 * time.Millisecond)
+	if got := e.timeout(); got != 500*time.Millisecond {
+		t.Fatalf("timeout before any sample = %s, want seed 500ms", got)
+	}
+
+	e.sample(100 * time.Millisecond)
+	if got := e.timeout(); got != 300*time.Millisecond {
+		t.Fatalf("timeout after first sample = %s, want 300ms (srtt=100ms, rttvar=50ms)", got)
+	}
+
+	// A long run of identical samples should converge the timeout toward
+	// a small multiple of the RTT rather than staying pinned to the
+	// original seed.
+	for i := 0; i < 50; i++ {
+		e.sample(100 * time.Millisecond)
+	}
+	if got := e.timeout(); got > 210*time.Millisecond {
+		t.Errorf("timeout after converging on a steady 100ms RTT = %s, want close to 100ms", got)
+	}
+}
+
+func TestRTOEstimatorBackoffDoublesWithoutSampling(t *testing.T) {
+	e := newRTOEstimator(200 * time.Millisecond)
+	e.backoff()
+	if got := e.timeout(); got != 400*time.Millisecond {
+		t.Fatalf("timeout after one backoff = %s, want 400ms", got)
+	}
+	e.backoff()
+	if got := e.timeout(); got != 800*time.Millisecond {
+		t.Fatalf("timeout after two backoffs = %s, want 800ms", got)
+	}
+	if e.seeded {
+		t.Error("backoff should not mark the estimator as seeded by a real sample")
+	}
+}
@@ -0,0 +1,74 @@
+//go:build linux && amd64
+
+package tftp
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	// SYS_OPENAT2 isn't defined by the syscall package on this
+	// toolchain's amd64 table yet (see linux/arch/x86/entry/syscalls),
+	// so the number is mirrored here, the same way recvBatch mirrors
+	// SYS_RECVMMSG's mmsghdr struct in mmsg_linux.go.
+	sysOpenat2 = 437
+
+	// resolveBeneath is RESOLVE_BENEATH from linux/openat2.h: the
+	// kernel refuses any path component that would escape dirfd,
+	// including ".." segments, symlinks that resolve outside it, and
+	// absolute paths, which is what lets openBeneath enforce
+	// FileHandler.Sandbox's guarantee independently of this package's
+	// own path-cleaning logic (see FileHandler.resolve).
+	resolveBeneath = 0x08
+)
+
+// openHow mirrors struct open_how from linux/openat2.h, which the
+// syscall package doesn't expose.
+type openHow struct {
+	flags   uint64
+	mode    uint64
+	resolve uint64
+}
+
+// openBeneath opens rel, a single path component supplied by a remote
+// client, strictly beneath dir via openat2(2)'s RESOLVE_BENEATH: the
+// kernel itself rejects an escape attempt before the open can succeed,
+// rather than this package trusting its own path-cleaning to have
+// caught it first. Requires a Linux 5.6+ kernel; returns the kernel's
+// ENOSYS on anything older.
+func openBeneath(dir, rel string, flags int, perm os.FileMode) (*os.File, error) {
+	dirFd, err := syscall.Open(dir, syscall.O_RDONLY|syscall.O_DIRECTORY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.Close(dirFd)
+
+	relPtr, err := syscall.BytePtrFromString(rel)
+	if err != nil {
+		return nil, err
+	}
+	how := openHow{flags: uint64(flags), mode: uint64(perm), resolve: resolveBeneath}
+	r1, _, errno := syscall.Syscall6(sysOpenat2, uintptr(dirFd),
+		uintptr(unsafe.Pointer(relPtr)), uintptr(unsafe.Pointer(&how)), unsafe.Sizeof(how), 0, 0)
+	if errno != 0 {
+		return nil, fmt.Errorf("tftp: openat2 RESOLVE_BENEATH %s/%s: %w", dir, rel, errno)
+	}
+	return os.NewFile(r1, dir+string(os.PathSeparator)+rel), nil
+}
+
+// renameBeneath renames oldRel to newRel, both single path components
+// directly inside dir. It assumes the caller has already rejected any
+// newRel containing a path separator or a ".."/"." component (see
+// FileHandler.Sandbox), since renameat(2) has no RESOLVE_BENEATH
+// equivalent of its own to enforce that itself.
+func renameBeneath(dir, oldRel, newRel string) error {
+	dirFd, err := syscall.Open(dir, syscall.O_RDONLY|syscall.O_DIRECTORY, 0)
+	if err != nil {
+		return err
+	}
+	defer syscall.Close(dirFd)
+	return syscall.Renameat(dirFd, oldRel, dirFd, newRel)
+}
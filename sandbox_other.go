@@ -0,0 +1,15 @@
+//go:build !(linux && amd64)
+
+package tftp
+
+import (
+	"os"
+)
+
+func openBeneath(dir, rel string, flags int, perm os.FileMode) (*os.File, error) {
+	return nil, errSandboxUnsupported
+}
+
+func renameBeneath(dir, oldRel, newRel string) error {
+	return errSandboxUnsupported
+}
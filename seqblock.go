@@ -0,0 +1,55 @@
+package tftp
+
+import "io"
+
+// sequentialBlockSource adapts an io.Reader that only supports
+// sequential access — e.g. a PayloadProvider's io.ReadCloser, which
+// might be a database cursor or an HTTP response body — to
+// blockSource's random-access Block(i) contract. Blocks are read
+// forward and cached the first time they're needed; a block already
+// sent can then be handed back byte-for-byte on retransmission without
+// re-reading (or, for a cursor that can't rewind, without failing) the
+// underlying source.
+type sequentialBlockSource struct {
+	r         io.Reader
+	blockSize int
+	n         int
+	blocks    [][]byte
+	next      int
+}
+
+// newSequentialBlockSource returns a sequentialBlockSource over the
+// first size bytes readable from r, split into blockSize-sized blocks
+// with the same trailing-short-block EOF convention as BlockIterator.
+func newSequentialBlockSource(r io.Reader, size int64, blockSize int) *sequentialBlockSource {
+	n := int((size + int64(blockSize) - 1) / int64(blockSize))
+	if size%int64(blockSize) == 0 {
+		n++
+	}
+	return &sequentialBlockSource{r: r, blockSize: blockSize, n: n, blocks: make([][]byte, n)}
+}
+
+// Len returns the total number of blocks, including the trailing EOF
+// block.
+func (s *sequentialBlockSource) Len() int {
+	return s.n
+}
+
+// Block returns the payload for the i'th block (0-indexed), reading and
+// caching every block up to and including i on first access. It panics
+// if i is out of range, the same contract slice indexing has.
+func (s *sequentialBlockSource) Block(i int) ([]byte, error) {
+	if i < 0 || i >= s.n {
+		panic("tftp: sequentialBlockSource index out of range")
+	}
+	for s.next <= i {
+		buf := make([]byte, s.blockSize)
+		n, err := io.ReadFull(s.r, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, err
+		}
+		s.blocks[s.next] = buf[:n]
+		s.next++
+	}
+	return s.blocks[i], nil
+}
@@ -0,0 +1,37 @@
+package tftp
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSequentialBlockSourceMatchesBlockIterator(t *testing.T) {
+	data := []byte("abcdefghij")
+	s := newSequentialBlockSource(bytes.NewReader(data), int64(len(data)), 4)
+	want := NewBlockIterator(data, 4)
+
+	if got, wantLen := s.Len(), want.Len(); got != wantLen {
+		t.Fatalf("Len() = %d, want %d", got, wantLen)
+	}
+	for i := 0; i < want.Len(); i++ {
+		got := block(t, s, i)
+		wantBlock := block(t, want, i)
+		if !bytes.Equal(got, wantBlock) {
+			t.Errorf("Block(%d) = %q, want %q", i, got, wantBlock)
+		}
+	}
+}
+
+func TestSequentialBlockSourceRetransmitReturnsCachedBlock(t *testing.T) {
+	data := []byte("abcdefgh")
+	s := newSequentialBlockSource(bytes.NewReader(data), int64(len(data)), 4)
+
+	first := block(t, s, 0)
+	// Re-request block 0 after block 1 has already advanced the
+	// underlying reader, as a retransmit would.
+	block(t, s, 1)
+	second := block(t, s, 0)
+	if !bytes.Equal(first, second) {
+		t.Fatalf("Block(0) returned %q then %q, want identical results for a retransmit", first, second)
+	}
+}
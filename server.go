@@ -0,0 +1,1532 @@
+package tftp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultTimeout is how long the server waits for an ACK before
+// retransmitting the current window.
+const DefaultTimeout = 3 * time.Second
+
+// DefaultRetries is how many times the server retransmits a window
+// before abandoning a transfer.
+const DefaultRetries = 5
+
+// Handler serves read and write requests. Implementations are expected
+// to validate filenames themselves; the server does not sandbox paths.
+type Handler interface {
+	// ReadFile returns the full contents of filename for an RRQ.
+	ReadFile(filename string) ([]byte, error)
+	// WriteFile stores data received via a WRQ under filename.
+	WriteFile(filename string, data []byte) error
+}
+
+// PayloadProvider resolves an RRQ's content per request, for embedders
+// serving from a database, cache, or generated source rather than
+// anything shaped like a filesystem. It receives the requested filename
+// and the client's address, so content (or access control) can depend
+// on who's asking. The returned io.ReadCloser is read forward in
+// blockSize-sized pieces and closed once the transfer finishes,
+// successfully or not; size must be the exact number of bytes it will
+// yield. An error that is a *ERROR is sent to the client with its own
+// Code and Message unchanged, so a provider can signal something more
+// specific than "not found" (e.g. ErrCodeDiskFull for a cold cache);
+// any other error is reported as ErrCodeNotFound. When set, a
+// PayloadProvider takes priority over Handler for every RRQ, including
+// ListFilename.
+type PayloadProvider func(ctx context.Context, filename string, clientAddr net.Addr) (io.ReadCloser, int64, error)
+
+// Server answers TFTP requests over UDP.
+type Server struct {
+	Addr    string
+	Handler Handler
+	Timeout time.Duration
+	Retries int
+
+	// AdaptiveTimeout, if true, adapts each RRQ's retransmission timeout
+	// to the round trip times actually measured to that client instead
+	// of holding every session to Timeout for its whole duration. See
+	// Client.AdaptiveTimeout, which does the same thing for downloads;
+	// enabling both sides gets a consistent timeout strategy across a
+	// round trip regardless of which end initiated it.
+	AdaptiveTimeout bool
+
+	// Validators run against every RRQ/WRQ before it reaches Handler.
+	// The first one to return a Rejection causes the server to reply
+	// with an ERROR packet instead of servicing the request.
+	Validators []Validator
+
+	// ContentValidators run against a WRQ's fully assembled upload after
+	// the transfer completes but before it reaches Handler.WriteFile.
+	// Unlike Validators, which only ever sees request metadata (filename,
+	// options, client address), these see the actual uploaded bytes, so
+	// they're the place to reject oversized uploads, bad magic bytes, an
+	// unparsable config file, or malware an AV scanner flags — at the
+	// cost of the client having already spent the bandwidth to send the
+	// content that's about to be rejected. The first one to return a
+	// Rejection aborts the write.
+	ContentValidators []UploadValidator
+
+	// MaxConcurrentTransfers, if greater than zero, is the concurrency
+	// level at which SoftQuotaThreshold is evaluated against. It is
+	// advisory only: the server does not reject requests past this
+	// point, it only drives OnQuotaWarning.
+	MaxConcurrentTransfers int
+
+	// MaxSessionsPerIP, if greater than zero, caps how many transfers
+	// may be in flight at once from a single client IP, independently
+	// of any overall concurrency limit. A new RRQ/WRQ past the limit is
+	// answered with an ERROR instead of starting a session, so one
+	// chattering or misbehaving device can't consume the server's
+	// entire session budget by itself. A small value (a handful) is
+	// usually enough headroom for a device's own retry behavior without
+	// letting it monopolize capacity. Zero (the default) means
+	// unlimited.
+	MaxSessionsPerIP int
+
+	// CollapseIdenticalReads, if true, shares a single Handler.ReadFile
+	// (or Upstream) call across every RRQ currently waiting on the same
+	// filename instead of letting each trigger its own backend read —
+	// the fix for a fleet of machines requesting the same popular image
+	// within the same few milliseconds. It has no effect on
+	// PayloadProvider or ReaderAtHandler, whose streaming results can't
+	// be shared between readers the same way a []byte can.
+	CollapseIdenticalReads bool
+
+	// MaxReadersPerFile, if greater than zero, caps how many RRQs may
+	// be waiting on a single filename's Handler.ReadFile (or Upstream)
+	// call at once, whether or not CollapseIdenticalReads is also set.
+	// An RRQ past the limit is answered with an ERROR immediately
+	// rather than piling up behind a slow backend. Zero (the default)
+	// means unlimited.
+	MaxReadersPerFile int
+
+	// SoftQuotaThreshold is the fraction of MaxConcurrentTransfers at
+	// which OnQuotaWarning fires. Defaults to DefaultSoftQuotaThreshold
+	// when left zero.
+	SoftQuotaThreshold float64
+
+	// OnQuotaWarning, if set, is called when active transfers cross
+	// SoftQuotaThreshold, giving operators an early signal before
+	// clients start being rejected by a hard limit.
+	OnQuotaWarning func(QuotaWarning)
+
+	// Metrics, if set, receives cumulative request/byte/error counters
+	// published via expvar. Left nil by default, since a process not
+	// already scraping expvar shouldn't pay for it. See NewServerMetrics.
+	Metrics *ServerMetrics
+
+	// OnTransferComplete, if set, is called once for every RRQ/WRQ that
+	// reaches sendData/receiveData, whether it succeeds or fails, with
+	// the session it served and the stats accumulated up to that point.
+	// err is nil on success. This is the hook to wire up per-client
+	// retransmission/timeout tracking across a large fleet, e.g. to spot
+	// a lossy switch port during mass PXE provisioning, without parsing
+	// the Logger's free-text output.
+	OnTransferComplete func(Session, Stats, error)
+
+	// Logger receives diagnostic output such as rejected requests and
+	// quota warnings. Defaults to a no-op Logger via NewServer, so a
+	// Server constructed that way stays silent until the caller opts in.
+	Logger Logger
+
+	// Capture, if set, receives every datagram the server sends or
+	// receives in pcap format, for diagnosing wire-level issues with
+	// picky firmware TFTP clients. Left nil by default; NewServer does
+	// not set one, since writing a capture is not free and should stay
+	// opt-in.
+	Capture *PacketCapture
+
+	// RateLimit, if greater than zero, caps each RRQ's outbound DATA
+	// throughput to this many bytes/sec. The limit is per transfer, not
+	// shared across them, so one greedy client pulling a huge image
+	// cannot starve the others booting concurrently. Zero (the default)
+	// means unlimited.
+	RateLimit int64
+
+	// GlobalRateLimit, if greater than zero, caps total outbound DATA
+	// throughput across every active session combined to this many
+	// bytes/sec, so the server can coexist on a link with other
+	// provisioning traffic without a traffic shaper in front of it.
+	// Sessions draw from the same shared budget, which gives them fair
+	// access to it without any per-session accounting. Zero (the
+	// default) means unlimited. Combine with RateLimit to also bound any
+	// one session's share of the global budget.
+	GlobalRateLimit int64
+
+	// PayloadProvider, if set, resolves every RRQ's content through this
+	// callback instead of Handler. See PayloadProvider's doc comment.
+	PayloadProvider PayloadProvider
+
+	// FilenameRules rewrites a request's filename before it reaches
+	// Validators or the backend (Handler/PayloadProvider), so legacy
+	// clients that hard-code a path layout can be served from wherever
+	// the content actually lives. See FilenameRule.
+	FilenameRules []FilenameRule
+
+	// AuthKey, if set, requires every RRQ/WRQ to carry a valid signature
+	// over its filename via the private OptAuthTimestamp/OptAuthSignature
+	// options, rejecting anything else with ErrCodeAccessViolation — so a
+	// shared secret on the provisioning VLAN keeps a spoofed UDP packet
+	// from fetching a sensitive image, even though TFTP itself has no
+	// transport-level authentication. Set Client.AuthKey to the same
+	// value to have a Client sign its requests. See signRequest.
+	AuthKey []byte
+
+	// AuthSkew bounds how far a signed request's embedded timestamp may
+	// differ from the server's own clock before it's rejected, limiting
+	// how long a captured request stays replayable. Defaults to
+	// DefaultAuthSkew when left zero. Has no effect unless AuthKey is
+	// set.
+	AuthSkew time.Duration
+
+	// LogSampleWindow and LogSampleBurst bound how many warnf lines a
+	// single (client, reason) pair can produce before the rest of that
+	// window are suppressed down to one periodic summary line, so a scan
+	// of port 69 can't turn a malformed-packet storm or a wave of
+	// rejected requests into one log line per datagram. Left zero, they
+	// default to defaultLogSampleWindow and defaultLogSampleBurst.
+	LogSampleWindow time.Duration
+	LogSampleBurst  int
+
+	// TokenKey, if set, verifies any filename using the signed, expiring
+	// token convention ("token.<signature>.<expiry>/<path>", see
+	// SignedFilename) before serving the path it wraps, rejecting an
+	// invalid or expired one with ErrCodeAccessViolation. Unlike AuthKey,
+	// which signs a request over the wire via options a client must add
+	// itself, the signature here travels inside the filename, so a
+	// time-limited link to a sensitive image can be handed to any
+	// RFC 1350 client as-is. A filename that doesn't use the convention
+	// is served normally; TokenKey only gates the ones that opt into it.
+	TokenKey []byte
+
+	// CanaryRules selects an alternate file to serve for an RRQ whose
+	// client address matches a Variant's Networks/Addrs, e.g. shipping
+	// a candidate bootloader to one rack before rolling it out to the
+	// rest of the fleet. Evaluated after FilenameRules, against the
+	// rewritten filename. Has no effect on WRQ. The matched Variant's
+	// Name, if any, is recorded on the resulting Session (and from
+	// there, the audit log and OnTransferComplete) so a canary's blast
+	// radius can be reconstructed after the fact.
+	CanaryRules []CanaryRule
+
+	// EncryptionKey, if set, lets a client negotiate OptEncrypt to have
+	// the DATA stream carry the file's content AES-256-GCM-encrypted
+	// instead of raw, for a closed deployment where some served images
+	// contain secrets and plain TFTP's cleartext wire format is a
+	// liability. Set Client.EncryptionKey to the same value to have a
+	// Client request and decrypt it. See OptEncrypt.
+	EncryptionKey []byte
+
+	// CaseInsensitiveFilenames, if true, resolves an RRQ's filename
+	// case-insensitively against the Handler's listing when no file
+	// matches it exactly. See resolveFilenameCase.
+	CaseInsensitiveFilenames bool
+
+	// ReadOnly, if true, rejects every WRQ with ErrCodeAccessViolation
+	// before it reaches Handler.WriteFile, for a deployment (e.g. a boot
+	// server) that should never accept an upload. Mutually exclusive
+	// with WriteOnly in practice, since setting both leaves the server
+	// unable to serve either request type.
+	ReadOnly bool
+
+	// WriteOnly, if true, rejects every RRQ with ErrCodeAccessViolation
+	// before it reaches Handler.ReadFile or PayloadProvider, for a
+	// deployment (e.g. a config-backup server) that should only ever
+	// accept uploads.
+	WriteOnly bool
+
+	// AuditLog, if set, receives one JSON-lines record for every
+	// completed or failed RRQ/WRQ. See AuditLogger.
+	AuditLog *AuditLogger
+
+	// Webhook, if set, receives an HTTP POST for every completed or
+	// failed RRQ/WRQ. See WebhookNotifier.
+	Webhook *WebhookNotifier
+
+	// Middleware, if set, wraps every session's ephemeral UDP socket in
+	// order before it's handed to negotiate/sendData/receiveData, so a
+	// cross-cutting concern like extra metrics or throttling can be
+	// layered on without touching the transfer logic itself. It does not
+	// apply to the listening socket Serve accepts requests on. See
+	// PacketMiddleware's doc comment for the tradeoff it implies.
+	Middleware []PacketMiddleware
+
+	// Upstream, if set, is the host:port of another TFTP server that
+	// RRQs are relayed to whenever this Server can't satisfy them itself
+	// (Handler unset, or its ReadFile returns an error) — effectively a
+	// branch-office cache in front of a central TFTP server over a
+	// slower or metered link. The fetched content is streamed back to
+	// the requesting client the same as any locally served file, subject
+	// to the same block size and compression negotiation. Has no effect
+	// on WRQ (there's no analogous "I don't have this, ask upstream" for
+	// an upload).
+	Upstream string
+
+	// CacheUpstream, if true, saves every file fetched via Upstream
+	// locally through Handler.WriteFile, so a second request for the
+	// same filename is served from Handler without relaying again. A
+	// write failure is logged and otherwise ignored, since the fetched
+	// bytes are already good to serve to the client that's waiting on
+	// them; it only means this particular file stays uncached. Requires
+	// Handler to be set; has no effect unless Upstream is too.
+	CacheUpstream bool
+
+	// IdleTimeout, if greater than zero, closes a session that goes this
+	// long without the server receiving any packet from its client —
+	// the whole point being to reclaim the goroutine, ephemeral port,
+	// and any open file/reader a stuck embedded client would otherwise
+	// pin forever by simply going silent mid-transfer instead of
+	// retrying or erroring out.
+	IdleTimeout time.Duration
+
+	// MaxSessionDuration, if greater than zero, closes a session this
+	// long after it started, regardless of how recently its client was
+	// active — a backstop against a transfer that keeps making progress
+	// (so IdleTimeout never fires) but never actually finishes, e.g. a
+	// multi-GB image over a connection so slow it would otherwise run
+	// indefinitely.
+	MaxSessionDuration time.Duration
+
+	// RetryPolicy governs the wait between retransmission attempts in
+	// sendData/receiveData (when AdaptiveTimeout isn't already driving
+	// the RRQ side via a continuously adapting RTO). Leave it nil to get
+	// the default exponential-with-jitter behavior; see
+	// Client.RetryPolicy, which does the same thing for the other end of
+	// the link.
+	RetryPolicy RetryPolicy
+
+	// PrefetchBlocks, if greater than zero, has an RRQ served through a
+	// ReaderAtHandler read this many blocks ahead of whichever one was
+	// last sent, in the background, while the current window awaits its
+	// ACK. This hides a slow backend's own per-block latency (an HTTP
+	// range request, an S3 GetObject call) behind the protocol's round
+	// trips instead of adding to each one. Has no effect on a Handler
+	// whose content is already fully in memory, since there's no
+	// backend latency to hide there. Zero (the default) disables
+	// prefetching.
+	PrefetchBlocks int
+
+	globalLimiterOnce sync.Once
+	globalLimiterVal  *rateLimiter
+
+	backendReadsOnce sync.Once
+	backendReadsVal  *backendReadLimiter
+
+	logSamplerOnce sync.Once
+	logSamplerVal  *logSampler
+
+	conn    *net.UDPConn
+	connsMu sync.Mutex
+	conns   []*net.UDPConn
+	active  atomic.Int32
+	warned  atomic.Bool
+
+	// cfgMu guards the fields a live reload (see cmd/server's SIGHUP
+	// handler, which calls the Set* methods below) can replace while
+	// sessions are already in flight: Logger, RateLimit,
+	// GlobalRateLimit, FilenameRules, and Validators. Every other Server
+	// field is documented to be set once before Serve is called and
+	// never touched again, the same as Handler or Timeout, so it doesn't
+	// need this protection.
+	cfgMu sync.RWMutex
+
+	draining      atomic.Bool
+	nextSessionID atomic.Uint64
+	sessionsMu    sync.Mutex
+	sessions      map[uint64]*activeSession
+
+	eventsOnce sync.Once
+	eventsCh   chan TransferEvent
+	eventsOn   atomic.Bool
+}
+
+// logger, rateLimit, filenameRules, and validators are the read-side
+// counterparts of SetLogger, SetRateLimit, SetFilenameRules, and
+// SetValidators: every internal read of the field they guard goes
+// through these instead of touching Logger/RateLimit/FilenameRules/
+// Validators directly, so a concurrent reload can't race a session
+// goroutine reading mid-transfer. GlobalRateLimit has no such accessor
+// because it's only ever read once, by globalLimiter's sync.Once.
+func (s *Server) logger() Logger {
+	s.cfgMu.RLock()
+	defer s.cfgMu.RUnlock()
+	return s.Logger
+}
+
+func (s *Server) rateLimit() int64 {
+	s.cfgMu.RLock()
+	defer s.cfgMu.RUnlock()
+	return s.RateLimit
+}
+
+func (s *Server) filenameRules() []FilenameRule {
+	s.cfgMu.RLock()
+	defer s.cfgMu.RUnlock()
+	return s.FilenameRules
+}
+
+func (s *Server) validators() []Validator {
+	s.cfgMu.RLock()
+	defer s.cfgMu.RUnlock()
+	return s.Validators
+}
+
+// SetLogger, SetRateLimit, SetGlobalRateLimit, SetFilenameRules, and
+// SetValidators replace the Server field of the same name under cfgMu,
+// so they're safe to call from a live reload (cmd/server's SIGHUP
+// handler) while the server is already serving requests. A plain
+// assignment to one of these fields after Serve has been called races
+// every in-flight session reading it; use these instead. Before Serve
+// is called, assigning the field directly (the usual struct-literal
+// idiom used by every other Server field) is still fine, since nothing
+// else is reading it yet.
+func (s *Server) SetLogger(l Logger) {
+	s.cfgMu.Lock()
+	s.Logger = l
+	s.cfgMu.Unlock()
+}
+
+func (s *Server) SetRateLimit(bytesPerSec int64) {
+	s.cfgMu.Lock()
+	s.RateLimit = bytesPerSec
+	s.cfgMu.Unlock()
+}
+
+// SetGlobalRateLimit replaces GlobalRateLimit under cfgMu, but is
+// subject to the same caveat as setting the field directly: globalLimiter
+// builds the shared limiter from this value only once, on the first
+// transfer that needs it, so a reload after that point has no effect.
+func (s *Server) SetGlobalRateLimit(bytesPerSec int64) {
+	s.cfgMu.Lock()
+	s.GlobalRateLimit = bytesPerSec
+	s.cfgMu.Unlock()
+}
+
+func (s *Server) SetFilenameRules(rules []FilenameRule) {
+	s.cfgMu.Lock()
+	s.FilenameRules = rules
+	s.cfgMu.Unlock()
+}
+
+func (s *Server) SetValidators(v []Validator) {
+	s.cfgMu.Lock()
+	s.Validators = v
+	s.cfgMu.Unlock()
+}
+
+// logf writes to s.Logger if one is set, and is a no-op otherwise so a
+// Server built as a zero-value struct literal rather than via NewServer
+// doesn't panic on a nil Logger.
+func (s *Server) logf(format string, args ...any) {
+	if l := s.logger(); l != nil {
+		l.Printf(format, args...)
+	}
+}
+
+// warnf is logf's counterpart for conditions worth distinguishing from
+// routine traffic: a rejected request, a transfer that failed partway
+// through. It uses s.Logger's WarnLogger capability when present, or
+// falls back to Printf like logf.
+func (s *Server) warnf(format string, args ...any) {
+	warnf(s.logger(), format, args...)
+}
+
+// logSampler lazily builds the Server's logSampler from LogSampleWindow
+// and LogSampleBurst on first use, the same lazy-init shape as
+// globalLimiter and backendReads.
+func (s *Server) logSampler() *logSampler {
+	s.logSamplerOnce.Do(func() {
+		s.logSamplerVal = newLogSampler(s.LogSampleWindow, s.LogSampleBurst)
+	})
+	return s.logSamplerVal
+}
+
+// warnfSampled is warnf run through s.logSampler() under key, so a
+// repetitive condition sharing the same key (typically a client address
+// and the reason) logs its first few occurrences in full and the rest
+// as a periodic summary line instead of one warnf per occurrence.
+func (s *Server) warnfSampled(key, format string, args ...any) {
+	ok, summary := s.logSampler().allow(key)
+	if summary != "" {
+		s.warnf(summary)
+	}
+	if ok {
+		s.warnf(format, args...)
+	}
+}
+
+// authSkew returns s.AuthSkew, or DefaultAuthSkew when it's left zero.
+func (s *Server) authSkew() time.Duration {
+	if s.AuthSkew > 0 {
+		return s.AuthSkew
+	}
+	return DefaultAuthSkew
+}
+
+// fetchUpstream relays filename from s.Upstream for a local miss (see
+// Upstream's doc comment) and, if CacheUpstream is set, saves the result
+// via Handler.WriteFile so the next request for filename is served
+// locally instead of relayed again.
+func (s *Server) fetchUpstream(filename string) ([]byte, error) {
+	c := NewClient(s.Upstream)
+	data, err := c.GetBytes(filename, "octet")
+	if err != nil {
+		return nil, err
+	}
+	if s.CacheUpstream && s.Handler != nil {
+		if werr := s.Handler.WriteFile(filename, data); werr != nil {
+			s.warnf("tftp: caching %s from upstream %s: %v", filename, s.Upstream, werr)
+		}
+	}
+	return data, nil
+}
+
+// readBackend resolves filename via Handler.ReadFile, falling back to
+// Upstream on a local miss exactly as openBlockSource's callers expect,
+// but goes through the backendReadLimiter first whenever
+// CollapseIdenticalReads or MaxReadersPerFile is configured, so a burst
+// of RRQs for the same filename shares one backend read (and can't pile
+// up past the configured limit) instead of each triggering its own.
+func (s *Server) readBackend(filename string) ([]byte, error) {
+	fetch := func() ([]byte, error) {
+		var data []byte
+		var err error
+		if s.Handler != nil {
+			data, err = s.Handler.ReadFile(filename)
+		}
+		if s.Handler == nil || err != nil {
+			if s.Upstream == "" {
+				if err == nil {
+					err = fmt.Errorf("tftp: no handler configured for %s", filename)
+				}
+				return nil, err
+			}
+			return s.fetchUpstream(filename)
+		}
+		return data, nil
+	}
+	if !s.CollapseIdenticalReads && s.MaxReadersPerFile <= 0 {
+		return fetch()
+	}
+	return s.backendReads().Do(filename, fetch)
+}
+
+// globalLimiter lazily builds the rateLimiter shared by every session,
+// sized from GlobalRateLimit on first use. The lazy init lets
+// GlobalRateLimit be set any time before Serve is called, the same as
+// every other Server field.
+func (s *Server) globalLimiter() *rateLimiter {
+	s.globalLimiterOnce.Do(func() {
+		s.cfgMu.RLock()
+		limit := s.GlobalRateLimit
+		s.cfgMu.RUnlock()
+		s.globalLimiterVal = newRateLimiter(limit)
+	})
+	return s.globalLimiterVal
+}
+
+// backendReads returns the Server's backendReadLimiter, constructing it
+// on first use from CollapseIdenticalReads and MaxReadersPerFile.
+func (s *Server) backendReads() *backendReadLimiter {
+	s.backendReadsOnce.Do(func() {
+		s.backendReadsVal = newBackendReadLimiter(s.CollapseIdenticalReads, s.MaxReadersPerFile)
+	})
+	return s.backendReadsVal
+}
+
+// NewServer constructs a Server bound to addr (host:port, host may be
+// empty to listen on all interfaces) that dispatches to h.
+func NewServer(addr string, h Handler) *Server {
+	return &Server{
+		Addr:    addr,
+		Handler: h,
+		Timeout: DefaultTimeout,
+		Retries: DefaultRetries,
+		Logger:  noopLogger{},
+	}
+}
+
+// ListenAndServe resolves s.Addr and serves requests until an
+// unrecoverable socket error occurs.
+func (s *Server) ListenAndServe() error {
+	udpAddr, err := net.ResolveUDPAddr("udp", s.Addr)
+	if err != nil {
+		return err
+	}
+	conn, err := net.ListenUDP("udp", udpAddr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(conn)
+}
+
+// Serve accepts requests on an already-bound connection, handing each
+// one to a dedicated goroutine bound to a fresh ephemeral socket (as
+// required by RFC 1350's per-transfer TID scheme). On Linux/amd64 it
+// drains a burst of simultaneous incoming requests with a single
+// recvmmsg(2) syscall via recvBatch instead of one syscall per request;
+// elsewhere recvBatch degrades to an ordinary per-packet read.
+func (s *Server) Serve(conn *net.UDPConn) error {
+	s.setConn(conn)
+	return s.serveOn(conn, "")
+}
+
+// setConn and getConn guard conn with connsMu (the same mutex
+// protecting conns) so Serve's write race with Close/Shutdown reading
+// it from another goroutine: a production deployment's SIGTERM handler
+// calls Shutdown concurrently with the ListenAndServe goroutine that set
+// conn in the first place.
+func (s *Server) setConn(conn *net.UDPConn) {
+	s.connsMu.Lock()
+	s.conn = conn
+	s.connsMu.Unlock()
+}
+
+func (s *Server) getConn() *net.UDPConn {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+	return s.conn
+}
+
+// ListenSpec names one address a Server should accept requests on when
+// started via ListenAndServeAll, plus an optional Label identifying it
+// afterwards in logs, Session.Listener, and anything built on
+// OnTransferComplete/AuditLog. Label has no effect on the wire; it
+// exists purely so an operator running one Server instance across
+// several provisioning VLANs (or any other set of interfaces) can tell
+// which one served a given request without decoding its bind address.
+type ListenSpec struct {
+	Addr  string
+	Label string
+}
+
+// ListenAndServeAll resolves and binds every spec's address and serves
+// requests on all of them concurrently from this one Server instance —
+// the multi-interface equivalent of ListenAndServe, for deployments that
+// would otherwise need a separate process (and a separate set of
+// Validators, Metrics, rate limits, and so on) per interface. It blocks
+// until any one listener returns an unrecoverable error, then returns
+// that error; the other listeners are left running; call Close to shut
+// all of them down together.
+func (s *Server) ListenAndServeAll(specs ...ListenSpec) error {
+	if len(specs) == 0 {
+		return errors.New("tftp: ListenAndServeAll requires at least one ListenSpec")
+	}
+
+	conns := make([]*net.UDPConn, 0, len(specs))
+	for _, spec := range specs {
+		udpAddr, err := net.ResolveUDPAddr("udp", spec.Addr)
+		if err != nil {
+			for _, c := range conns {
+				c.Close()
+			}
+			return err
+		}
+		conn, err := net.ListenUDP("udp", udpAddr)
+		if err != nil {
+			for _, c := range conns {
+				c.Close()
+			}
+			return err
+		}
+		conns = append(conns, conn)
+	}
+
+	s.connsMu.Lock()
+	s.conns = append(s.conns, conns...)
+	s.connsMu.Unlock()
+
+	errs := make(chan error, len(specs))
+	for i, spec := range specs {
+		go func(conn *net.UDPConn, label string) {
+			errs <- s.serveOn(conn, label)
+		}(conns[i], spec.Label)
+	}
+	return <-errs
+}
+
+// serveOn is Serve's request loop, parameterized over which listening
+// socket to read from and the label (see ListenSpec) to tag its
+// sessions with, so ListenAndServeAll can run it once per interface.
+func (s *Server) serveOn(conn *net.UDPConn, label string) error {
+	local, _ := conn.LocalAddr().(*net.UDPAddr)
+
+	if interval := s.sessionWatchdogInterval(); interval > 0 {
+		done := make(chan struct{})
+		defer close(done)
+		go s.runSessionWatchdog(interval, done)
+	}
+
+	logDone := make(chan struct{})
+	defer close(logDone)
+	go s.runLogSamplerFlush(logDone)
+
+	bufs := make([][]byte, recvBatchSize)
+	for i := range bufs {
+		bufs[i] = make([]byte, 65507)
+	}
+	srcs := make([]*net.UDPAddr, recvBatchSize)
+	lens := make([]int, recvBatchSize)
+
+	for {
+		n, err := recvBatch(conn, bufs, srcs, lens)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < n; i++ {
+			addr, buf := srcs[i], bufs[i][:lens[i]]
+			s.Capture.received(local, addr, buf)
+			debugPacket(debugLoggerOf(s.logger()), "received", addr, buf)
+			p, err := ParsePacket(buf)
+			if err != nil {
+				s.warnfSampled(addr.String()+":malformed", "tftp: dropping malformed packet from %s%s: %v", addr, listenerTag(label), err)
+				continue
+			}
+			go s.dispatch(p, addr, conn, label)
+		}
+	}
+}
+
+// Close shuts down every listening socket this Server accepted requests
+// on, whether bound via Serve/ListenAndServe or ListenAndServeAll.
+func (s *Server) Close() error {
+	var err error
+	if conn := s.getConn(); conn != nil {
+		err = conn.Close()
+	}
+	s.connsMu.Lock()
+	conns := s.conns
+	s.connsMu.Unlock()
+	for _, c := range conns {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+func (s *Server) dispatch(p Packet, addr *net.UDPAddr, listenConn *net.UDPConn, label string) {
+	if req, ok := p.(*RRQ); ok && s.hasActiveSession(addr) {
+		// PXE firmware commonly re-sends the RRQ while the first DATA
+		// packet is still in flight (it has no way to tell "slow" apart
+		// from "lost"). Spawning a second, independent session for the
+		// same client would have both compete to answer the same
+		// transfer; dropping the retransmit instead leaves the
+		// already-running session's own timeout/retransmit logic to
+		// keep the client in sync, exactly as if the retransmitted RRQ
+		// had been lost on the wire instead of duplicated.
+		s.logf("tftp: dropping duplicate RRQ for %s from %s%s: a transfer is already in flight", req.Filename, addr, listenerTag(label))
+		return
+	}
+
+	session, err := net.ListenUDP("udp", &net.UDPAddr{IP: listenConn.LocalAddr().(*net.UDPAddr).IP})
+	if err != nil {
+		return
+	}
+	defer session.Close()
+	s.serveSession(p, addr, session, label)
+}
+
+// ServeRequest serves a single RRQ or WRQ already received by an
+// application that demultiplexes UDP itself, such as a combined
+// DHCP+TFTP proxyDHCP daemon that must own port 69 before this package
+// ever sees a socket. conn is used only to learn which local address the
+// session's own ephemeral port should bind to (every TFTP transfer gets
+// its own port; see dispatch) — ServeRequest never reads from or writes
+// to conn, so the caller is free to keep demultiplexing later datagrams
+// on it itself. clientAddr must be a *net.UDPAddr and initialPacket must
+// decode as an RRQ or WRQ; anything else is returned as an error without
+// a session ever starting.
+//
+// ServeRequest blocks until the transfer finishes or ctx is done,
+// whichever comes first. A canceled or expired ctx aborts the transfer
+// by closing its session socket, the same mechanism CancelSession uses
+// to abort a session accepted through Serve. The transfer's own outcome
+// is reported the usual way — OnTransferComplete, AuditLog, Webhook,
+// Events — not through ServeRequest's return value, which only ever
+// reflects a failure to start the session, consistent with dispatch
+// never surfacing a transfer's result to its own caller either.
+func (s *Server) ServeRequest(ctx context.Context, conn net.PacketConn, clientAddr net.Addr, initialPacket []byte) error {
+	addr, ok := clientAddr.(*net.UDPAddr)
+	if !ok {
+		return fmt.Errorf("tftp: ServeRequest: clientAddr must be a *net.UDPAddr, got %T", clientAddr)
+	}
+	local, ok := conn.LocalAddr().(*net.UDPAddr)
+	if !ok {
+		return fmt.Errorf("tftp: ServeRequest: conn.LocalAddr must be a *net.UDPAddr, got %T", conn.LocalAddr())
+	}
+	p, err := ParsePacket(initialPacket)
+	if err != nil {
+		return err
+	}
+	switch p.(type) {
+	case *RRQ, *WRQ:
+	default:
+		return fmt.Errorf("tftp: ServeRequest: unexpected opcode %v", p.Op())
+	}
+	if req, ok := p.(*RRQ); ok && s.hasActiveSession(addr) {
+		return fmt.Errorf("tftp: ServeRequest: a transfer for %s from %s is already in flight", req.Filename, addr)
+	}
+
+	session, err := net.ListenUDP("udp", &net.UDPAddr{IP: local.IP})
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			session.Close()
+		case <-stop:
+		}
+	}()
+
+	s.serveSession(p, addr, session, "")
+	return nil
+}
+
+// serveSession runs dispatch's validation and transfer logic against an
+// already-open session socket, so dispatch (accepting RRQ/WRQ off its
+// own listener) and ServeRequest (handed one by an embedder) share every
+// byte of it past the point their callers diverge on where the session
+// socket comes from.
+func (s *Server) serveSession(p Packet, addr *net.UDPAddr, session *net.UDPConn, label string) {
+	pc := applyMiddleware(session, s.Middleware)
+
+	s.Metrics.addRequest()
+	s.active.Add(1)
+	s.Metrics.addActiveSessions(1)
+	s.checkQuota()
+	defer func() {
+		s.active.Add(-1)
+		s.Metrics.addActiveSessions(-1)
+		s.checkQuota()
+	}()
+
+	var filename string
+	var opts Options
+	switch req := p.(type) {
+	case *RRQ:
+		filename, opts = req.Filename, req.Options
+	case *WRQ:
+		filename, opts = req.Filename, req.Options
+	default:
+		writePacket(pc, NewError(ErrCodeIllegalOp, "unexpected opcode"), addr, s.Capture, debugLoggerOf(s.logger()))
+		s.Metrics.recordError(ErrCodeIllegalOp)
+		return
+	}
+
+	if _, ok := p.(*RRQ); ok && s.WriteOnly {
+		writePacket(pc, NewError(ErrCodeAccessViolation, "server is write-only"), addr, s.Capture, debugLoggerOf(s.logger()))
+		s.Metrics.recordError(ErrCodeAccessViolation)
+		return
+	}
+	if _, ok := p.(*WRQ); ok && s.ReadOnly {
+		writePacket(pc, NewError(ErrCodeAccessViolation, "server is read-only"), addr, s.Capture, debugLoggerOf(s.logger()))
+		s.Metrics.recordError(ErrCodeAccessViolation)
+		return
+	}
+	if len(s.TokenKey) > 0 && strings.HasPrefix(filename, tokenFilenamePrefix) {
+		path, ok := verifyTokenFilename(s.TokenKey, filename, time.Now())
+		if !ok {
+			s.warnfSampled(addr.String()+":token", "tftp: rejecting %s from %s%s: missing, invalid, or expired request token", filename, addr, listenerTag(label))
+			writePacket(pc, NewError(ErrCodeAccessViolation, "missing, invalid, or expired request token"), addr, s.Capture, debugLoggerOf(s.logger()))
+			s.Metrics.recordError(ErrCodeAccessViolation)
+			return
+		}
+		filename = path
+	}
+
+	filename = s.rewriteFilename(filename)
+	switch req := p.(type) {
+	case *RRQ:
+		req.Filename = filename
+	case *WRQ:
+		req.Filename = filename
+	}
+
+	var variant string
+	if req, ok := p.(*RRQ); ok {
+		filename, variant = s.resolveCanary(filename, addr)
+		req.Filename = filename
+	}
+
+	if len(s.AuthKey) > 0 && !verifyRequestAuth(s.AuthKey, filename, opts, s.authSkew(), time.Now()) {
+		s.warnfSampled(addr.String()+":auth", "tftp: rejecting %s from %s%s: missing or invalid request signature", filename, addr, listenerTag(label))
+		writePacket(pc, NewError(ErrCodeAccessViolation, "missing or invalid request signature"), addr, s.Capture, debugLoggerOf(s.logger()))
+		s.Metrics.recordError(ErrCodeAccessViolation)
+		return
+	}
+
+	if s.draining.Load() {
+		writePacket(pc, NewError(ErrCodeUndefined, "server is draining"), addr, s.Capture, debugLoggerOf(s.logger()))
+		s.Metrics.recordError(ErrCodeUndefined)
+		return
+	}
+
+	if s.MaxSessionsPerIP > 0 {
+		if active := s.activeSessionsForIP(addr.IP); active >= s.MaxSessionsPerIP {
+			s.warnfSampled(addr.String()+":sessions", "tftp: rejecting %s from %s%s: %d sessions already active from this address (limit %d)", filename, addr, listenerTag(label), active, s.MaxSessionsPerIP)
+			writePacket(pc, NewError(ErrCodeUndefined, "too many concurrent sessions from this address"), addr, s.Capture, debugLoggerOf(s.logger()))
+			s.Metrics.recordError(ErrCodeUndefined)
+			return
+		}
+	}
+
+	nego, ack := parseOptions(opts)
+	sess := &Session{Addr: addr, Filename: filename, Op: p.Op(), Options: nego, Listener: label, Variant: variant}
+
+	if rej := s.reject(sess); rej != nil {
+		warnWithFields(s.logger(), []any{"client", addr.String(), "filename", filename}, "tftp: rejected %s from %s%s: %s", filename, addr, listenerTag(label), rej.Message)
+		writePacket(pc, NewError(rej.Code, rej.render(addr, filename)), addr, s.Capture, debugLoggerOf(s.logger()))
+		s.Metrics.recordError(rej.Code)
+		return
+	}
+
+	as := s.registerSession(session, *sess)
+	defer s.unregisterSession(as.id)
+	if s.IdleTimeout > 0 {
+		pc = &watchdogConn{PacketConn: pc, as: as}
+	}
+	s.emitEvent(TransferEvent{Kind: EventStart, Session: *sess, Time: time.Now()})
+
+	switch req := p.(type) {
+	case *RRQ:
+		s.serveRRQ(pc, addr, req, nego, ack, *sess)
+	case *WRQ:
+		s.serveWRQ(pc, addr, req, nego, ack, *sess)
+	}
+}
+
+// reportTransfer invokes OnTransferComplete and AuditLog, whichever are
+// set, with the Stats accumulated so far for sess. It's safe to call on
+// both the success and failure path, since xferStats already reflects
+// whatever progress was made before a transfer aborted.
+func (s *Server) reportTransfer(sess Session, xferStats *transferStats, duration time.Duration, err error) {
+	if s.OnTransferComplete == nil && s.AuditLog == nil && s.Webhook == nil && !s.eventsOn.Load() {
+		return
+	}
+	stats := Stats{
+		Bytes:           xferStats.bytes,
+		Duration:        duration,
+		Blocks:          xferStats.blocks,
+		Retransmissions: xferStats.retransmissions,
+		DuplicateACKs:   xferStats.duplicateACKs,
+		Timeouts:        xferStats.timeouts,
+		BlockSize:       sess.Options.BlockSize,
+	}
+	if s.OnTransferComplete != nil {
+		s.OnTransferComplete(sess, stats, err)
+	}
+	s.AuditLog.record(sess, stats, err)
+	s.Webhook.notify(sess, stats, err)
+	kind := EventComplete
+	if err != nil {
+		kind = EventError
+	}
+	s.emitEvent(TransferEvent{Kind: kind, Session: sess, Stats: stats, Err: err, Time: time.Now()})
+}
+
+// reject runs the configured Validators against a request, returning
+// the first Rejection raised, or nil if the request is allowed.
+func (s *Server) reject(sess *Session) *Rejection {
+	for _, v := range s.validators() {
+		if rej := v(sess); rej != nil {
+			return rej
+		}
+	}
+	return nil
+}
+
+// rejectContent runs the configured ContentValidators against an
+// uploaded WRQ's assembled content, returning the first Rejection
+// raised, or nil if the content is allowed.
+func (s *Server) rejectContent(sess *Session, data []byte) *Rejection {
+	for _, v := range s.ContentValidators {
+		if rej := v(sess, data); rej != nil {
+			return rej
+		}
+	}
+	return nil
+}
+
+func (s *Server) serveRRQ(conn packetConn, addr *net.UDPAddr, req *RRQ, nego NegotiatedOptions, ack Options, sess Session) {
+	blocks, size, closer, compressed, encrypted, err := s.openBlockSource(s.resolveFilenameCase(req.Filename), nego.BlockSize, addr, nego.Compressed, nego.Encrypted)
+	if err != nil {
+		code, message := payloadErrorCode(err)
+		writePacket(conn, NewError(code, message), addr, s.Capture, debugLoggerOf(s.logger()))
+		s.Metrics.recordError(code)
+		return
+	}
+	if closer != nil {
+		defer closer.Close()
+	}
+
+	if nego.Compressed && !compressed && ack != nil {
+		// openBlockSource couldn't honor OptCompress for this request
+		// (e.g. it's being served via PayloadProvider or ReaderAtHandler
+		// instead of Handler.ReadFile); withdraw the echo parseOptions
+		// already added so the client doesn't try to gunzip raw content.
+		delete(ack, OptCompress)
+	}
+	if nego.Encrypted && !encrypted && ack != nil {
+		// Same reasoning as above: openBlockSource couldn't honor
+		// OptEncrypt, either for the same streaming-source reason or
+		// because the server has no EncryptionKey configured.
+		delete(ack, OptEncrypt)
+	}
+
+	if req.Options != nil {
+		if _, ok := req.Options[OptTransferSize]; ok {
+			if ack == nil {
+				ack = Options{}
+			}
+			ack[OptTransferSize] = strconv.FormatInt(size, 10)
+		}
+	}
+
+	startBlock := uint16(1)
+	// A resumed offset into a compressed or encrypted stream can't be
+	// decompressed/decrypted on its own, so OptOffset is not honored
+	// alongside OptCompress or OptEncrypt.
+	if (compressed || encrypted) && ack != nil {
+		delete(ack, OptOffset)
+	}
+	if req.Options != nil && !compressed && !encrypted {
+		if v, ok := req.Options[OptOffset]; ok {
+			// parseOptions already echoed whatever value the client sent
+			// into ack, same as it does for tsize, without knowing the
+			// file's real length; override that here with the true
+			// outcome now that the file is in hand, clearing the echo
+			// entirely when the requested offset doesn't apply.
+			if ack != nil {
+				delete(ack, OptOffset)
+			}
+			if n, perr := strconv.ParseInt(v, 10, 64); perr == nil && n > 0 && n < size {
+				aligned := (n / int64(nego.BlockSize)) * int64(nego.BlockSize)
+				base := int(aligned / int64(nego.BlockSize))
+				blocks = offsetBlockSource{src: blocks, base: base}
+				startBlock = uint16((base + 1) & 0xffff)
+				if ack == nil {
+					ack = Options{}
+				}
+				ack[OptOffset] = strconv.FormatInt(aligned, 10)
+			}
+		}
+	}
+	if ack != nil && len(ack) == 0 {
+		ack = nil
+	}
+
+	if ack != nil {
+		if err := s.negotiate(conn, addr, ack, nego.BlockSize); err != nil {
+			return
+		}
+	}
+
+	var rto *rtoEstimator
+	if s.AdaptiveTimeout {
+		rto = newRTOEstimator(s.Timeout)
+	}
+
+	start := time.Now()
+	var xferStats transferStats
+	if err := sendData(conn, addr, blocks, nego, s.Timeout, s.Retries, startBlock, &xferStats, s.Capture, debugLoggerOf(s.logger()), s.progressReporter(sess), rto, s.RetryPolicy, newRateLimiter(s.rateLimit()), s.globalLimiter()); err != nil {
+		s.warnf("tftp: RRQ %s from %s%s failed after %d blocks: %v", req.Filename, addr, listenerTag(sess.Listener), xferStats.blocks, err)
+		s.reportTransfer(sess, &xferStats, time.Since(start), err)
+		return
+	}
+	s.logf("tftp: RRQ %s from %s%s: %d bytes in %s, %d retransmissions", req.Filename, addr, listenerTag(sess.Listener), xferStats.bytes, time.Since(start), xferStats.retransmissions)
+	s.Metrics.addBytesSent(xferStats.bytes)
+	s.reportTransfer(sess, &xferStats, time.Since(start), nil)
+}
+
+func (s *Server) serveWRQ(conn packetConn, addr *net.UDPAddr, req *WRQ, nego NegotiatedOptions, ack Options, sess Session) {
+	if ack != nil {
+		if err := writePacket(conn, &OACK{Options: ack}, addr, s.Capture, debugLoggerOf(s.logger())); err != nil {
+			return
+		}
+	} else {
+		if err := writePacket(conn, &ACK{Block: 0}, addr, s.Capture, debugLoggerOf(s.logger())); err != nil {
+			return
+		}
+	}
+
+	start := time.Now()
+	var xferStats transferStats
+	data, err := receiveData(conn, addr, nego, s.Timeout, s.Retries, &xferStats, s.Capture, debugLoggerOf(s.logger()), s.progressReporter(sess), s.RetryPolicy)
+	if err != nil {
+		s.warnf("tftp: WRQ %s from %s%s failed after %d blocks: %v", req.Filename, addr, listenerTag(sess.Listener), xferStats.blocks, err)
+		s.reportTransfer(sess, &xferStats, time.Since(start), err)
+		return
+	}
+	s.logf("tftp: WRQ %s from %s%s: %d bytes in %s, %d retransmissions", req.Filename, addr, listenerTag(sess.Listener), xferStats.bytes, time.Since(start), xferStats.retransmissions)
+	s.Metrics.addBytesReceived(xferStats.bytes)
+	if nego.Encrypted {
+		decrypted, derr := aesGCMDecrypt(s.EncryptionKey, data)
+		if derr != nil {
+			writePacket(conn, NewError(ErrCodeUndefined, "invalid encrypted stream"), addr, s.Capture, debugLoggerOf(s.logger()))
+			s.Metrics.recordError(ErrCodeUndefined)
+			s.reportTransfer(sess, &xferStats, time.Since(start), derr)
+			return
+		}
+		data = decrypted
+	}
+	if nego.Compressed {
+		decompressed, derr := gzipDecompress(data)
+		if derr != nil {
+			writePacket(conn, NewError(ErrCodeUndefined, "invalid compressed stream"), addr, s.Capture, debugLoggerOf(s.logger()))
+			s.Metrics.recordError(ErrCodeUndefined)
+			s.reportTransfer(sess, &xferStats, time.Since(start), derr)
+			return
+		}
+		data = decompressed
+	}
+	if rej := s.rejectContent(&sess, data); rej != nil {
+		msg := rej.render(addr, req.Filename)
+		warnWithFields(s.logger(), []any{"client", addr.String(), "filename", req.Filename}, "tftp: rejected upload %s from %s: %s", req.Filename, addr, rej.Message)
+		writePacket(conn, NewError(rej.Code, msg), addr, s.Capture, debugLoggerOf(s.logger()))
+		s.Metrics.recordError(rej.Code)
+		s.reportTransfer(sess, &xferStats, time.Since(start), errors.New(msg))
+		return
+	}
+	if err := s.Handler.WriteFile(req.Filename, data); err != nil {
+		writePacket(conn, NewError(ErrCodeAccessViolation, err.Error()), addr, s.Capture, debugLoggerOf(s.logger()))
+		s.Metrics.recordError(ErrCodeAccessViolation)
+		s.reportTransfer(sess, &xferStats, time.Since(start), err)
+		return
+	}
+	s.reportTransfer(sess, &xferStats, time.Since(start), nil)
+}
+
+func (s *Server) negotiate(conn packetConn, addr *net.UDPAddr, ack Options, blockSize int) error {
+	// buf is sized one byte larger than the biggest legitimate packet
+	// (an ACK is only 4 bytes, but a rejecting ERROR can be as large as
+	// the negotiated block size), so a datagram that fills it completely
+	// is rejected outright instead of silently truncated; see
+	// errDatagramTooLarge.
+	buf := make([]byte, maxPacketSize(blockSize)+1)
+	if err := writePacket(conn, &OACK{Options: ack}, addr, s.Capture, debugLoggerOf(s.logger())); err != nil {
+		return err
+	}
+	deadline := time.Now().Add(s.Timeout)
+	for {
+		conn.SetReadDeadline(deadline)
+		n, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+		if n == len(buf) {
+			return errDatagramTooLarge
+		}
+		if local, ok := conn.LocalAddr().(*net.UDPAddr); ok {
+			s.Capture.received(local, from, buf[:n])
+			debugPacket(debugLoggerOf(s.logger()), "received", from, buf[:n])
+		}
+		if !sameHost(from, addr) {
+			// A packet from some other host landed on this session's
+			// ephemeral TID; reject it without disturbing the
+			// negotiation still in progress with the real client.
+			writePacket(conn, NewError(ErrCodeUnknownID, "unexpected TID"), from, s.Capture, debugLoggerOf(s.logger()))
+			continue
+		}
+		p, err := ParsePacket(buf[:n])
+		if err != nil {
+			return err
+		}
+		if a, ok := p.(*ACK); !ok || a.Block != 0 {
+			return errors.New("tftp: expected ACK of block 0 after OACK")
+		}
+		return nil
+	}
+}
+
+// listenerTag formats a ListenSpec's Label for inclusion in a log line,
+// e.g. " [vlan10]", or "" when label is empty so a single-listener
+// deployment's log output is unchanged.
+func listenerTag(label string) string {
+	if label == "" {
+		return ""
+	}
+	return " [" + label + "]"
+}
+
+func itoa(n int) string {
+	if n == 0 {
+		return "0"
+	}
+	neg := n < 0
+	if neg {
+		n = -n
+	}
+	var b [20]byte
+	i := len(b)
+	for n > 0 {
+		i--
+		b[i] = byte('0' + n%10)
+		n /= 10
+	}
+	if neg {
+		i--
+		b[i] = '-'
+	}
+	return string(b[i:])
+}
+
+// sameHost reports whether a and b name the same UDP endpoint. It
+// accepts net.Addr rather than *net.UDPAddr so it still works once a
+// session's conn is wrapped by a PacketMiddleware, provided the
+// middleware passes the underlying address through unchanged (the
+// expected case); anything else compares by String instead of failing
+// outright.
+func sameHost(a, b net.Addr) bool {
+	au, aok := a.(*net.UDPAddr)
+	bu, bok := b.(*net.UDPAddr)
+	if aok && bok {
+		return au.IP.Equal(bu.IP) && au.Port == bu.Port
+	}
+	return a.String() == b.String()
+}
+
+// activeSession is the bookkeeping record kept for every in-flight
+// transfer, so an admin interface can list and cancel them. conn is the
+// transfer's ephemeral per-TID socket; closing it unblocks whatever
+// ReadFromUDP/WriteTo call sendData/receiveData is currently making,
+// which aborts the transfer almost immediately rather than waiting out
+// its retry budget.
+type activeSession struct {
+	id           uint64
+	conn         *net.UDPConn
+	session      Session
+	startedAt    time.Time
+	lastActivity atomic.Int64 // UnixNano, updated by watchdogConn on every read
+}
+
+// touch records that a packet was just received for this session,
+// resetting how long it's been idle from the IdleTimeout watchdog's
+// point of view.
+func (as *activeSession) touch() {
+	as.lastActivity.Store(time.Now().UnixNano())
+}
+
+// idleSince reports the last time touch was called, or startedAt if it
+// never has been.
+func (as *activeSession) idleSince() time.Time {
+	return time.Unix(0, as.lastActivity.Load())
+}
+
+// registerSession records a newly dispatched transfer and returns its
+// bookkeeping record.
+func (s *Server) registerSession(conn *net.UDPConn, sess Session) *activeSession {
+	id := s.nextSessionID.Add(1)
+	as := &activeSession{id: id, conn: conn, session: sess, startedAt: time.Now()}
+	as.lastActivity.Store(as.startedAt.UnixNano())
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	if s.sessions == nil {
+		s.sessions = make(map[uint64]*activeSession)
+	}
+	s.sessions[id] = as
+	return as
+}
+
+func (s *Server) unregisterSession(id uint64) {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	delete(s.sessions, id)
+}
+
+// hasActiveSession reports whether a session is already being served
+// for addr, so dispatch can recognize a retransmitted RRQ from a client
+// whose first request is still in flight.
+func (s *Server) hasActiveSession(addr *net.UDPAddr) bool {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	for _, as := range s.sessions {
+		if as.session.Addr.String() == addr.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// activeSessionsForIP counts how many sessions are currently being
+// served to ip, across all of its source ports, so MaxSessionsPerIP can
+// be enforced per device rather than per individual TID.
+func (s *Server) activeSessionsForIP(ip net.IP) int {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	n := 0
+	for _, as := range s.sessions {
+		if udpAddr, ok := as.session.Addr.(*net.UDPAddr); ok && udpAddr.IP.Equal(ip) {
+			n++
+		}
+	}
+	return n
+}
+
+// Sessions returns a snapshot of every transfer currently in flight.
+func (s *Server) Sessions() []SessionInfo {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	infos := make([]SessionInfo, 0, len(s.sessions))
+	for _, as := range s.sessions {
+		infos = append(infos, SessionInfo{
+			ID:        as.id,
+			Addr:      as.session.Addr.String(),
+			Filename:  as.session.Filename,
+			Op:        as.session.Op.String(),
+			StartedAt: as.startedAt,
+		})
+	}
+	return infos
+}
+
+// CancelSession aborts the in-flight transfer with the given ID by
+// closing its socket, and reports whether a session with that ID was
+// found. A transfer that finishes naturally between lookup and close is
+// treated as found, since the caller's intent (stop it) was satisfied
+// either way.
+func (s *Server) CancelSession(id uint64) bool {
+	s.sessionsMu.Lock()
+	as, ok := s.sessions[id]
+	s.sessionsMu.Unlock()
+	if !ok {
+		return false
+	}
+	as.conn.Close()
+	return true
+}
+
+// Drain stops the server from accepting new transfers; requests already
+// in flight run to completion. Pair with Sessions/CancelSession to also
+// cut short any that are stuck, so the process can shut down promptly.
+func (s *Server) Drain() {
+	s.draining.Store(true)
+}
+
+// Undrain resumes accepting new transfers after Drain.
+func (s *Server) Undrain() {
+	s.draining.Store(false)
+}
+
+// Draining reports whether Drain has been called without a matching
+// Undrain.
+func (s *Server) Draining() bool {
+	return s.draining.Load()
+}
+
+// DefaultEventBacklog is how many TransferEvents Events' channel
+// buffers before emitEvent starts dropping the oldest-pending event
+// rather than blocking the transfer that produced it.
+const DefaultEventBacklog = 256
+
+// Events returns a channel that receives a TransferEvent — EventStart,
+// EventProgress, EventComplete, or EventError — for every RRQ/WRQ this
+// server serves, as an alternative to OnTransferComplete for an
+// embedder that wants to fan transfers into its own pipeline (a message
+// bus, a UI) rather than handle them inline on the goroutine that's
+// serving the transfer. Calling Events turns this accounting on; a
+// transfer already dispatched before the first call to Events never
+// appears on the channel. Calling Events more than once returns the
+// same channel.
+//
+// The channel is buffered (DefaultEventBacklog) but never blocks a
+// transfer waiting on a slow or absent reader: an event that doesn't
+// fit is dropped (and logged via Logger) rather than queued, so a
+// subscriber that falls behind loses events instead of stalling every
+// transfer in the server behind it.
+func (s *Server) Events() <-chan TransferEvent {
+	s.eventsOnce.Do(func() {
+		s.eventsCh = make(chan TransferEvent, DefaultEventBacklog)
+		s.eventsOn.Store(true)
+	})
+	return s.eventsCh
+}
+
+// emitEvent sends ev to Events' channel if a caller has subscribed,
+// dropping it instead of blocking if the channel is full.
+func (s *Server) emitEvent(ev TransferEvent) {
+	if !s.eventsOn.Load() {
+		return
+	}
+	select {
+	case s.eventsCh <- ev:
+	default:
+		s.warnf("tftp: dropping %s event for %s %s: Events subscriber isn't keeping up", ev.Kind, ev.Session.Op, ev.Session.Filename)
+	}
+}
+
+// progressReporter returns a func(*transferStats) that emits an
+// EventProgress for sess on every call, or nil if nothing is
+// subscribed to Events, so sendData/receiveData can skip the snapshot
+// work entirely in the overwhelmingly common case where it would go
+// unused.
+func (s *Server) progressReporter(sess Session) func(*transferStats) {
+	if !s.eventsOn.Load() {
+		return nil
+	}
+	return func(st *transferStats) {
+		if st == nil {
+			return
+		}
+		s.emitEvent(TransferEvent{
+			Kind:    EventProgress,
+			Session: sess,
+			Stats: Stats{
+				Bytes:           st.bytes,
+				Blocks:          st.blocks,
+				Retransmissions: st.retransmissions,
+				DuplicateACKs:   st.duplicateACKs,
+				Timeouts:        st.timeouts,
+				BlockSize:       sess.Options.BlockSize,
+			},
+			Time: time.Now(),
+		})
+	}
+}
+
+// shutdownPollInterval is how often Shutdown rechecks Sessions for
+// completion while waiting out ctx's deadline.
+const shutdownPollInterval = 50 * time.Millisecond
+
+// Shutdown drains the server (see Drain) and then waits for every
+// in-flight transfer to finish on its own before closing the listening
+// socket(s) (see Close), so a caller can give active transfers a grace
+// period to complete instead of cutting them off mid-transfer. If ctx is
+// done before every session finishes, Shutdown closes the listening
+// socket(s) immediately, returns ctx's error, and leaves whatever
+// sessions are still running to fail on their own when their sockets'
+// next read or write errors out.
+//
+// Shutdown does not resume accepting new transfers; call Undrain first
+// if that's ever wanted after a Shutdown call returns.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.Drain()
+
+	ticker := time.NewTicker(shutdownPollInterval)
+	defer ticker.Stop()
+	for len(s.Sessions()) > 0 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			s.Close()
+			return ctx.Err()
+		}
+	}
+
+	return s.Close()
+}
+
+// watchdogConn wraps a session's net.PacketConn to record the time of
+// its most recent successful read on the session's activeSession, so
+// the IdleTimeout watchdog can tell a session that's silently gone dark
+// apart from one still actively exchanging packets. It's only applied
+// when IdleTimeout is set, since MaxSessionDuration alone needs nothing
+// beyond the startedAt dispatch already records.
+type watchdogConn struct {
+	net.PacketConn
+	as *activeSession
+}
+
+func (w *watchdogConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	n, addr, err := w.PacketConn.ReadFrom(p)
+	if err == nil {
+		w.as.touch()
+	}
+	return n, addr, err
+}
+
+// sessionWatchdogInterval picks how often runSessionWatchdog scans
+// active sessions: a quarter of whichever of IdleTimeout/
+// MaxSessionDuration is set and smaller, so a limit gets enforced
+// within a reasonable margin of itself rather than some fixed interval
+// unrelated to the limit's own scale. Returns zero, meaning "don't run
+// the watchdog at all", when neither limit is configured.
+func (s *Server) sessionWatchdogInterval() time.Duration {
+	limit := s.IdleTimeout
+	if s.MaxSessionDuration > 0 && (limit == 0 || s.MaxSessionDuration < limit) {
+		limit = s.MaxSessionDuration
+	}
+	if limit <= 0 {
+		return 0
+	}
+	if interval := limit / 4; interval > time.Millisecond {
+		return interval
+	}
+	return time.Millisecond
+}
+
+// runSessionWatchdog periodically closes any session that's exceeded
+// IdleTimeout or MaxSessionDuration, until done is closed.
+func (s *Server) runSessionWatchdog(interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			s.reapStuckSessions()
+		}
+	}
+}
+
+// runLogSamplerFlush periodically flushes s.logSampler(), so a key that
+// stops recurring still gets its suppressed-count summary logged
+// instead of waiting on a next occurrence that never comes. It runs for
+// the lifetime of a single serveOn call, on the same window as
+// LogSampleWindow (or defaultLogSampleWindow, if that's left zero),
+// until done is closed.
+func (s *Server) runLogSamplerFlush(done <-chan struct{}) {
+	ticker := time.NewTicker(s.logSampler().window)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			s.logSampler().flush(s.warnf)
+		}
+	}
+}
+
+// reapStuckSessions closes every active session that has exceeded
+// IdleTimeout or MaxSessionDuration, sending each one an ERROR first so
+// a well-behaved client at least learns why before its socket closes
+// out from under it; a client that's simply stopped responding never
+// sees it, but closing the socket still unblocks sendData/receiveData
+// and reclaims the session the same way CancelSession does.
+func (s *Server) reapStuckSessions() {
+	now := time.Now()
+	s.sessionsMu.Lock()
+	var stuck []*activeSession
+	for _, as := range s.sessions {
+		switch {
+		case s.MaxSessionDuration > 0 && now.Sub(as.startedAt) > s.MaxSessionDuration:
+			stuck = append(stuck, as)
+		case s.IdleTimeout > 0 && now.Sub(as.idleSince()) > s.IdleTimeout:
+			stuck = append(stuck, as)
+		}
+	}
+	s.sessionsMu.Unlock()
+
+	for _, as := range stuck {
+		s.warnf("tftp: closing stuck session %d (%s %s from %s)", as.id, as.session.Op, as.session.Filename, as.session.Addr)
+		writePacket(as.conn, NewError(ErrCodeUndefined, "session timed out"), as.session.Addr, s.Capture, debugLoggerOf(s.logger()))
+		as.conn.Close()
+	}
+}
@@ -0,0 +1,130 @@
+package tftp
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+// capturePacket reads one datagram off conn, standing in for an
+// embedder's own UDP demultiplexer handing the bytes to ServeRequest.
+func capturePacket(t *testing.T, conn *net.UDPConn) ([]byte, *net.UDPAddr) {
+	t.Helper()
+	buf := make([]byte, maxPacketSize(DefaultBlockSize)+1)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, addr, err := conn.ReadFromUDP(buf)
+	if err != nil {
+		t.Fatalf("ReadFromUDP: %v", err)
+	}
+	return buf[:n], addr
+}
+
+func TestServeRequestServesSingleSession(t *testing.T) {
+	want := bytes.Repeat([]byte("r"), DefaultBlockSize*2)
+	s := NewServer("", &memHandler{files: map[string][]byte{"file.bin": want}})
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer conn.Close()
+
+	type result struct {
+		data []byte
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		c := NewClient(conn.LocalAddr().String())
+		c.Timeout = time.Second
+		data, err := c.GetBytes("file.bin", "octet")
+		done <- result{data, err}
+	}()
+
+	pkt, addr := capturePacket(t, conn)
+	if err := s.ServeRequest(context.Background(), conn, addr, pkt); err != nil {
+		t.Fatalf("ServeRequest: %v", err)
+	}
+
+	res := <-done
+	if res.err != nil {
+		t.Fatalf("GetBytes: %v", res.err)
+	}
+	if !bytes.Equal(res.data, want) {
+		t.Fatalf("GetBytes returned %d bytes, want %d", len(res.data), len(want))
+	}
+}
+
+func TestServeRequestRejectsNonUDPClientAddr(t *testing.T) {
+	s := NewServer("", &memHandler{files: map[string][]byte{}})
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer conn.Close()
+
+	rrq := &RRQ{Filename: "file.bin", Mode: "octet"}
+	pkt, err := rrq.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary: %v", err)
+	}
+
+	if err := s.ServeRequest(context.Background(), conn, &net.TCPAddr{}, pkt); err == nil {
+		t.Fatal("ServeRequest: want an error for a non-UDP clientAddr, got nil")
+	}
+}
+
+func TestServeRequestRejectsMalformedPacket(t *testing.T) {
+	s := NewServer("", &memHandler{files: map[string][]byte{}})
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer conn.Close()
+
+	addr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 12345}
+	if err := s.ServeRequest(context.Background(), conn, addr, []byte{0, 99}); err == nil {
+		t.Fatal("ServeRequest: want an error for a malformed packet, got nil")
+	}
+}
+
+func TestServeRequestCtxCancelAbortsTransfer(t *testing.T) {
+	s := NewServer("", &memHandler{files: map[string][]byte{"file.bin": bytes.Repeat([]byte("r"), DefaultBlockSize*3)}})
+
+	serverConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer serverConn.Close()
+
+	clientConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer clientConn.Close()
+
+	rrq := &RRQ{Filename: "file.bin", Mode: "octet"}
+	pkt, err := rrq.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary: %v", err)
+	}
+	if _, err := clientConn.WriteTo(pkt, serverConn.LocalAddr()); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	req, addr := capturePacket(t, serverConn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	// clientConn never ACKs, so without ctx's cancellation sendData would
+	// keep retransmitting for the server's full Timeout*Retries budget.
+	start := time.Now()
+	if err := s.ServeRequest(ctx, serverConn, addr, req); err != nil {
+		t.Fatalf("ServeRequest: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("ServeRequest took %s after ctx expired, want it to abort quickly", elapsed)
+	}
+}
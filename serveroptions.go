@@ -0,0 +1,187 @@
+package tftp
+
+import (
+	"fmt"
+	"time"
+)
+
+// ServerOption configures a Server built via NewServerWithOptions.
+// Unlike setting a Server's exported fields directly after NewServer
+// returns, each option is applied (and validated) up front, so an
+// invalid combination is reported as an error at construction instead
+// of surfacing later as confusing runtime behavior.
+type ServerOption func(*Server) error
+
+// NewServerWithOptions builds on NewServer, applying opts in order and
+// failing on the first one that reports an invalid configuration. It
+// exists for callers that want that up-front validation; a Server
+// built via plain NewServer followed by setting fields directly works
+// exactly as before and is unaffected by anything in this file.
+func NewServerWithOptions(addr string, h Handler, opts ...ServerOption) (*Server, error) {
+	s := NewServer(addr, h)
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// WithTimeout sets how long the server waits for an ACK before
+// retransmitting the current window. d must be positive.
+func WithTimeout(d time.Duration) ServerOption {
+	return func(s *Server) error {
+		if d <= 0 {
+			return fmt.Errorf("tftp: timeout must be positive, got %v", d)
+		}
+		s.Timeout = d
+		return nil
+	}
+}
+
+// WithRetries sets how many times the server retransmits a window
+// before abandoning a transfer. n must not be negative.
+func WithRetries(n int) ServerOption {
+	return func(s *Server) error {
+		if n < 0 {
+			return fmt.Errorf("tftp: retries must not be negative, got %d", n)
+		}
+		s.Retries = n
+		return nil
+	}
+}
+
+// WithAdaptiveTimeout sets Server.AdaptiveTimeout.
+func WithAdaptiveTimeout(enabled bool) ServerOption {
+	return func(s *Server) error {
+		s.AdaptiveTimeout = enabled
+		return nil
+	}
+}
+
+// WithLogger sets the Logger the server reports diagnostic output to.
+// logger must not be nil; use NewServer's default no-op Logger to
+// silence output instead of passing nil here.
+func WithLogger(logger Logger) ServerOption {
+	return func(s *Server) error {
+		if logger == nil {
+			return fmt.Errorf("tftp: logger must not be nil")
+		}
+		s.Logger = logger
+		return nil
+	}
+}
+
+// WithMiddleware sets the PacketMiddleware chain wrapping every
+// session's ephemeral socket.
+func WithMiddleware(mw ...PacketMiddleware) ServerOption {
+	return func(s *Server) error {
+		s.Middleware = mw
+		return nil
+	}
+}
+
+// WithValidators sets the Validators every RRQ/WRQ is checked against
+// before it reaches the backend, the usual place to enforce
+// allow/deny-list access control.
+func WithValidators(v ...Validator) ServerOption {
+	return func(s *Server) error {
+		s.Validators = v
+		return nil
+	}
+}
+
+// WithRateLimit sets the per-transfer outbound DATA throughput cap, in
+// bytes/sec. bytesPerSec must not be negative; zero means unlimited.
+func WithRateLimit(bytesPerSec int64) ServerOption {
+	return func(s *Server) error {
+		if bytesPerSec < 0 {
+			return fmt.Errorf("tftp: rate limit must not be negative, got %d", bytesPerSec)
+		}
+		s.RateLimit = bytesPerSec
+		return nil
+	}
+}
+
+// WithGlobalRateLimit sets the combined outbound DATA throughput cap
+// shared across every active session, in bytes/sec. bytesPerSec must
+// not be negative; zero means unlimited.
+func WithGlobalRateLimit(bytesPerSec int64) ServerOption {
+	return func(s *Server) error {
+		if bytesPerSec < 0 {
+			return fmt.Errorf("tftp: global rate limit must not be negative, got %d", bytesPerSec)
+		}
+		s.GlobalRateLimit = bytesPerSec
+		return nil
+	}
+}
+
+// WithUpstream sets the host:port of another TFTP server that RRQs are
+// relayed to on a local miss, and whether a relayed file is then cached
+// locally via Handler.WriteFile. Since caching requires a Handler to
+// write through, cache is rejected when h (passed to
+// NewServerWithOptions) is nil.
+func WithUpstream(addr string, cache bool) ServerOption {
+	return func(s *Server) error {
+		if cache && s.Handler == nil {
+			return fmt.Errorf("tftp: CacheUpstream requires a Handler")
+		}
+		s.Upstream = addr
+		s.CacheUpstream = cache
+		return nil
+	}
+}
+
+// WithAuthKey requires every RRQ/WRQ to carry a valid signature over
+// its filename, and bounds how far a signed request's embedded
+// timestamp may differ from the server's own clock before it's
+// rejected. key must not be empty; skew must not be negative. Pass
+// skew as zero to fall back to DefaultAuthSkew.
+func WithAuthKey(key []byte, skew time.Duration) ServerOption {
+	return func(s *Server) error {
+		if len(key) == 0 {
+			return fmt.Errorf("tftp: auth key must not be empty")
+		}
+		if skew < 0 {
+			return fmt.Errorf("tftp: auth skew must not be negative, got %v", skew)
+		}
+		s.AuthKey = key
+		s.AuthSkew = skew
+		return nil
+	}
+}
+
+// WithOnTransferComplete sets the hook called once for every RRQ/WRQ
+// that reaches sendData/receiveData, whether it succeeds or fails.
+func WithOnTransferComplete(fn func(Session, Stats, error)) ServerOption {
+	return func(s *Server) error {
+		s.OnTransferComplete = fn
+		return nil
+	}
+}
+
+// WithReadOnly sets Server.ReadOnly, rejecting every WRQ. It conflicts
+// with a Server already configured WithWriteOnly, since that would
+// leave neither request type servable.
+func WithReadOnly() ServerOption {
+	return func(s *Server) error {
+		if s.WriteOnly {
+			return fmt.Errorf("tftp: ReadOnly conflicts with WriteOnly")
+		}
+		s.ReadOnly = true
+		return nil
+	}
+}
+
+// WithWriteOnly sets Server.WriteOnly, rejecting every RRQ. It
+// conflicts with a Server already configured WithReadOnly, since that
+// would leave neither request type servable.
+func WithWriteOnly() ServerOption {
+	return func(s *Server) error {
+		if s.ReadOnly {
+			return fmt.Errorf("tftp: WriteOnly conflicts with ReadOnly")
+		}
+		s.WriteOnly = true
+		return nil
+	}
+}
@@ -0,0 +1,39 @@
+package tftp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewServerWithOptionsAppliesOptions(t *testing.T) {
+	s, err := NewServerWithOptions("", &memHandler{files: map[string][]byte{}},
+		WithTimeout(5*time.Second),
+		WithRetries(2),
+		WithRateLimit(1024),
+	)
+	if err != nil {
+		t.Fatalf("NewServerWithOptions: %v", err)
+	}
+	if s.Timeout != 5*time.Second || s.Retries != 2 || s.RateLimit != 1024 {
+		t.Fatalf("options not applied: %+v", s)
+	}
+}
+
+func TestNewServerWithOptionsRejectsInvalidCombination(t *testing.T) {
+	_, err := NewServerWithOptions("", nil, WithUpstream("upstream:69", true))
+	if err == nil {
+		t.Fatal("expected an error for CacheUpstream without a Handler")
+	}
+}
+
+func TestNewServerWithOptionsRejectsInvalidTimeout(t *testing.T) {
+	if _, err := NewServerWithOptions("", &memHandler{}, WithTimeout(0)); err == nil {
+		t.Fatal("expected an error for a non-positive timeout")
+	}
+}
+
+func TestNewServerWithOptionsRejectsReadOnlyAndWriteOnlyTogether(t *testing.T) {
+	if _, err := NewServerWithOptions("", &memHandler{}, WithReadOnly(), WithWriteOnly()); err == nil {
+		t.Fatal("expected an error for ReadOnly combined with WriteOnly")
+	}
+}
@@ -0,0 +1,28 @@
+package tftp
+
+import "net"
+
+// Session describes a single in-progress RRQ/WRQ, including the options
+// negotiated for it. It's handed to Validators and will back the
+// forthcoming admin inspection API, so dashboards and hooks can see
+// exactly what a given client negotiated (critical when debugging mixed
+// firmware fleets where blksize/windowsize support varies).
+type Session struct {
+	Addr     net.Addr
+	Filename string
+	Op       OpCode
+	Options  NegotiatedOptions
+
+	// Listener is the Label of the ListenSpec this session's request
+	// arrived on, or empty when the server was started via Serve or
+	// ListenAndServe rather than ListenAndServeAll. It lets logs,
+	// metrics, and OnTransferComplete tell requests apart when one
+	// Server instance is bound to several interfaces at once, e.g. a
+	// separate provisioning VLAN per listener.
+	Listener string
+
+	// Variant is the Name of the CanaryVariant this RRQ was matched
+	// against, or empty if no CanaryRule applied (including every
+	// WRQ, which CanaryRules never affect). See Server.CanaryRules.
+	Variant string
+}
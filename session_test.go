@@ -0,0 +1,61 @@
+package tftp
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestValidatorReceivesNegotiatedOptions(t *testing.T) {
+	sessions := make(chan *Session, 1)
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer conn.Close()
+
+	h := &memHandler{files: map[string][]byte{"file.bin": []byte("hello")}}
+	s := NewServer("", h)
+	s.Validators = append(s.Validators, func(sess *Session) *Rejection {
+		sessions <- sess
+		return nil
+	})
+	srvConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s.conn = srvConn
+	go s.Serve(srvConn)
+	defer srvConn.Close()
+
+	req := &RRQ{
+		Filename: "file.bin",
+		Mode:     "octet",
+		Options: Options{
+			OptBlockSize:  "1024",
+			OptWindowSize: "4",
+		},
+	}
+	if err := writePacket(conn, req, srvConn.LocalAddr().(*net.UDPAddr), nil, nil); err != nil {
+		t.Fatalf("writePacket: %v", err)
+	}
+
+	select {
+	case sess := <-sessions:
+		if sess.Filename != "file.bin" {
+			t.Errorf("Filename = %q, want %q", sess.Filename, "file.bin")
+		}
+		if sess.Op != OpRRQ {
+			t.Errorf("Op = %v, want %v", sess.Op, OpRRQ)
+		}
+		if sess.Options.BlockSize != 1024 {
+			t.Errorf("BlockSize = %d, want 1024", sess.Options.BlockSize)
+		}
+		if sess.Options.WindowSize != 4 {
+			t.Errorf("WindowSize = %d, want 4", sess.Options.WindowSize)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for Validator to run")
+	}
+}
@@ -0,0 +1,109 @@
+package tftp
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+// delayingConn wraps a net.PacketConn to sleep before every write,
+// giving a test time to observe the session it belongs to as still
+// in-flight.
+type delayingConn struct {
+	net.PacketConn
+	delay time.Duration
+}
+
+func (c *delayingConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	time.Sleep(c.delay)
+	return c.PacketConn.WriteTo(p, addr)
+}
+
+func delayMiddleware(delay time.Duration) PacketMiddleware {
+	return func(pc net.PacketConn) net.PacketConn {
+		return &delayingConn{PacketConn: pc, delay: delay}
+	}
+}
+
+func TestServerShutdownWaitsForInFlightTransfers(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	want := bytes.Repeat([]byte("s"), DefaultBlockSize*3)
+	s := NewServer("", &memHandler{files: map[string][]byte{"file.bin": want}})
+	s.conn = conn
+	s.Middleware = append(s.Middleware, delayMiddleware(20*time.Millisecond))
+	go s.Serve(conn)
+	defer conn.Close()
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.GetBytes("file.bin", "octet")
+		done <- err
+	}()
+
+	// Give the transfer time to start and register before draining.
+	time.Sleep(30 * time.Millisecond)
+
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+	if s.Sessions() != nil && len(s.Sessions()) != 0 {
+		t.Fatalf("Sessions() after Shutdown = %v, want none", s.Sessions())
+	}
+}
+
+func TestServerShutdownReturnsContextErrorWhenGraceExpires(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	want := bytes.Repeat([]byte("s"), DefaultBlockSize*5)
+	s := NewServer("", &memHandler{files: map[string][]byte{"file.bin": want}})
+	s.conn = conn
+	s.Middleware = append(s.Middleware, delayMiddleware(200*time.Millisecond))
+	go s.Serve(conn)
+	defer conn.Close()
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = 2 * time.Second
+	go c.GetBytes("file.bin", "octet")
+
+	time.Sleep(30 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); err == nil {
+		t.Fatal("Shutdown: want a context deadline error, got nil")
+	}
+}
+
+func TestServerShutdownRejectsNewTransfers(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s := NewServer("", &memHandler{files: map[string][]byte{"file.bin": []byte("data")}})
+	s.conn = conn
+	go s.Serve(conn)
+	defer conn.Close()
+
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = 200 * time.Millisecond
+	c.Retries = 1
+	if _, err := c.GetBytes("file.bin", "octet"); err == nil {
+		t.Fatal("GetBytes: want an error after Shutdown, got nil")
+	}
+}
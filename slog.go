@@ -0,0 +1,52 @@
+package tftp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// SlogLogger adapts an *slog.Logger to the Logger, DebugLogger,
+// WarnLogger, and FieldLogger interfaces, for embedders who have
+// already centralized on log/slog. Printf messages are logged at
+// slog.LevelInfo, Warnf at slog.LevelWarn, and Debugf — the optional,
+// much chattier per-packet trace, see DebugLogger — at
+// slog.LevelDebug, so an operator can filter packet-level noise out
+// with the handler's level without this package needing its own
+// verbosity knob.
+type SlogLogger struct {
+	l *slog.Logger
+}
+
+// NewSlogLogger returns a SlogLogger that writes through l. If l is
+// nil, slog.Default() is used.
+func NewSlogLogger(l *slog.Logger) *SlogLogger {
+	if l == nil {
+		l = slog.Default()
+	}
+	return &SlogLogger{l: l}
+}
+
+// Printf logs an info-level message.
+func (s *SlogLogger) Printf(format string, args ...any) {
+	s.l.Log(context.Background(), slog.LevelInfo, fmt.Sprintf(format, args...))
+}
+
+// Debugf logs a debug-level message, implementing the optional
+// DebugLogger capability.
+func (s *SlogLogger) Debugf(format string, args ...any) {
+	s.l.Log(context.Background(), slog.LevelDebug, fmt.Sprintf(format, args...))
+}
+
+// Warnf logs a warn-level message, implementing the optional
+// WarnLogger capability.
+func (s *SlogLogger) Warnf(format string, args ...any) {
+	s.l.Log(context.Background(), slog.LevelWarn, fmt.Sprintf(format, args...))
+}
+
+// WithFields returns a SlogLogger whose messages carry keyvals as
+// structured attributes, implementing the optional FieldLogger
+// capability. keyvals is passed straight through to slog.Logger.With.
+func (s *SlogLogger) WithFields(keyvals ...any) Logger {
+	return &SlogLogger{l: s.l.With(keyvals...)}
+}
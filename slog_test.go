@@ -0,0 +1,52 @@
+package tftp
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogLoggerPrintfAndDebugf(t *testing.T) {
+	var buf strings.Builder
+	h := slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+	l := NewSlogLogger(slog.New(h))
+
+	var _ DebugLogger = l
+	var _ WarnLogger = l
+	var _ FieldLogger = l
+
+	l.Printf("RRQ %s from %s", "boot.img", "10.0.0.5")
+	l.Debugf("sent block=%d", 1)
+	l.Warnf("retry budget exhausted")
+
+	out := buf.String()
+	if !strings.Contains(out, "level=INFO") || !strings.Contains(out, "RRQ boot.img from 10.0.0.5") {
+		t.Errorf("output = %q, want an info-level line with the formatted text", out)
+	}
+	if !strings.Contains(out, "level=DEBUG") || !strings.Contains(out, "sent block=1") {
+		t.Errorf("output = %q, want a debug-level line with the formatted text", out)
+	}
+	if !strings.Contains(out, "level=WARN") || !strings.Contains(out, "retry budget exhausted") {
+		t.Errorf("output = %q, want a warn-level line with the formatted text", out)
+	}
+}
+
+func TestSlogLoggerWithFieldsAttachesAttrs(t *testing.T) {
+	var buf strings.Builder
+	h := slog.NewTextHandler(&buf, nil)
+	l := NewSlogLogger(slog.New(h))
+
+	l.WithFields("client", "10.0.0.5").Printf("RRQ %s", "boot.img")
+
+	out := buf.String()
+	if !strings.Contains(out, "client=10.0.0.5") {
+		t.Errorf("output = %q, want the client attribute attached", out)
+	}
+}
+
+func TestNewSlogLoggerDefaultsToSlogDefault(t *testing.T) {
+	l := NewSlogLogger(nil)
+	if l.l != slog.Default() {
+		t.Errorf("NewSlogLogger(nil).l = %v, want slog.Default()", l.l)
+	}
+}
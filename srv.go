@@ -0,0 +1,75 @@
+package tftp
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ResolveSRV looks up the _tftp._udp SRV records for domain and returns
+// the servers they name as an ordered list of host:port addresses,
+// suitable for Client.Addrs: this lets a fleet's boot traffic be
+// repointed at a new set of provisioning servers by updating DNS
+// instead of re-imaging every device's baked-in server address. The
+// order follows RFC 2782: ascending priority, with same-priority
+// records drawn out in weighted-random order so a heavier weight tends
+// to land earlier within its priority group without ever starving a
+// lighter one.
+func ResolveSRV(ctx context.Context, domain string) ([]string, error) {
+	_, records, err := net.DefaultResolver.LookupSRV(ctx, "tftp", "udp", domain)
+	if err != nil {
+		return nil, fmt.Errorf("tftp: resolve SRV for %s: %w", domain, err)
+	}
+	return orderSRV(records), nil
+}
+
+// orderSRV groups records by priority (ascending) and, within each
+// group, orders them by weightedOrder.
+func orderSRV(records []*net.SRV) []string {
+	byPriority := map[uint16][]*net.SRV{}
+	var priorities []uint16
+	for _, r := range records {
+		if _, ok := byPriority[r.Priority]; !ok {
+			priorities = append(priorities, r.Priority)
+		}
+		byPriority[r.Priority] = append(byPriority[r.Priority], r)
+	}
+	sort.Slice(priorities, func(i, j int) bool { return priorities[i] < priorities[j] })
+
+	addrs := make([]string, 0, len(records))
+	for _, p := range priorities {
+		addrs = append(addrs, weightedOrder(byPriority[p])...)
+	}
+	return addrs
+}
+
+// weightedOrder repeatedly draws one record out of group at random,
+// weighted by Weight plus one (RFC 2782's algorithm, adjusted so a
+// weight of 0 can still be drawn rather than only ever going last),
+// until none remain, and formats each as a host:port address.
+func weightedOrder(group []*net.SRV) []string {
+	remaining := append([]*net.SRV(nil), group...)
+	out := make([]string, 0, len(remaining))
+	for len(remaining) > 0 {
+		total := 0
+		for _, r := range remaining {
+			total += int(r.Weight) + 1
+		}
+		pick := rand.Intn(total)
+		running := 0
+		for i, r := range remaining {
+			running += int(r.Weight) + 1
+			if pick < running {
+				host := strings.TrimSuffix(r.Target, ".")
+				out = append(out, net.JoinHostPort(host, strconv.Itoa(int(r.Port))))
+				remaining = append(remaining[:i], remaining[i+1:]...)
+				break
+			}
+		}
+	}
+	return out
+}
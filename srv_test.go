@@ -0,0 +1,45 @@
+package tftp
+
+import (
+	"net"
+	"testing"
+)
+
+func TestOrderSRVSortsByPriorityAscending(t *testing.T) {
+	records := []*net.SRV{
+		{Target: "b.example.com.", Port: 69, Priority: 10, Weight: 0},
+		{Target: "a.example.com.", Port: 69, Priority: 0, Weight: 0},
+		{Target: "c.example.com.", Port: 69, Priority: 5, Weight: 0},
+	}
+	got := orderSRV(records)
+	want := []string{"a.example.com:69", "c.example.com:69", "b.example.com:69"}
+	if len(got) != len(want) {
+		t.Fatalf("orderSRV = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("orderSRV[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestWeightedOrderIncludesEveryRecordExactlyOnce(t *testing.T) {
+	group := []*net.SRV{
+		{Target: "a.example.com.", Port: 69, Weight: 100},
+		{Target: "b.example.com.", Port: 69, Weight: 0},
+		{Target: "c.example.com.", Port: 69, Weight: 1},
+	}
+	got := weightedOrder(group)
+	if len(got) != len(group) {
+		t.Fatalf("weightedOrder returned %d addrs, want %d", len(got), len(group))
+	}
+	seen := map[string]bool{}
+	for _, addr := range got {
+		seen[addr] = true
+	}
+	for _, want := range []string{"a.example.com:69", "b.example.com:69", "c.example.com:69"} {
+		if !seen[want] {
+			t.Errorf("weightedOrder result %v missing %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package tftp
+
+import "time"
+
+// Stats summarizes one completed transfer. It's meant for automation
+// that wants to record throughput and watch for degrading links, not
+// for driving transfer logic itself.
+type Stats struct {
+	// Bytes is the number of file-content bytes transferred (the
+	// decoded netascii length when Mode is "netascii", not the raw
+	// wire length).
+	Bytes int64
+	// Duration is the wall-clock time from sending the initial request
+	// to the transfer's last ACK or DATA packet.
+	Duration time.Duration
+	// Blocks is the number of DATA blocks sent or received.
+	Blocks int
+	// Retransmissions is the number of DATA/ACK packets retransmitted
+	// due to a timeout or an out-of-order reply. A climbing count
+	// across transfers to the same host is an early sign of a
+	// degrading link.
+	Retransmissions int
+	// DuplicateACKs is the number of ACKs received that didn't
+	// acknowledge the most recently sent window, typically a late or
+	// re-sent ACK arriving after a retransmission already happened. A
+	// high count alongside a low Timeouts count suggests the link is
+	// asymmetric (replies are slow, not lost) rather than lossy.
+	DuplicateACKs int
+	// Timeouts is the number of times a read deadline expired waiting
+	// for a reply, before any retransmission occurred. Unlike
+	// Retransmissions, this counts the underlying cause rather than the
+	// recovery action, so it isolates genuine packet loss from
+	// out-of-order or duplicate replies.
+	Timeouts int
+	// BlockSize is the negotiated block size actually used.
+	BlockSize int
+}
+
+// Throughput returns the transfer's effective rate in bytes per second,
+// or 0 if Duration is zero (e.g. Stats hasn't been populated yet).
+func (s Stats) Throughput() float64 {
+	if s.Duration <= 0 {
+		return 0
+	}
+	return float64(s.Bytes) / s.Duration.Seconds()
+}
+
+// transferStats accumulates the block, retransmission, and loss counts
+// for a single sendData or receiveData/receiveDataFrom call, so those
+// functions don't need to know about Stats or Client/Server at all.
+type transferStats struct {
+	bytes           int64
+	blocks          int
+	retransmissions int
+	duplicateACKs   int
+	timeouts        int
+}
@@ -0,0 +1,112 @@
+package tftp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestClientGetStats(t *testing.T) {
+	want := bytes.Repeat([]byte("s"), DefaultBlockSize*2+10)
+	addr, _ := startTestServer(t, map[string][]byte{"file.bin": want})
+
+	c := NewClient(addr)
+	c.Timeout = time.Second
+
+	rc, err := c.Get(context.Background(), "file.bin", "octet")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got := rc.Stats(); got != (Stats{}) {
+		t.Errorf("Stats() before Read = %+v, want zero value", got)
+	}
+
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if err := rc.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("read %d bytes, want %d", len(got), len(want))
+	}
+
+	stats := rc.Stats()
+	if stats.Bytes != int64(len(want)) {
+		t.Errorf("Stats.Bytes = %d, want %d", stats.Bytes, len(want))
+	}
+	if stats.Blocks != 3 {
+		t.Errorf("Stats.Blocks = %d, want 3", stats.Blocks)
+	}
+	if stats.BlockSize != DefaultBlockSize {
+		t.Errorf("Stats.BlockSize = %d, want %d", stats.BlockSize, DefaultBlockSize)
+	}
+	if stats.Duration <= 0 {
+		t.Error("Stats.Duration = 0, want a positive elapsed time")
+	}
+}
+
+func TestStatsThroughput(t *testing.T) {
+	s := Stats{Bytes: 1000, Duration: 2 * time.Second}
+	if got, want := s.Throughput(), 500.0; got != want {
+		t.Errorf("Throughput() = %v, want %v", got, want)
+	}
+	if got := (Stats{}).Throughput(); got != 0 {
+		t.Errorf("Throughput() on zero Stats = %v, want 0", got)
+	}
+}
+
+func TestServerOnTransferCompleteReportsStats(t *testing.T) {
+	want := bytes.Repeat([]byte("s"), DefaultBlockSize*2+10)
+	h := &memHandler{files: map[string][]byte{"file.bin": want}}
+
+	results := make(chan struct {
+		sess  Session
+		stats Stats
+		err   error
+	}, 1)
+	s := NewServer("", h)
+	s.OnTransferComplete = func(sess Session, stats Stats, err error) {
+		results <- struct {
+			sess  Session
+			stats Stats
+			err   error
+		}{sess, stats, err}
+	}
+
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s.conn = conn
+	go s.Serve(conn)
+	defer conn.Close()
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	if _, err := c.GetBytes("file.bin", "octet"); err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+
+	select {
+	case r := <-results:
+		if r.err != nil {
+			t.Errorf("OnTransferComplete err = %v, want nil", r.err)
+		}
+		if r.sess.Filename != "file.bin" {
+			t.Errorf("Session.Filename = %q, want file.bin", r.sess.Filename)
+		}
+		if r.stats.Bytes != int64(len(want)) {
+			t.Errorf("Stats.Bytes = %d, want %d", r.stats.Bytes, len(want))
+		}
+		if r.stats.Blocks != 3 {
+			t.Errorf("Stats.Blocks = %d, want 3", r.stats.Blocks)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for OnTransferComplete")
+	}
+}
@@ -0,0 +1,86 @@
+package tftp
+
+import "io"
+
+// streamingBlockSource adapts an io.Reader of unknown total length to
+// blockSource, for Put uploading a stream (stdin, a pipe) whose size
+// can't be known up front the way sequentialBlockSource's size
+// parameter requires. It discovers EOF one block at a time instead of
+// computing Len() from a byte count: Len() reads one block ahead of
+// whatever's already known, so sendData's "for next < blocks.Len()"
+// loop keeps advancing until the trailing short (or, for an
+// exact-multiple-of-blockSize input, empty) block has actually been
+// read off r. Every block read is cached, same as sequentialBlockSource,
+// so a retransmitted block is handed back byte-for-byte without
+// re-reading r.
+type streamingBlockSource struct {
+	r         io.Reader
+	blockSize int
+	blocks    [][]byte
+	done      bool
+	err       error
+}
+
+// newStreamingBlockSource returns a streamingBlockSource reading
+// blockSize-sized blocks from r on demand.
+func newStreamingBlockSource(r io.Reader, blockSize int) *streamingBlockSource {
+	return &streamingBlockSource{r: r, blockSize: blockSize}
+}
+
+// readNext reads one more block from r, if one hasn't already been
+// fully consumed, applying the same short/empty final block convention
+// as BlockIterator and sequentialBlockSource.
+func (s *streamingBlockSource) readNext() {
+	if s.done {
+		return
+	}
+	buf := make([]byte, s.blockSize)
+	n, err := io.ReadFull(s.r, buf)
+	switch err {
+	case nil:
+		s.blocks = append(s.blocks, buf)
+	case io.EOF, io.ErrUnexpectedEOF:
+		s.blocks = append(s.blocks, buf[:n])
+		s.done = true
+	default:
+		s.err = err
+		s.done = true
+	}
+}
+
+// Len reports how many blocks are known so far, reading one block ahead
+// from r first unless the trailing short/empty block has already been
+// seen. A windowSize greater than 1 therefore fills in over the first
+// few round trips rather than all at once, since nothing here knows how
+// large a window sendData actually wants.
+//
+// If r returned an error other than EOF, Len reports one more block
+// than has actually been read, so sendData's loop advances into it and
+// Block surfaces the error instead of the transfer silently ending as
+// if r had reached a clean EOF.
+func (s *streamingBlockSource) Len() int {
+	if !s.done {
+		s.readNext()
+	}
+	if s.err != nil {
+		return len(s.blocks) + 1
+	}
+	return len(s.blocks)
+}
+
+// Block returns the payload for the i'th block (0-indexed), reading
+// ahead as far as necessary if it hasn't been read yet. It panics if i
+// is out of range once r is exhausted, the same contract slice indexing
+// has.
+func (s *streamingBlockSource) Block(i int) ([]byte, error) {
+	for len(s.blocks) <= i && !s.done {
+		s.readNext()
+	}
+	if s.err != nil && i >= len(s.blocks) {
+		return nil, s.err
+	}
+	if i < 0 || i >= len(s.blocks) {
+		panic("tftp: streamingBlockSource index out of range")
+	}
+	return s.blocks[i], nil
+}
@@ -0,0 +1,36 @@
+package tftp
+
+// SyslogFacility selects which syslog facility a SyslogLogger tags its
+// messages with (RFC 5424 section 6.2.1's facility numbering). This is
+// a plain int type of this package's own, rather than an alias for
+// log/syslog's Priority, so NewSyslogLogger's signature builds
+// identically on every platform even though log/syslog itself isn't
+// implemented on windows/plan9.
+type SyslogFacility int
+
+const (
+	SyslogFacilityKern SyslogFacility = iota
+	SyslogFacilityUser
+	SyslogFacilityMail
+	SyslogFacilityDaemon
+	SyslogFacilityAuth
+	SyslogFacilitySyslog
+	SyslogFacilityLPR
+	SyslogFacilityNews
+	SyslogFacilityUUCP
+	SyslogFacilityCron
+	SyslogFacilityAuthpriv
+	SyslogFacilityFTP
+	_
+	_
+	_
+	_
+	SyslogFacilityLocal0
+	SyslogFacilityLocal1
+	SyslogFacilityLocal2
+	SyslogFacilityLocal3
+	SyslogFacilityLocal4
+	SyslogFacilityLocal5
+	SyslogFacilityLocal6
+	SyslogFacilityLocal7
+)
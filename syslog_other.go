@@ -0,0 +1,24 @@
+//go:build windows || plan9
+
+package tftp
+
+import "errors"
+
+// SyslogLogger is unavailable on this platform, since log/syslog itself
+// isn't implemented here (see log/syslog's package doc). NewSyslogLogger
+// always returns an error; the methods below exist only so code written
+// against SyslogLogger on other platforms still compiles here.
+type SyslogLogger struct{}
+
+// NewSyslogLogger always fails on this platform.
+func NewSyslogLogger(network, raddr string, facility SyslogFacility, tag string) (*SyslogLogger, error) {
+	return nil, errors.New("tftp: SyslogLogger is not supported on this platform")
+}
+
+func (l *SyslogLogger) Printf(format string, args ...any) {}
+
+func (l *SyslogLogger) Debugf(format string, args ...any) {}
+
+func (l *SyslogLogger) Warnf(format string, args ...any) {}
+
+func (l *SyslogLogger) Close() error { return nil }
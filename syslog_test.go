@@ -0,0 +1,98 @@
+package tftp
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSyslogLoggerWritesOverUDP dials a fake local syslog daemon over
+// UDP and checks that Printf produces a message containing the
+// formatted text and the configured tag, without depending on an actual
+// syslog daemon being present in the test environment.
+func TestSyslogLoggerWritesOverUDP(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer conn.Close()
+
+	l, err := NewSyslogLogger("udp", conn.LocalAddr().String(), SyslogFacilityDaemon, "tftpd")
+	if err != nil {
+		t.Fatalf("NewSyslogLogger: %v", err)
+	}
+	defer l.Close()
+
+	l.Printf("RRQ %s from %s", "boot.img", "10.0.0.5")
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, 1024)
+	n, _, err := conn.ReadFromUDP(buf)
+	if err != nil {
+		t.Fatalf("ReadFromUDP: %v", err)
+	}
+	msg := string(buf[:n])
+	if !strings.Contains(msg, "tftpd") || !strings.Contains(msg, "RRQ boot.img from 10.0.0.5") {
+		t.Errorf("syslog message = %q, want it to mention the tag and formatted text", msg)
+	}
+}
+
+func TestSyslogLoggerDebugfUsesDebugSeverity(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer conn.Close()
+
+	l, err := NewSyslogLogger("udp", conn.LocalAddr().String(), SyslogFacilityDaemon, "tftpd")
+	if err != nil {
+		t.Fatalf("NewSyslogLogger: %v", err)
+	}
+	defer l.Close()
+
+	var _ DebugLogger = l
+	l.Debugf("sent block=%d", 1)
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, 1024)
+	n, _, err := conn.ReadFromUDP(buf)
+	if err != nil {
+		t.Fatalf("ReadFromUDP: %v", err)
+	}
+	msg := string(buf[:n])
+	// RFC 3164 priority is "<facility*8+severity>"; LOG_DEBUG is
+	// severity 7, LOG_DAEMON is facility 3, so 3*8+7 = 31.
+	if !strings.HasPrefix(msg, "<31>") {
+		t.Errorf("syslog message = %q, want priority <31> (LOG_DAEMON|LOG_DEBUG)", msg)
+	}
+}
+
+func TestSyslogLoggerWarnfUsesWarningSeverity(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer conn.Close()
+
+	l, err := NewSyslogLogger("udp", conn.LocalAddr().String(), SyslogFacilityDaemon, "tftpd")
+	if err != nil {
+		t.Fatalf("NewSyslogLogger: %v", err)
+	}
+	defer l.Close()
+
+	var _ WarnLogger = l
+	l.Warnf("retry budget exhausted")
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, 1024)
+	n, _, err := conn.ReadFromUDP(buf)
+	if err != nil {
+		t.Fatalf("ReadFromUDP: %v", err)
+	}
+	msg := string(buf[:n])
+	// LOG_WARNING is severity 4, LOG_DAEMON is facility 3, so 3*8+4 = 28.
+	if !strings.HasPrefix(msg, "<28>") {
+		t.Errorf("syslog message = %q, want priority <28> (LOG_DAEMON|LOG_WARNING)", msg)
+	}
+}
@@ -0,0 +1,53 @@
+//go:build !windows && !plan9
+
+package tftp
+
+import (
+	"fmt"
+	"log/syslog"
+)
+
+// SyslogLogger adapts log/syslog to the Logger (and DebugLogger)
+// interface, for deployments that centralize on syslog instead of
+// scraping a process's stdout/stderr. Printf messages are written at
+// LOG_INFO severity; Debugf messages — the optional, much chattier
+// per-packet trace, see DebugLogger — are written at LOG_DEBUG, so an
+// operator can filter packet-level noise out at the syslog daemon
+// without this package needing its own verbosity knob.
+type SyslogLogger struct {
+	w *syslog.Writer
+}
+
+// NewSyslogLogger dials the syslog daemon named by network and raddr
+// (both empty connects to the local daemon over its UNIX socket; see
+// syslog.Dial) and returns a SyslogLogger tagging every message with
+// facility and tag.
+func NewSyslogLogger(network, raddr string, facility SyslogFacility, tag string) (*SyslogLogger, error) {
+	w, err := syslog.Dial(network, raddr, syslog.Priority(facility)<<3|syslog.LOG_INFO, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &SyslogLogger{w: w}, nil
+}
+
+// Printf writes an informational-severity syslog message.
+func (l *SyslogLogger) Printf(format string, args ...any) {
+	l.w.Info(fmt.Sprintf(format, args...))
+}
+
+// Debugf writes a debug-severity syslog message, implementing the
+// optional DebugLogger capability.
+func (l *SyslogLogger) Debugf(format string, args ...any) {
+	l.w.Debug(fmt.Sprintf(format, args...))
+}
+
+// Warnf writes a warning-severity syslog message, implementing the
+// optional WarnLogger capability.
+func (l *SyslogLogger) Warnf(format string, args ...any) {
+	l.w.Warning(fmt.Sprintf(format, args...))
+}
+
+// Close closes the underlying connection to the syslog daemon.
+func (l *SyslogLogger) Close() error {
+	return l.w.Close()
+}
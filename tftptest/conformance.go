@@ -0,0 +1,76 @@
+package tftptest
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	tftp "github.com/cjvirtucio87/tftp-go"
+)
+
+// ConformanceFiles are the filenames TestHandler and TestPayloadProvider
+// expect to be able to Get, covering the read-path edge cases most
+// backend implementations get wrong: an empty file, a file whose size
+// is an exact multiple of the default block size (where an
+// off-by-one in a backend's chunking can hide), and a file spanning
+// many blocks. Seed a Handler or PayloadProvider with exactly this
+// content before running either conformance suite against it.
+var ConformanceFiles = map[string][]byte{
+	"empty.bin": {},
+	"exact.bin": bytes.Repeat([]byte("e"), tftp.DefaultBlockSize*3),
+	"huge.bin":  bytes.Repeat([]byte("h"), tftp.DefaultBlockSize*50+7),
+}
+
+// ConformanceMissingFile is a filename ConformanceFiles deliberately
+// leaves unseeded, for exercising the missing-file edge case.
+const ConformanceMissingFile = "does-not-exist.bin"
+
+// TestHandler runs h, already seeded with ConformanceFiles (e.g. via
+// NewMemHandler(ConformanceFiles)), through a battery of subtests
+// covering TFTP's read-path protocol edge cases, the same role
+// testing/fstest.TestFS plays for an fs.FS implementation. Use it from
+// a third-party Handler implementation's own test suite to catch a
+// chunking bug, an off-by-one at a block boundary, or a missing-file
+// code path that doesn't return an error.
+func TestHandler(t *testing.T, h tftp.Handler) {
+	t.Helper()
+	s := tftp.NewServer("", h)
+	addr := NewServer(t, s)
+	c := tftp.NewClient(addr)
+	c.Timeout = time.Second
+
+	testConformanceReads(t, c)
+}
+
+// TestPayloadProvider is TestHandler's counterpart for a
+// tftp.PayloadProvider, serving ConformanceFiles through p instead of a
+// Handler.
+func TestPayloadProvider(t *testing.T, p tftp.PayloadProvider) {
+	t.Helper()
+	s := tftp.NewServer("", nil)
+	s.PayloadProvider = p
+	addr := NewServer(t, s)
+	c := tftp.NewClient(addr)
+	c.Timeout = time.Second
+
+	testConformanceReads(t, c)
+}
+
+func testConformanceReads(t *testing.T, c *tftp.Client) {
+	t.Helper()
+
+	t.Run("EmptyFile", func(t *testing.T) {
+		AssertGetEquals(t, c, "empty.bin", "octet", ConformanceFiles["empty.bin"])
+	})
+	t.Run("ExactBlockSizeMultiple", func(t *testing.T) {
+		AssertGetEquals(t, c, "exact.bin", "octet", ConformanceFiles["exact.bin"])
+	})
+	t.Run("HugeFile", func(t *testing.T) {
+		AssertGetEquals(t, c, "huge.bin", "octet", ConformanceFiles["huge.bin"])
+	})
+	t.Run("MissingFile", func(t *testing.T) {
+		if _, err := c.GetBytes(ConformanceMissingFile, "octet"); err == nil {
+			t.Fatalf("Get %s: want an error for a file that was never seeded", ConformanceMissingFile)
+		}
+	})
+}
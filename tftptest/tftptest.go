@@ -0,0 +1,233 @@
+// Package tftptest provides the scaffolding an application embedding
+// github.com/cjvirtucio87/tftp-go needs to write fast, deterministic
+// tests against it: an in-memory net.PacketConn pair for exercising
+// packet-level code without a real socket, a helper that runs a Server
+// on a loopback ephemeral port, a ready-made in-memory Handler, and a
+// couple of assert helpers for the polling most TFTP tests end up
+// needing anyway.
+package tftptest
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	tftp "github.com/cjvirtucio87/tftp-go"
+)
+
+// NewServer starts s — which the caller should have already configured
+// (Timeout, Retries, Logger, and so on) — listening on an ephemeral
+// loopback UDP port, and returns the address to dial it at. The
+// listening socket is closed when the test completes.
+func NewServer(t testing.TB, s *tftp.Server) string {
+	t.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("tftptest: ListenUDP: %v", err)
+	}
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+	return conn.LocalAddr().String()
+}
+
+// MemHandler is a tftp.Handler backed entirely by in-memory maps, for
+// tests that want a working server without touching a filesystem.
+// Files seeds what ReadFile serves; WriteFile records uploads where
+// Uploaded can observe them. The zero value is ready to use.
+type MemHandler struct {
+	mu       sync.Mutex
+	Files    map[string][]byte
+	uploaded map[string][]byte
+}
+
+// NewMemHandler returns a MemHandler that serves files for ReadFile.
+// files is not copied, so the caller shouldn't mutate it concurrently
+// with the server reading from it.
+func NewMemHandler(files map[string][]byte) *MemHandler {
+	return &MemHandler{Files: files}
+}
+
+// ReadFile implements tftp.Handler.
+func (h *MemHandler) ReadFile(filename string) ([]byte, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	data, ok := h.Files[filename]
+	if !ok {
+		return nil, errors.New("tftptest: no such file: " + filename)
+	}
+	return data, nil
+}
+
+// WriteFile implements tftp.Handler, recording data under filename so
+// a later Uploaded call can retrieve it.
+func (h *MemHandler) WriteFile(filename string, data []byte) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.uploaded == nil {
+		h.uploaded = make(map[string][]byte)
+	}
+	h.uploaded[filename] = data
+	return nil
+}
+
+// Uploaded returns the data an earlier WriteFile recorded for filename,
+// and whether anything has been uploaded under that name yet.
+func (h *MemHandler) Uploaded(filename string) ([]byte, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	data, ok := h.uploaded[filename]
+	return data, ok
+}
+
+// AssertGetEquals downloads filename with c and fails t if the
+// transfer errors or the content doesn't match want.
+func AssertGetEquals(t testing.TB, c *tftp.Client, filename, mode string, want []byte) {
+	t.Helper()
+	got, err := c.GetBytes(filename, mode)
+	if err != nil {
+		t.Fatalf("tftptest: Get %s: %v", filename, err)
+	}
+	if string(got) != string(want) {
+		t.Fatalf("tftptest: Get %s returned %d bytes, want %d", filename, len(got), len(want))
+	}
+}
+
+// AssertUploaded polls h for filename's uploaded content until it
+// matches want or timeout elapses, failing t otherwise. A server ACKs
+// the final DATA block (unblocking the client's Put) just before
+// calling Handler.WriteFile, so callers can't simply check immediately
+// after Put returns.
+func AssertUploaded(t testing.TB, h *MemHandler, filename string, want []byte, timeout time.Duration) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for {
+		if got, ok := h.Uploaded(filename); ok {
+			if string(got) != string(want) {
+				t.Fatalf("tftptest: %s uploaded %d bytes, want %d", filename, len(got), len(want))
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("tftptest: %s was never uploaded within %s", filename, timeout)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// pipeAddr is the net.Addr of one end of a PacketConnPair.
+type pipeAddr string
+
+func (a pipeAddr) Network() string { return "tftptest" }
+func (a pipeAddr) String() string  { return string(a) }
+
+// datagram is one packet in flight between the two ends of a
+// PacketConnPair, tagged with the sender's address the way a real
+// socket would tag an incoming UDP datagram.
+type datagram struct {
+	b    []byte
+	from net.Addr
+}
+
+// pipeConn is one end of an in-memory, unbuffered packet transport: a
+// WriteTo on this end is delivered to the peer's next ReadFrom, and
+// vice versa. It implements net.PacketConn, so it's a drop-in
+// substitute for a real UDP socket in code written against that
+// interface.
+type pipeConn struct {
+	local, peer net.Addr
+	send        chan<- datagram
+	recv        <-chan datagram
+
+	closeOnce sync.Once
+	closed    chan struct{}
+
+	mu           sync.Mutex
+	readDeadline time.Time
+}
+
+// pipeBacklog is how many datagrams a PacketConnPair end buffers
+// before WriteTo blocks, so a caller that writes and reads from the
+// same goroutine (the common case in a test) doesn't deadlock the way
+// an unbuffered channel would — real UDP sockets buffer outgoing
+// datagrams the same way.
+const pipeBacklog = 64
+
+// PacketConnPair returns two ends of an in-memory, connected
+// net.PacketConn pipe, for driving code written against net.PacketConn
+// — a Client or Server transfer loop, say — without binding a real UDP
+// socket. Each end sees the other as a distinct net.Addr.
+func PacketConnPair() (net.PacketConn, net.PacketConn) {
+	aToB := make(chan datagram, pipeBacklog)
+	bToA := make(chan datagram, pipeBacklog)
+	aAddr := pipeAddr("tftptest-a")
+	bAddr := pipeAddr("tftptest-b")
+	a := &pipeConn{local: aAddr, peer: bAddr, send: aToB, recv: bToA, closed: make(chan struct{})}
+	b := &pipeConn{local: bAddr, peer: aAddr, send: bToA, recv: aToB, closed: make(chan struct{})}
+	return a, b
+}
+
+func (c *pipeConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	c.mu.Lock()
+	deadline := c.readDeadline
+	c.mu.Unlock()
+
+	var timeoutCh <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		timeoutCh = timer.C
+	}
+
+	select {
+	case dgram := <-c.recv:
+		return copy(b, dgram.b), dgram.from, nil
+	case <-timeoutCh:
+		return 0, nil, errTimeout{}
+	case <-c.closed:
+		return 0, nil, net.ErrClosed
+	}
+}
+
+func (c *pipeConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	cp := make([]byte, len(b))
+	copy(cp, b)
+	select {
+	case c.send <- datagram{b: cp, from: c.local}:
+		return len(b), nil
+	case <-c.closed:
+		return 0, net.ErrClosed
+	}
+}
+
+func (c *pipeConn) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+	return nil
+}
+
+func (c *pipeConn) LocalAddr() net.Addr { return c.local }
+
+func (c *pipeConn) SetDeadline(t time.Time) error {
+	c.SetReadDeadline(t)
+	return nil
+}
+
+func (c *pipeConn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.readDeadline = t
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *pipeConn) SetWriteDeadline(t time.Time) error { return nil }
+
+// errTimeout is returned from pipeConn.ReadFrom once its read deadline
+// passes, the same role net.Error's Timeout() plays for a real socket.
+type errTimeout struct{}
+
+func (errTimeout) Error() string   { return "tftptest: i/o timeout" }
+func (errTimeout) Timeout() bool   { return true }
+func (errTimeout) Temporary() bool { return true }
+
+var _ net.Error = errTimeout{}
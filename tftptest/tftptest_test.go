@@ -0,0 +1,121 @@
+package tftptest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	tftp "github.com/cjvirtucio87/tftp-go"
+)
+
+func TestNewServerAndMemHandlerRoundTrip(t *testing.T) {
+	want := bytes.Repeat([]byte("x"), tftp.DefaultBlockSize*2+7)
+	h := NewMemHandler(map[string][]byte{"file.bin": want})
+	s := tftp.NewServer("", h)
+	addr := NewServer(t, s)
+
+	c := tftp.NewClient(addr)
+	c.Timeout = time.Second
+	AssertGetEquals(t, c, "file.bin", "octet", want)
+}
+
+func TestAssertUploadedSeesWrittenContent(t *testing.T) {
+	want := []byte("hostname switch-1\n")
+	h := NewMemHandler(nil)
+	s := tftp.NewServer("", h)
+	addr := NewServer(t, s)
+
+	c := tftp.NewClient(addr)
+	c.Timeout = time.Second
+	if _, err := c.Put(context.Background(), "config.txt", "octet", bytes.NewReader(want)); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	AssertUploaded(t, h, "config.txt", want, time.Second)
+}
+
+func TestHandlerConformance(t *testing.T) {
+	h := NewMemHandler(ConformanceFiles)
+	TestHandler(t, h)
+}
+
+func TestPayloadProviderConformance(t *testing.T) {
+	provider := func(_ context.Context, filename string, _ net.Addr) (io.ReadCloser, int64, error) {
+		data, ok := ConformanceFiles[filename]
+		if !ok {
+			return nil, 0, errors.New("tftptest: no such file: " + filename)
+		}
+		return io.NopCloser(bytes.NewReader(data)), int64(len(data)), nil
+	}
+	TestPayloadProvider(t, provider)
+}
+
+func TestMemHandlerReadFileMissing(t *testing.T) {
+	h := NewMemHandler(nil)
+	if _, err := h.ReadFile("missing.bin"); err == nil {
+		t.Fatal("ReadFile: want an error for a file that was never seeded")
+	}
+}
+
+func TestPacketConnPairDeliversDatagrams(t *testing.T) {
+	a, b := PacketConnPair()
+	defer a.Close()
+	defer b.Close()
+
+	if _, err := a.WriteTo([]byte("hello"), b.LocalAddr()); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	buf := make([]byte, 16)
+	n, from, err := b.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("ReadFrom: %v", err)
+	}
+	if string(buf[:n]) != "hello" {
+		t.Errorf("ReadFrom = %q, want %q", buf[:n], "hello")
+	}
+	if from.String() != a.LocalAddr().String() {
+		t.Errorf("from = %v, want %v", from, a.LocalAddr())
+	}
+}
+
+func TestPacketConnPairReadDeadline(t *testing.T) {
+	a, b := PacketConnPair()
+	defer a.Close()
+	defer b.Close()
+
+	b.SetReadDeadline(time.Now().Add(20 * time.Millisecond))
+	buf := make([]byte, 16)
+	_, _, err := b.ReadFrom(buf)
+	if err == nil {
+		t.Fatal("ReadFrom: want a timeout error when nothing was ever written")
+	}
+	netErr, ok := err.(interface{ Timeout() bool })
+	if !ok || !netErr.Timeout() {
+		t.Errorf("ReadFrom err = %v, want a net.Error with Timeout() == true", err)
+	}
+}
+
+func TestPacketConnPairCloseUnblocksReadFrom(t *testing.T) {
+	a, b := PacketConnPair()
+	defer a.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 16)
+		_, _, err := b.ReadFrom(buf)
+		done <- err
+	}()
+	b.Close()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("ReadFrom: want an error once the conn is closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("ReadFrom did not unblock after Close")
+	}
+}
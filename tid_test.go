@@ -0,0 +1,84 @@
+package tftp
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestReceiveDataFromRejectsStrayEndpoint simulates a second, unrelated
+// UDP endpoint sending DATA packets for the same transfer; the client
+// must ignore them (replying with an UnknownID ERROR) and only accept
+// packets from the address that answered the original RRQ.
+func TestReceiveDataFromRejectsStrayEndpoint(t *testing.T) {
+	client, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer client.Close()
+
+	real, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer real.Close()
+
+	stray, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer stray.Close()
+
+	clientAddr := client.LocalAddr().(*net.UDPAddr)
+	realAddr := real.LocalAddr().(*net.UDPAddr)
+
+	nego := NegotiatedOptions{BlockSize: DefaultBlockSize, WindowSize: DefaultWindowSize}
+	first := &DATA{Block: 1, Payload: bytes.Repeat([]byte("a"), DefaultBlockSize)}
+
+	var buf bytes.Buffer
+	done := make(chan error, 1)
+	go func() {
+		done <- receiveDataFrom(client, realAddr, nego, time.Second, 3, first, &buf, nil, -1, nil, nil, nil, nil)
+	}()
+
+	ackBuf := make([]byte, 4)
+	real.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, _, err := real.ReadFromUDP(ackBuf); err != nil {
+		t.Fatalf("expected ACK for block 1: %v", err)
+	}
+
+	// The stray endpoint sends a crossed block 2; the client should
+	// reject it with an UnknownID ERROR rather than accepting it.
+	strayPkt := &DATA{Block: 2, Payload: []byte("crossed")}
+	sb, _ := strayPkt.MarshalBinary()
+	stray.WriteToUDP(sb, clientAddr)
+
+	errBuf := make([]byte, 516)
+	stray.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := stray.ReadFromUDP(errBuf)
+	if err != nil {
+		t.Fatalf("expected an UnknownID ERROR back to the stray sender: %v", err)
+	}
+	var errPkt ERROR
+	if derr := errPkt.Decode(errBuf[:n]); derr != nil {
+		t.Fatalf("Decode ERROR: %v", derr)
+	}
+	if errPkt.Code != ErrCodeUnknownID {
+		t.Fatalf("Code = %v, want %v", errPkt.Code, ErrCodeUnknownID)
+	}
+
+	// Now the real endpoint completes the transfer normally.
+	last := &DATA{Block: 2, Payload: []byte("second")}
+	lb, _ := last.MarshalBinary()
+	real.WriteToUDP(lb, clientAddr)
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("receiveDataFrom: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for receiveDataFrom to finish via the real endpoint")
+	}
+}
@@ -0,0 +1,100 @@
+package tftp
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestServeRRQClosesSessionAfterIdleTimeout(t *testing.T) {
+	data := bytes.Repeat([]byte("x"), DefaultBlockSize*3)
+	h := &memHandler{files: map[string][]byte{"big.img": data}}
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s := NewServer("", h)
+	s.IdleTimeout = 30 * time.Millisecond
+	s.Timeout = time.Second
+	s.Retries = 50
+	done := make(chan struct{})
+	s.OnTransferComplete = func(sess Session, stats Stats, err error) {
+		if err != nil {
+			close(done)
+		}
+	}
+	s.conn = conn
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	raw, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer raw.Close()
+	req := &RRQ{Filename: "big.img", Mode: "octet"}
+	b, err := req.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary: %v", err)
+	}
+	if _, err := raw.WriteTo(b, conn.LocalAddr()); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	// Read (and discard) the first DATA block, then go silent: never ACK
+	// it, never send anything else, exactly like a client whose network
+	// stack wedged mid-transfer.
+	buf := make([]byte, 65507)
+	raw.SetReadDeadline(time.Now().Add(time.Second))
+	if _, _, err := raw.ReadFrom(buf); err != nil {
+		t.Fatalf("ReadFrom: %v", err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("idle session was never reaped")
+	}
+	if len(s.Sessions()) != 0 {
+		t.Fatalf("Sessions() = %d, want 0 after idle reap", len(s.Sessions()))
+	}
+}
+
+func TestServeRRQClosesSessionAfterMaxDuration(t *testing.T) {
+	data := bytes.Repeat([]byte("y"), DefaultBlockSize*20)
+	h := &memHandler{files: map[string][]byte{"big.img": data}}
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s := NewServer("", h)
+	// RateLimit stretches the transfer out so it's still in flight when
+	// MaxSessionDuration elapses, instead of finishing before the
+	// watchdog ever gets a chance to look at it.
+	s.RateLimit = 2000
+	s.MaxSessionDuration = 200 * time.Millisecond
+	s.Timeout = 500 * time.Millisecond
+	s.Retries = 10
+	done := make(chan struct{})
+	s.OnTransferComplete = func(sess Session, stats Stats, err error) {
+		if err != nil {
+			close(done)
+		}
+	}
+	s.conn = conn
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = 50 * time.Millisecond
+	// The client keeps ACKing normally; only the server-side wall clock
+	// decides this transfer has run long enough to cut off, so whether
+	// GetBytes itself succeeds or errors doesn't matter to this test.
+	_, _ = c.GetBytes("big.img", "octet")
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("session exceeding MaxSessionDuration was never reaped")
+	}
+}
@@ -0,0 +1,170 @@
+package tftp
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceEvent is one packet observed by a SessionTracer, decoded just
+// far enough to label on a sequence diagram. Unlike RecordedPacket, it
+// doesn't retain the raw payload, since a trace exists to be read by a
+// person rather than replayed.
+type TraceEvent struct {
+	At        time.Duration
+	Direction string // "sent" or "recv"
+	Addr      string
+	Label     string
+}
+
+// SessionTracer records a human-readable timeline of a single session's
+// RRQ/WRQ/OACK/DATA/ACK/ERROR traffic, for rendering as a sequence
+// diagram when debugging interop with a quirky device's firmware —
+// "device X stalls after block 213" is a lot easier to see as a
+// diagram than as a log file. Unlike PacketCapture and SessionRecorder,
+// which preserve a transfer's raw bytes for Wireshark or replay, a
+// SessionTracer only keeps each packet's decoded String() form.
+//
+// A SessionTracer is safe for concurrent use.
+type SessionTracer struct {
+	mu     sync.Mutex
+	events []TraceEvent
+	start  time.Time
+}
+
+// NewSessionTracer returns a SessionTracer measuring every TraceEvent's
+// At field from the moment it's created.
+func NewSessionTracer() *SessionTracer {
+	return &SessionTracer{start: time.Now()}
+}
+
+// Events returns a copy of the events traced so far, in the order they
+// occurred.
+func (t *SessionTracer) Events() []TraceEvent {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]TraceEvent(nil), t.events...)
+}
+
+func (t *SessionTracer) trace(direction string, addr net.Addr, payload []byte) {
+	if t == nil {
+		return
+	}
+	label := labelPacket(payload)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, TraceEvent{
+		At:        time.Since(t.start),
+		Direction: direction,
+		Addr:      addr.String(),
+		Label:     label,
+	})
+}
+
+// labelPacket decodes payload for display, falling back to a generic
+// label rather than failing outright, since a trace meant for human
+// debugging is more useful showing "malformed packet" than nothing at
+// all for a datagram that doesn't parse.
+func labelPacket(payload []byte) string {
+	p, err := ParsePacket(payload)
+	if err != nil {
+		return fmt.Sprintf("malformed packet (%d bytes)", len(payload))
+	}
+	return p.String()
+}
+
+// Middleware returns a PacketMiddleware that traces every datagram a
+// session sends or receives through t, leaving the wrapped conn's
+// behavior otherwise unchanged. It composes with FaultInjector,
+// SessionRecorder, and any other PacketMiddleware the way
+// Client.Middleware/Server.Middleware expect.
+func (t *SessionTracer) Middleware() PacketMiddleware {
+	return func(conn net.PacketConn) net.PacketConn {
+		return &tracingConn{PacketConn: conn, tracer: t}
+	}
+}
+
+// tracingConn wraps a net.PacketConn, tracing every WriteTo and
+// successful ReadFrom through its SessionTracer.
+type tracingConn struct {
+	net.PacketConn
+	tracer *SessionTracer
+}
+
+func (c *tracingConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	c.tracer.trace("sent", addr, b)
+	return c.PacketConn.WriteTo(b, addr)
+}
+
+func (c *tracingConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	n, addr, err := c.PacketConn.ReadFrom(b)
+	if err == nil {
+		c.tracer.trace("recv", addr, b[:n])
+	}
+	return n, addr, err
+}
+
+// RenderMermaid renders the traced events as a Mermaid sequence
+// diagram, suitable for pasting straight into a Markdown document or
+// the Mermaid Live Editor.
+func (t *SessionTracer) RenderMermaid() string {
+	events := t.Events()
+	var b strings.Builder
+	b.WriteString("sequenceDiagram\n")
+	b.WriteString("    participant Local\n")
+	if remote := remoteOf(events); remote != "" {
+		fmt.Fprintf(&b, "    participant Remote as %q\n", remote)
+	} else {
+		b.WriteString("    participant Remote\n")
+	}
+	for _, e := range events {
+		from, to := directionArrow(e.Direction)
+		fmt.Fprintf(&b, "    %s->>%s: %s (+%s)\n", from, to, e.Label, e.At)
+	}
+	return b.String()
+}
+
+// RenderPlantUML renders the traced events as a PlantUML sequence
+// diagram.
+func (t *SessionTracer) RenderPlantUML() string {
+	events := t.Events()
+	var b strings.Builder
+	b.WriteString("@startuml\n")
+	b.WriteString("participant Local\n")
+	if remote := remoteOf(events); remote != "" {
+		fmt.Fprintf(&b, "participant %q as Remote\n", remote)
+	} else {
+		b.WriteString("participant Remote\n")
+	}
+	for _, e := range events {
+		from, to := directionArrow(e.Direction)
+		fmt.Fprintf(&b, "%s -> %s: %s (+%s)\n", from, to, e.Label, e.At)
+	}
+	b.WriteString("@enduml\n")
+	return b.String()
+}
+
+// directionArrow maps a TraceEvent's Direction to the (from, to)
+// participant pair a diagram line should read as.
+func directionArrow(direction string) (from, to string) {
+	if direction == "recv" {
+		return "Remote", "Local"
+	}
+	return "Local", "Remote"
+}
+
+// remoteOf returns the remote address events were traced against, or
+// "" if events is empty. A session only ever talks to one remote
+// address once its TID is fixed, so the first event's Addr speaks for
+// all of them.
+func remoteOf(events []TraceEvent) string {
+	if len(events) == 0 {
+		return ""
+	}
+	return events[0].Addr
+}
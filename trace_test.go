@@ -0,0 +1,82 @@
+package tftp
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSessionTracerRecordsADecodedTimeline(t *testing.T) {
+	want := bytes.Repeat([]byte("x"), DefaultBlockSize*2+10)
+	addr, _ := startTestServer(t, map[string][]byte{"file.bin": want})
+
+	tr := NewSessionTracer()
+	c := NewClient(addr)
+	c.Middleware = append(c.Middleware, tr.Middleware())
+	got, err := c.GetBytes("file.bin", "octet")
+	if err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("GetBytes returned %d bytes, want %d", len(got), len(want))
+	}
+
+	events := tr.Events()
+	if len(events) == 0 {
+		t.Fatal("SessionTracer recorded no events")
+	}
+	if !strings.HasPrefix(events[0].Label, "RRQ file.bin") {
+		t.Errorf("first event label = %q, want prefix %q", events[0].Label, "RRQ file.bin")
+	}
+	if events[0].Direction != "sent" {
+		t.Errorf("first event direction = %q, want %q", events[0].Direction, "sent")
+	}
+
+	var sawData, sawACK bool
+	for _, e := range events {
+		if strings.HasPrefix(e.Label, "DATA") {
+			sawData = true
+		}
+		if strings.HasPrefix(e.Label, "ACK") {
+			sawACK = true
+		}
+	}
+	if !sawData || !sawACK {
+		t.Errorf("traced events missing DATA (%v) or ACK (%v)", sawData, sawACK)
+	}
+}
+
+func TestSessionTracerRenderMermaidAndPlantUML(t *testing.T) {
+	addr, _ := startTestServer(t, map[string][]byte{"file.bin": []byte("hello")})
+
+	tr := NewSessionTracer()
+	c := NewClient(addr)
+	c.Middleware = append(c.Middleware, tr.Middleware())
+	if _, err := c.GetBytes("file.bin", "octet"); err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+
+	mermaid := tr.RenderMermaid()
+	if !strings.HasPrefix(mermaid, "sequenceDiagram\n") {
+		t.Errorf("RenderMermaid doesn't start with sequenceDiagram header: %q", mermaid)
+	}
+	if !strings.Contains(mermaid, "Local->>Remote: RRQ file.bin octet") {
+		t.Errorf("RenderMermaid missing RRQ line:\n%s", mermaid)
+	}
+
+	plantuml := tr.RenderPlantUML()
+	if !strings.HasPrefix(plantuml, "@startuml\n") || !strings.HasSuffix(plantuml, "@enduml\n") {
+		t.Errorf("RenderPlantUML missing @startuml/@enduml wrapper:\n%s", plantuml)
+	}
+	if !strings.Contains(plantuml, "Local -> Remote: RRQ file.bin octet") {
+		t.Errorf("RenderPlantUML missing RRQ line:\n%s", plantuml)
+	}
+}
+
+func TestSessionTracerNilIsANoOp(t *testing.T) {
+	var tr *SessionTracer
+	if events := tr.Events(); events != nil {
+		t.Errorf("nil SessionTracer.Events() = %v, want nil", events)
+	}
+	tr.trace("sent", nil, []byte("x"))
+}
@@ -0,0 +1,302 @@
+package tftp
+
+import (
+	"errors"
+	"net"
+	"time"
+)
+
+var errTransferAborted = errors.New("tftp: transfer aborted after max retries")
+
+// isFailoverError reports whether err is one of the handshake failures
+// that Client.Addrs failover treats as "try the next address" rather
+// than giving up outright: the address never answered at all
+// (errTransferAborted) or it actively refused the connection
+// (errConnRefused, an ICMP port-unreachable).
+func isFailoverError(err error) bool {
+	return errors.Is(err, errTransferAborted) || errors.Is(err, errConnRefused)
+}
+
+// sendData streams blocks to addr as a sequence of DATA packets,
+// honoring the negotiated block and window size (RFC 7440). blocks
+// supplies each block's payload on demand, so a caller backed by an
+// io.ReaderAt (see ReaderAtBlockIterator) never has to hold the whole
+// transfer in memory. When the window contains more than one packet,
+// the platform's batchSend is used so the whole window can be handed to
+// the kernel in as few syscalls as possible. startBlock numbers the
+// first block in blocks; callers serving a full transfer from the
+// beginning pass 1, while a resumed download (see OptOffset) passes the
+// block number its aligned offset falls on. rto, if non-nil, replaces
+// timeout with a continuously adapting retransmission timeout: each
+// window's ACK wait reseeds it from the round trip just measured (see
+// rtoEstimator), so a transfer settles onto whatever RTT its path
+// actually has instead of living with the timeout it started with. When
+// rto is nil, policy instead governs the wait between retransmission
+// attempts (nil policy gets the default exponential-with-jitter
+// behavior; see retryDelay); rto and policy are mutually exclusive since
+// an adapting RTO already decides its own deadline.
+// limiters, if any are non-nil, each pace the window-send loop to at
+// most their own configured bytes/sec; a caller combining a
+// per-transfer limiter with a server-wide one gets both constraints
+// enforced, with the shared one providing fairness across concurrent
+// sessions since they all draw from the same bucket.
+// onProgress, if non-nil, is called with stats after each window is
+// fully ACKed, letting a caller surface live progress (e.g. Server's
+// EventProgress) without polling stats itself.
+func sendData(conn packetConn, addr *net.UDPAddr, blocks blockSource, nego NegotiatedOptions, timeout time.Duration, retries int, startBlock uint16, stats *transferStats, capture *PacketCapture, debug DebugLogger, onProgress func(*transferStats), rto *rtoEstimator, policy RetryPolicy, limiters ...*rateLimiter) error {
+	blockSize := nego.BlockSize
+	windowSize := nego.WindowSize
+	if windowSize < 1 {
+		windowSize = 1
+	}
+	local, _ := conn.LocalAddr().(*net.UDPAddr)
+
+	next := 0
+	// readBuf only ever needs to hold an ACK or ERROR, but it's sized
+	// like a DATA packet (plus one byte of slack) so a misbehaving peer
+	// can't have an oversized reply silently truncated into something
+	// that parses as a valid one; see errDatagramTooLarge.
+	readBuf := make([]byte, maxPacketSize(blockSize)+1)
+	// packetBuf backs every DATA packet in a window; AppendBinary grows
+	// it in place so a multi-GB transfer reuses one buffer instead of
+	// allocating a new []byte per block.
+	packetBuf := make([]byte, 0, windowSize*(blockSize+4))
+	packets := make([][]byte, windowSize)
+	windowPayloads := make([][]byte, windowSize)
+	var pkt DATA
+	for next < blocks.Len() {
+		end := next + windowSize
+		if end > blocks.Len() {
+			end = blocks.Len()
+		}
+		window := windowPayloads[:end-next]
+		for i := range window {
+			payload, err := blocks.Block(next + i)
+			if err != nil {
+				return err
+			}
+			window[i] = payload
+		}
+		packetBuf = packetBuf[:0]
+		for i, payload := range window {
+			pkt.Block = uint16((int(startBlock) + next + i) & 0xffff)
+			pkt.Payload = payload
+			start := len(packetBuf)
+			var err error
+			packetBuf, err = pkt.AppendBinary(packetBuf)
+			if err != nil {
+				return err
+			}
+			packets[i] = packetBuf[start:]
+		}
+		window2 := packets[:len(window)]
+
+		windowBytes := 0
+		for _, payload := range window {
+			windowBytes += len(payload)
+		}
+		for _, limiter := range limiters {
+			limiter.wait(windowBytes)
+		}
+
+		attempt := 0
+		acked := false
+		var windowSent time.Time
+		for !acked {
+			if attempt > retries {
+				return errTransferAborted
+			}
+			if udpConn, ok := conn.(*net.UDPConn); ok {
+				if err := batchSend(udpConn, addr, window2); err != nil {
+					return err
+				}
+			} else {
+				// A PacketMiddleware is in play; fall back to one
+				// WriteTo per packet so every write still passes
+				// through it, at the cost of the platform's GSO fast
+				// path (see PacketMiddleware's doc comment).
+				for _, pkt := range window2 {
+					if _, err := conn.WriteTo(pkt, addr); err != nil {
+						return err
+					}
+				}
+			}
+			for _, pkt := range window2 {
+				capture.sent(local, addr, pkt)
+				debugPacket(debug, "sent", addr, pkt)
+			}
+			if attempt > 0 {
+				if stats != nil {
+					stats.retransmissions++
+				}
+				if rto != nil {
+					rto.backoff()
+				}
+			}
+			windowSent = time.Now()
+			deadline := timeout
+			switch {
+			case rto != nil:
+				deadline = rto.timeout()
+			default:
+				var ok bool
+				deadline, ok = retryDelay(policy, timeout, attempt)
+				if !ok {
+					return errTransferAborted
+				}
+			}
+			conn.SetReadDeadline(windowSent.Add(deadline))
+			n, from, err := conn.ReadFrom(readBuf)
+			if err != nil {
+				attempt++
+				if stats != nil {
+					stats.timeouts++
+				}
+				continue
+			}
+			if n == len(readBuf) {
+				attempt++
+				continue
+			}
+			capture.received(local, from, readBuf[:n])
+			debugPacket(debug, "received", from, readBuf[:n])
+			if !sameHost(from, addr) {
+				writePacket(conn, NewError(ErrCodeUnknownID, "unexpected TID"), from, capture, debug)
+				continue
+			}
+			if opcodeOf(readBuf[:n]) == OpERROR {
+				errPkt := &ERROR{}
+				if derr := errPkt.Decode(readBuf[:n]); derr == nil {
+					return errPkt
+				}
+				attempt++
+				continue
+			}
+			if opcodeOf(readBuf[:n]) != OpACK {
+				attempt++
+				continue
+			}
+			var ack ACK
+			if err := ack.Decode(readBuf[:n]); err != nil {
+				attempt++
+				continue
+			}
+			lastBlock := uint16((int(startBlock) + next + len(window) - 1) & 0xffff)
+			if ack.Block == lastBlock {
+				acked = true
+				if rto != nil && attempt == 0 {
+					rto.sample(time.Since(windowSent))
+				}
+			} else {
+				attempt++
+				if stats != nil {
+					stats.duplicateACKs++
+				}
+			}
+		}
+		if stats != nil {
+			stats.blocks += len(window)
+			for _, payload := range window {
+				stats.bytes += int64(len(payload))
+			}
+		}
+		if onProgress != nil {
+			onProgress(stats)
+		}
+		next = end
+	}
+	return nil
+}
+
+// receiveData accepts a WRQ's DATA stream, ACKing each block as it
+// arrives. Window pipelining on receive still ACKs per-block, since RFC
+// 7440 windowing only changes how many unacked DATA packets the sender
+// may have in flight, not how receive-side ACKs work. policy governs
+// the wait between attempts while stalled on the next DATA block; nil
+// gets the default exponential-with-jitter behavior (see retryDelay).
+// onProgress, if non-nil, is called with stats after each block is
+// ACKed; see sendData's doc comment for why.
+func receiveData(conn packetConn, addr *net.UDPAddr, nego NegotiatedOptions, timeout time.Duration, retries int, stats *transferStats, capture *PacketCapture, debug DebugLogger, onProgress func(*transferStats), policy RetryPolicy) ([]byte, error) {
+	var out []byte
+	expected := uint16(1)
+	// buf is sized one byte larger than the biggest legitimate DATA
+	// packet, so a datagram that fills it completely is distinguishable
+	// from a full-size final block; see errDatagramTooLarge.
+	buf := make([]byte, maxPacketSize(nego.BlockSize)+1)
+	var pkt DATA
+	local, _ := conn.LocalAddr().(*net.UDPAddr)
+
+	for {
+		attempt := 0
+		data := (*DATA)(nil)
+		for data == nil {
+			if attempt > retries {
+				return nil, errTransferAborted
+			}
+			delay, ok := retryDelay(policy, timeout, attempt)
+			if !ok {
+				return nil, errTransferAborted
+			}
+			conn.SetReadDeadline(time.Now().Add(delay))
+			n, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				attempt++
+				if stats != nil {
+					stats.timeouts++
+				}
+				continue
+			}
+			if n == len(buf) {
+				attempt++
+				continue
+			}
+			capture.received(local, from, buf[:n])
+			debugPacket(debug, "received", from, buf[:n])
+			if !sameHost(from, addr) {
+				writePacket(conn, NewError(ErrCodeUnknownID, "unexpected TID"), from, capture, debug)
+				continue
+			}
+			if opcodeOf(buf[:n]) == OpERROR {
+				errPkt := &ERROR{}
+				if derr := errPkt.Decode(buf[:n]); derr == nil {
+					return nil, errPkt
+				}
+				attempt++
+				continue
+			}
+			if opcodeOf(buf[:n]) != OpDATA {
+				attempt++
+				continue
+			}
+			if err := pkt.Decode(buf[:n]); err != nil {
+				attempt++
+				continue
+			}
+			data = &pkt
+		}
+
+		if data.Block == expected {
+			out = append(out, data.Payload...)
+			if err := writePacket(conn, &ACK{Block: data.Block}, addr, capture, debug); err != nil {
+				return nil, err
+			}
+			if stats != nil {
+				stats.blocks++
+				stats.bytes += int64(len(data.Payload))
+			}
+			if onProgress != nil {
+				onProgress(stats)
+			}
+			if len(data.Payload) < nego.BlockSize {
+				return out, nil
+			}
+			expected++
+		} else if data.Block == expected-1 {
+			// Duplicate of the previous block; re-ACK it.
+			writePacket(conn, &ACK{Block: data.Block}, addr, capture, debug)
+			if stats != nil {
+				stats.retransmissions++
+			}
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package tftp
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSendDataReturnsTypedErrorOnReceiverAbort(t *testing.T) {
+	sender, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer sender.Close()
+	receiver, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer receiver.Close()
+
+	nego := NegotiatedOptions{BlockSize: DefaultBlockSize, WindowSize: DefaultWindowSize}
+	data := bytes.Repeat([]byte("a"), DefaultBlockSize+5)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- sendData(sender, receiver.LocalAddr().(*net.UDPAddr), NewBlockIterator(data, nego.BlockSize), nego, time.Second, 3, 1, nil, nil, nil, nil, nil, nil, nil)
+	}()
+
+	dataBuf := make([]byte, DefaultBlockSize+4)
+	receiver.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, _, err := receiver.ReadFromUDP(dataBuf); err != nil {
+		t.Fatalf("expected first DATA block: %v", err)
+	}
+	abort := NewError(ErrCodeDiskFull, "disk full")
+	ab, _ := abort.MarshalBinary()
+	receiver.WriteToUDP(ab, sender.LocalAddr().(*net.UDPAddr))
+
+	select {
+	case err := <-done:
+		var got *ERROR
+		if !errors.As(err, &got) {
+			t.Fatalf("sendData err = %v, want a *tftp.ERROR", err)
+		}
+		if got.Code != ErrCodeDiskFull {
+			t.Errorf("Code = %v, want %v", got.Code, ErrCodeDiskFull)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for sendData to report the receiver's ERROR")
+	}
+}
+
+func TestReceiveDataReturnsTypedErrorOnSenderAbort(t *testing.T) {
+	receiver, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer receiver.Close()
+	sender, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer sender.Close()
+
+	nego := NegotiatedOptions{BlockSize: DefaultBlockSize, WindowSize: DefaultWindowSize}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := receiveData(receiver, sender.LocalAddr().(*net.UDPAddr), nego, time.Second, 3, nil, nil, nil, nil, nil)
+		done <- err
+	}()
+
+	abort := NewError(ErrCodeIllegalOp, "client aborted")
+	ab, _ := abort.MarshalBinary()
+	sender.WriteToUDP(ab, receiver.LocalAddr().(*net.UDPAddr))
+
+	select {
+	case err := <-done:
+		var got *ERROR
+		if !errors.As(err, &got) {
+			t.Fatalf("receiveData err = %v, want a *tftp.ERROR", err)
+		}
+		if got.Code != ErrCodeIllegalOp {
+			t.Errorf("Code = %v, want %v", got.Code, ErrCodeIllegalOp)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for receiveData to report the sender's ERROR")
+	}
+}
@@ -0,0 +1,99 @@
+package tftp
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestServeWRQRejectsContentFromUploadValidator(t *testing.T) {
+	// A WRQ's last DATA block is ACKed (see receiveData) before the
+	// server runs ContentValidators against the assembled content, so a
+	// rejection here can't retroactively fail the client's Put the way a
+	// Validator rejection fails an RRQ/WRQ up front — by the time it's
+	// known, the client has already moved on. What a rejection can still
+	// do is keep the content out of Handler and report the failure via
+	// OnTransferComplete.
+	h := &memHandler{files: map[string][]byte{}}
+	s := NewServer("", h)
+	s.ContentValidators = append(s.ContentValidators, func(sess *Session, data []byte) *Rejection {
+		if !bytes.HasPrefix(data, []byte("MZ")) {
+			return &Rejection{Code: ErrCodeAccessViolation, Message: "{{.Filename}}: not a recognized image"}
+		}
+		return nil
+	})
+	failed := make(chan error, 1)
+	s.OnTransferComplete = func(sess Session, stats Stats, err error) {
+		if sess.Op == OpWRQ && err != nil {
+			failed <- err
+		}
+	}
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s.conn = conn
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	if _, err := c.Put(context.Background(), "bad.bin", "octet", bytes.NewReader([]byte("not an image"))); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	select {
+	case err := <-failed:
+		if err == nil {
+			t.Fatal("OnTransferComplete: want a non-nil rejection error")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("OnTransferComplete was never called with a rejection error")
+	}
+	if _, ok := h.get("bad.bin"); ok {
+		t.Fatal("rejected content was written to the Handler")
+	}
+}
+
+func TestServeWRQAcceptsContentPassingUploadValidator(t *testing.T) {
+	h := &memHandler{files: map[string][]byte{}}
+	s := NewServer("", h)
+	s.ContentValidators = append(s.ContentValidators, func(sess *Session, data []byte) *Rejection {
+		if !bytes.HasPrefix(data, []byte("MZ")) {
+			return &Rejection{Code: ErrCodeAccessViolation, Message: "not a recognized image"}
+		}
+		return nil
+	})
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s.conn = conn
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	want := append([]byte("MZ"), []byte("rest of the image")...)
+	if _, err := c.Put(context.Background(), "good.bin", "octet", bytes.NewReader(want)); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	var got []byte
+	var ok bool
+	for time.Now().Before(deadline) {
+		if got, ok = h.get("good.bin"); ok {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !ok {
+		t.Fatal("server never recorded the uploaded file")
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("stored %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,109 @@
+package tftp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WebhookPayload is the JSON body WebhookNotifier POSTs for every
+// completed or failed transfer.
+type WebhookPayload struct {
+	Time       time.Time `json:"time"`
+	Client     string    `json:"client"`
+	Filename   string    `json:"filename"`
+	Direction  string    `json:"direction"` // "read" (RRQ) or "write" (WRQ)
+	Bytes      int64     `json:"bytes"`
+	DurationMS int64     `json:"duration_ms"`
+	Result     string    `json:"result"` // "ok" or "error"
+	Error      string    `json:"error,omitempty"`
+}
+
+// DefaultWebhookTimeout bounds how long WebhookNotifier waits for the
+// configured URL to respond, so a slow or unreachable webhook can never
+// hang the goroutine posting to it for longer than this.
+const DefaultWebhookTimeout = 5 * time.Second
+
+// WebhookNotifier POSTs a WebhookPayload to URL for every completed or
+// failed transfer, so an inventory or provisioning system can be pushed
+// to rather than have to tail AuditLog's JSON-lines output. Assign an
+// instance to Server.Webhook to opt in; leaving it nil (the default)
+// sends nothing.
+type WebhookNotifier struct {
+	// URL receives one HTTP POST per completed or failed transfer.
+	URL string
+	// Client performs the POST. Defaults to an *http.Client with
+	// DefaultWebhookTimeout when left nil.
+	Client *http.Client
+	// OnError, if set, is called with any error posting to URL — a
+	// non-2xx response, a timeout, a connection failure — so an
+	// embedder can alert on a broken webhook without this package
+	// taking a position on how (Logger, metrics, a paging system...).
+	OnError func(error)
+}
+
+// NewWebhookNotifier returns a WebhookNotifier that POSTs to url using
+// an *http.Client with DefaultWebhookTimeout.
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{URL: url, Client: &http.Client{Timeout: DefaultWebhookTimeout}}
+}
+
+// notify is a no-op on a nil *WebhookNotifier, so Server can call it
+// unconditionally regardless of whether Webhook was set. The POST runs
+// on its own goroutine, so a slow or unreachable URL never delays
+// reportTransfer's caller.
+func (w *WebhookNotifier) notify(sess Session, stats Stats, err error) {
+	if w == nil {
+		return
+	}
+	payload := WebhookPayload{
+		Time:       time.Now(),
+		Client:     sess.Addr.String(),
+		Filename:   sess.Filename,
+		Direction:  directionOf(sess.Op),
+		Bytes:      stats.Bytes,
+		DurationMS: stats.Duration.Milliseconds(),
+		Result:     "ok",
+	}
+	if err != nil {
+		payload.Result = "error"
+		payload.Error = err.Error()
+	}
+	go w.post(payload)
+}
+
+func (w *WebhookNotifier) post(payload WebhookPayload) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		w.reportError(err)
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		w.reportError(err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := w.Client
+	if client == nil {
+		client = &http.Client{Timeout: DefaultWebhookTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		w.reportError(err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		w.reportError(fmt.Errorf("tftp: webhook %s: unexpected status %s", w.URL, resp.Status))
+	}
+}
+
+func (w *WebhookNotifier) reportError(err error) {
+	if w.OnError != nil {
+		w.OnError(err)
+	}
+}
@@ -0,0 +1,146 @@
+package tftp
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWebhookNotifierPostsSuccessAndFailure(t *testing.T) {
+	var mu sync.Mutex
+	var got []WebhookPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p WebhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			t.Errorf("Decode: %v", err)
+			return
+		}
+		mu.Lock()
+		got = append(got, p)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	n := NewWebhookNotifier(srv.URL)
+	sess := Session{Addr: &net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 6900}, Filename: "firmware.bin", Op: OpRRQ}
+	n.notify(sess, Stats{Bytes: 2048, Duration: 250 * time.Millisecond}, nil)
+	n.notify(sess, Stats{Bytes: 512}, NewError(ErrCodeDiskFull, "disk full"))
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		n := len(got)
+		mu.Unlock()
+		if n >= 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for 2 webhook posts, got %d", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// The two notify calls POST on their own goroutines with nothing
+	// ordering them relative to each other, so identify each payload by
+	// its Result rather than assuming got[0]/got[1] arrived in the order
+	// notify was called.
+	var ok, failed *WebhookPayload
+	for i := range got {
+		switch got[i].Result {
+		case "ok":
+			ok = &got[i]
+		case "error":
+			failed = &got[i]
+		}
+	}
+	if ok == nil {
+		t.Fatalf("no success payload among %+v", got)
+	}
+	if failed == nil {
+		t.Fatalf("no failure payload among %+v", got)
+	}
+
+	if ok.Client != "10.0.0.1:6900" || ok.Filename != "firmware.bin" || ok.Direction != "read" {
+		t.Errorf("success payload = %+v, want client/filename/direction set from sess", ok)
+	}
+	if ok.Bytes != 2048 || ok.DurationMS != 250 {
+		t.Errorf("success payload = %+v, want a clean success", ok)
+	}
+	if failed.Error == "" {
+		t.Errorf("failure payload = %+v, want a non-empty Error", failed)
+	}
+}
+
+func TestWebhookNotifierNilIsNoOp(t *testing.T) {
+	var n *WebhookNotifier
+	n.notify(Session{}, Stats{}, nil)
+}
+
+func TestWebhookNotifierReportsNonSuccessStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	errCh := make(chan error, 1)
+	n := NewWebhookNotifier(srv.URL)
+	n.OnError = func(err error) { errCh <- err }
+	n.notify(Session{Addr: &net.UDPAddr{}, Filename: "f"}, Stats{}, nil)
+
+	select {
+	case err := <-errCh:
+		if err == nil {
+			t.Error("OnError: want a non-nil error")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for OnError")
+	}
+}
+
+func TestServeRRQNotifiesWebhook(t *testing.T) {
+	errCh := make(chan error, 1)
+	got := make(chan WebhookPayload, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p WebhookPayload
+		json.NewDecoder(r.Body).Decode(&p)
+		got <- p
+	}))
+	defer srv.Close()
+
+	h := &memHandler{files: map[string][]byte{"file.bin": bytes.Repeat([]byte("w"), DefaultBlockSize*2)}}
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	s := NewServer("", h)
+	s.Webhook = NewWebhookNotifier(srv.URL)
+	s.Webhook.OnError = func(err error) { errCh <- err }
+	s.conn = conn
+	go s.Serve(conn)
+	t.Cleanup(func() { conn.Close() })
+
+	c := NewClient(conn.LocalAddr().String())
+	c.Timeout = time.Second
+	if _, err := c.GetBytes("file.bin", "octet"); err != nil {
+		t.Fatalf("GetBytes: %v", err)
+	}
+
+	select {
+	case p := <-got:
+		if p.Filename != "file.bin" || p.Direction != "read" || p.Result != "ok" {
+			t.Errorf("payload = %+v, want a clean read of file.bin", p)
+		}
+	case err := <-errCh:
+		t.Fatalf("OnError: %v", err)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for a webhook POST")
+	}
+}